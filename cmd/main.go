@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"reports-system/internal/app/handlers"
 	"reports-system/internal/domain/entities"
@@ -26,41 +29,80 @@ func main() {
 		log.Fatal("Database connection details are not set in environment variables")
 	}
 
-	var db entities.Database
-	var err error
-
-	if os.Getenv("DB_TYPE") == "postgres" {
-		log.Println("Using Postgres database")
-		postgress := database.PostgresDB{}
-		db, err = postgress.NewDB()
-		if err != nil {
-
-			fmt.Printf("Failed to connect to database: %s\n", err.Error())
-			log.Fatal("Failed to connect to database:", err)
+	db, err := openDatabase("DB_")
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer db.Close()
 
+	// Inicializar cache
+	var cacheProvider entities.CacheProvider
+	if os.Getenv("CACHE_PROVIDER") == "file" {
+		cacheDir := os.Getenv("CACHE_DIR")
+		if cacheDir == "" {
+			cacheDir = "./data/cache"
 		}
-		defer db.Close()
-
-	} else if os.Getenv("DB_TYPE") == "sqlserver" {
-		log.Println("Using SQL Server database")
-		sqlServer := database.SqlServerDB{}
-		db, err = sqlServer.NewDB()
+		maxBytes, _ := strconv.ParseInt(os.Getenv("CACHE_MAX_BYTES"), 10, 64)
+		cacheProvider, err = cache.NewFileCache(cacheDir, maxBytes)
 		if err != nil {
-			log.Fatal("Failed to connect to database:", err)
+			log.Fatal("Failed to initialize file cache:", err)
 		}
-		defer db.Close()
+		log.Printf("Using file cache at %s", cacheDir)
 	} else {
-		log.Fatal("Unsupported database type. Please set DB_TYPE to 'postgres' or 'sql	server'")
+		cacheProvider = cache.NewMemoryCache()
 	}
 
-	// Inicializar cache
-	cacheProvider := cache.NewMemoryCache()
+	// Compressão opcional dos valores em cache (gzip, zstd ou none)
+	if compression := os.Getenv("CACHE_COMPRESSION"); compression != "" && compression != "none" {
+		threshold, _ := strconv.Atoi(os.Getenv("CACHE_COMPRESSION_THRESHOLD"))
+		cacheProvider, err = cache.NewCompressedCache(cacheProvider, compression, threshold)
+		if err != nil {
+			log.Fatal("Failed to configure cache compression:", err)
+		}
+		log.Printf("Cache compression enabled: %s", compression)
+	}
 
 	// Inicializar serviços
 	confReports := os.Getenv("CONFIG_REPORTS")
 	reportService := usecase.NewReportService(db, cacheProvider, confReports) // Exemplo de caminho
 	reportHandler := handlers.NewReportHandler(reportService)
 
+	// Prazo de execução das queries (padrão 30s)
+	if timeout := os.Getenv("DB_QUERY_TIMEOUT"); timeout != "" {
+		duration, err := time.ParseDuration(timeout)
+		if err != nil {
+			log.Fatal("Invalid DB_QUERY_TIMEOUT:", err)
+		}
+		reportService.SetQueryTimeout(duration)
+	}
+
+	// Credenciais aceitas nos relatórios com "require_auth" (API_KEYS=k1,k2);
+	// sem a variável qualquer credencial informada é aceita
+	if keys := os.Getenv("API_KEYS"); keys != "" {
+		reportService.SetAPIKeys(strings.Split(keys, ","))
+	}
+
+	// Datasources adicionais, usados pelos relatórios com "datasource":
+	// DATASOURCES=legacy,dw com DB_LEGACY_TYPE, DB_LEGACY_HOST, ...
+	for _, name := range strings.Split(os.Getenv("DATASOURCES"), ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		datasource, err := openDatabase("DB_" + strings.ToUpper(name) + "_")
+		if err != nil {
+			log.Fatalf("Failed to connect to datasource %s: %v", name, err)
+		}
+		defer datasource.Close()
+		reportService.RegisterDatasource(name, datasource)
+	}
+	if err := reportService.ValidateDatasources(); err != nil {
+		log.Fatal("Invalid report configuration:", err)
+	}
+
+	// Pré-carregar no cache os relatórios com warmup agendado
+	reportService.StartCacheWarming()
+	defer reportService.StopCacheWarming()
+
 	// Configurar Fiber
 	app := fiber.New(fiber.Config{
 		ErrorHandler: func(c fiber.Ctx, err error) error {
@@ -84,6 +126,7 @@ func main() {
 	api.Get("/reports", reportHandler.GetAvailableReports)
 	api.Get("/reports/:report_id", reportHandler.GetReport)
 	api.Post("/reports/:report_id", reportHandler.PostReport)
+	api.Get("/reports/:report_id/params/:param/options", reportHandler.GetParamOptions)
 
 	// Health check
 	api.Get("/health", func(c fiber.Ctx) error {
@@ -102,3 +145,17 @@ func main() {
 	log.Printf("Server starting on port %s", port)
 	log.Fatal(app.Listen(":" + port))
 }
+
+// openDatabase conecta ao banco descrito pelas variáveis com o prefixo
+// informado (DB_TYPE, DB_HOST, ...)
+func openDatabase(prefix string) (entities.Database, error) {
+	switch os.Getenv(prefix + "TYPE") {
+	case "postgres":
+		log.Printf("Using Postgres database (%s*)", prefix)
+		return (&database.PostgresDB{Prefix: prefix}).NewDB()
+	case "sqlserver":
+		log.Printf("Using SQL Server database (%s*)", prefix)
+		return (&database.SqlServerDB{Prefix: prefix}).NewDB()
+	}
+	return nil, fmt.Errorf("unsupported database type. Please set %sTYPE to 'postgres' or 'sqlserver'", prefix)
+}
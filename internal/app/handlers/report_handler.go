@@ -1,12 +1,16 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"reports-system/internal/domain/entities"
 	"reports-system/internal/usecase"
+	reportfile "reports-system/pkg/report"
 
 	"github.com/gofiber/fiber/v3"
 )
@@ -23,51 +27,82 @@ func (h *ReportHandler) GetReport(c fiber.Ctx) error {
 	reportID := c.Params("report_id")
 	format := c.Query("format", "json")
 
-	println("Report ID:", reportID)
+	if err := h.service.Authorize(reportID, requestCredentials(c)); err != nil {
+		return h.sendError(c, err)
+	}
 
 	// Extrair parâmetros da query string
 	params := make(map[string]interface{})
 	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
 		keyStr := string(key)
-		if keyStr != "format" {
-			valueStr := string(value)
-			// Tentar converter para número se possível
-			if num, err := strconv.ParseFloat(valueStr, 64); err == nil {
-				params[keyStr] = num
-			} else {
-				params[keyStr] = valueStr
+		if !reservedQueryArgs[keyStr] {
+			// Os valores seguem como texto; a conversão para o tipo
+			// declarado é feita pela validação da query
+			var parsed interface{} = string(value)
+
+			// Chaves repetidas (?regions=a&regions=b) viram lista
+			switch existing := params[keyStr].(type) {
+			case nil:
+				params[keyStr] = parsed
+			case []interface{}:
+				params[keyStr] = append(existing, parsed)
+			default:
+				params[keyStr] = []interface{}{existing, parsed}
 			}
 		}
 	})
 
-	report, err := h.service.GetReport(reportID, params, format)
+	page, err := pageRequest(c.Query("page"), c.Query("page_size"), c.Query("cursor"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return h.sendError(c, err)
 	}
 
-	switch strings.ToLower(format) {
-	case "csv":
-		return h.renderCSV(c, report)
-	case "xlsx":
-		return h.renderXLSX(c, report)
-	default:
-		return c.JSON(report)
+	report, err := h.service.GetReport(reportID, params, entities.ReportOptions{
+		Format:   format,
+		Timezone: c.Query("tz", c.Get("X-Timezone")),
+		Page:     page,
+		Sort:     splitList(c.Query("sort")),
+		Fields:   splitList(c.Query("fields")),
+		Shape:    c.Query("shape"),
+	})
+	if err != nil {
+		return h.sendError(c, err)
 	}
+
+	h.setCacheHeaders(c, report)
+	if etag := reportETag(report); etag != "" {
+		c.Set(fiber.HeaderETag, etag)
+		if etagMatches(c.Get(fiber.HeaderIfNoneMatch), etag) {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+	}
+
+	return h.sendReport(c, report, format)
 }
 
 func (h *ReportHandler) PostReport(c fiber.Ctx) error {
 	reportID := c.Params("report_id")
 
+	if err := h.service.Authorize(reportID, requestCredentials(c)); err != nil {
+		return h.sendError(c, err)
+	}
+
 	var requestBody struct {
-		Params map[string]interface{} `json:"params"`
-		Format string                 `json:"format"`
+		Params   map[string]interface{} `json:"params"`
+		Format   string                 `json:"format"`
+		Timezone string                 `json:"timezone"`
+		Page     int                    `json:"page"`
+		PageSize int                    `json:"page_size"`
+		Cursor   string                 `json:"cursor"`
+		Sort     []string               `json:"sort"`
+		Fields   []string               `json:"fields"`
+		Shape    string                 `json:"shape"`
 	}
 
 	if err := c.Bind().JSON(&requestBody); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Invalid JSON body",
+			"code":  "invalid_request",
 		})
 	}
 
@@ -75,14 +110,126 @@ func (h *ReportHandler) PostReport(c fiber.Ctx) error {
 		requestBody.Format = "json"
 	}
 
-	report, err := h.service.GetReport(reportID, requestBody.Params, requestBody.Format)
+	if requestBody.Timezone == "" {
+		requestBody.Timezone = c.Get("X-Timezone")
+	}
+
+	options := entities.ReportOptions{
+		Format:   requestBody.Format,
+		Timezone: requestBody.Timezone,
+		Sort:     requestBody.Sort,
+		Fields:   requestBody.Fields,
+		Shape:    requestBody.Shape,
+	}
+	if requestBody.Page != 0 || requestBody.PageSize != 0 || requestBody.Cursor != "" {
+		options.Page = &entities.PageRequest{
+			Page:     requestBody.Page,
+			PageSize: requestBody.PageSize,
+			Cursor:   requestBody.Cursor,
+		}
+	}
+
+	report, err := h.service.GetReport(reportID, requestBody.Params, options)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return h.sendError(c, err)
+	}
+
+	// ETag e respostas 304 valem apenas para GET
+	h.setCacheHeaders(c, report)
+
+	return h.sendReport(c, report, requestBody.Format)
+}
+
+// Argumentos da query string que controlam a resposta e não são parâmetros
+// do relatório
+var reservedQueryArgs = map[string]bool{
+	"format":    true,
+	"tz":        true,
+	"page":      true,
+	"page_size": true,
+	"cursor":    true,
+	"sort":      true,
+	"fields":    true,
+	"shape":     true,
+}
+
+// requestCredentials retorna o token do cabeçalho Authorization (com ou sem o
+// esquema Bearer) ou, na sua ausência, o do cabeçalho X-API-Key
+func requestCredentials(c fiber.Ctx) string {
+	if authorization := strings.TrimSpace(c.Get(fiber.HeaderAuthorization)); authorization != "" {
+		if scheme, token, ok := strings.Cut(authorization, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return authorization
+	}
+	return strings.TrimSpace(c.Get("X-API-Key"))
+}
+
+// splitList separa valores informados por vírgula (?sort=-total,region)
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// pageRequest monta a paginação pedida na query string; sem page, page_size
+// ou cursor o relatório é retornado completo
+func pageRequest(page, pageSize, cursor string) (*entities.PageRequest, error) {
+	if page == "" && pageSize == "" && cursor == "" {
+		return nil, nil
+	}
+
+	request := &entities.PageRequest{Cursor: cursor}
+	var fieldErrors []entities.FieldError
+	parseInt := func(name, raw string, target *int) {
+		if raw == "" {
+			return
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			fieldErrors = append(fieldErrors, entities.FieldError{
+				Param:   name,
+				Rule:    "type",
+				Message: fmt.Sprintf("%s must be an integer", name),
+				Value:   raw,
+			})
+			return
+		}
+		*target = n
+	}
+	parseInt("page", page, &request.Page)
+	parseInt("page_size", pageSize, &request.PageSize)
+
+	if len(fieldErrors) > 0 {
+		return nil, &entities.ValidationError{Errors: fieldErrors}
+	}
+	return request, nil
+}
+
+func (h *ReportHandler) GetParamOptions(c fiber.Ctx) error {
+	reportID := c.Params("report_id")
+	paramName := c.Params("param")
+
+	if err := h.service.Authorize(reportID, requestCredentials(c)); err != nil {
+		return h.sendError(c, err)
 	}
 
-	return c.JSON(report)
+	// Valores dos parâmetros pai (?country=BR) filtram as opções em cascata
+	params := make(map[string]interface{})
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		params[string(key)] = string(value)
+	})
+
+	options, err := h.service.GetParamOptions(reportID, paramName, params)
+	if err != nil {
+		return h.sendError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"param":   paramName,
+		"options": options,
+	})
 }
 
 func (h *ReportHandler) GetAvailableReports(c fiber.Ctx) error {
@@ -92,20 +239,108 @@ func (h *ReportHandler) GetAvailableReports(c fiber.Ctx) error {
 	})
 }
 
-func (h *ReportHandler) renderCSV(c fiber.Ctx, report *entities.ReportResponse) error {
-	// Implementação básica CSV
-	c.Set("Content-Type", "text/csv")
-	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.csv\"", report.Metadata.Report))
+// sendError responde com o status correspondente ao tipo do erro e, em erros
+// de validação, com a lista de falhas por parâmetro
+func (h *ReportHandler) sendError(c fiber.Ctx, err error) error {
+	kind := entities.ErrorKindOf(err)
+
+	status := fiber.StatusInternalServerError
+	switch kind {
+	case entities.ErrorNotFound, entities.ErrorParamNotFound:
+		status = fiber.StatusNotFound
+	case entities.ErrorValidation:
+		status = fiber.StatusUnprocessableEntity
+	case entities.ErrorTimeout:
+		status = fiber.StatusGatewayTimeout
+	case entities.ErrorUnauthorized:
+		status = fiber.StatusUnauthorized
+	case entities.ErrorForbidden:
+		status = fiber.StatusForbidden
+	}
+
+	body := fiber.Map{
+		"error": err.Error(),
+		"code":  kind,
+	}
+
+	var validationErr *entities.ValidationError
+	if errors.As(err, &validationErr) {
+		body["details"] = validationErr.Errors
+	}
+
+	return c.Status(status).JSON(body)
+}
+
+// setCacheHeaders emite Cache-Control, Expires e X-Cache para o relatório
+func (h *ReportHandler) setCacheHeaders(c fiber.Ctx, report *entities.ReportResponse) {
+	c.Set("X-Cache", cacheStatus(report))
+
+	maxAge := int(time.Until(report.Metadata.ExpiresAt).Seconds())
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("private, max-age=%d", maxAge))
+	if !report.Metadata.ExpiresAt.IsZero() {
+		c.Set(fiber.HeaderExpires, report.Metadata.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+}
+
+// reportETag retorna o ETag (entre aspas) do relatório no formato pedido
+func reportETag(report *entities.ReportResponse) string {
+	if report.Metadata.ETag == "" {
+		return ""
+	}
+
+	// O mesmo conteúdo em formatos diferentes é outra representação
+	etag := report.Metadata.ETag
+	if format := strings.ToLower(report.Metadata.Format); format != "" && format != "json" {
+		etag += "-" + format
+	}
+	return `"` + etag + `"`
+}
+
+func cacheStatus(report *entities.ReportResponse) string {
+	if report.Metadata.CacheHit {
+		return "HIT"
+	}
+	return "MISS"
+}
+
+// etagMatches verifica o cabeçalho If-None-Match (lista de ETags, "*" ou weak)
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
 
-	// Converter dados para CSV (implementação simplificada)
-	return c.SendString("CSV export not fully implemented yet")
+	return false
 }
 
-func (h *ReportHandler) renderXLSX(c fiber.Ctx, report *entities.ReportResponse) error {
-	// Implementação básica XLSX
-	c.Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
-	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.xlsx\"", report.Metadata.Report))
+// sendReport responde em JSON ou, para formatos de exportação (csv, xlsx,
+// pdf, html), com o arquivo gerado: como anexo ou, no html, para exibição
+func (h *ReportHandler) sendReport(c fiber.Ctx, report *entities.ReportResponse, format string) error {
+	format = strings.ToLower(format)
+	if format == "" || format == "json" {
+		return c.JSON(report)
+	}
+
+	data, renderer, err := h.service.RenderReport(report, format)
+	if err != nil {
+		return h.sendError(c, err)
+	}
+
+	disposition := "attachment"
+	if inline, ok := renderer.(reportfile.InlineRenderer); ok && inline.Inline() {
+		disposition = "inline"
+	}
 
-	// Converter dados para XLSX (implementação simplificada)
-	return c.SendString("XLSX export not fully implemented yet")
+	c.Set(fiber.HeaderContentType, renderer.ContentType())
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("%s; filename=\"%s.%s\"", disposition, report.Metadata.Report, renderer.Extension()))
+	return c.Send(data)
 }
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"reports-system/internal/domain/entities"
+	"reports-system/internal/infra/cache"
+	"reports-system/internal/usecase"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestEtagMatches(t *testing.T) {
+	const etag = `"abc"`
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        bool
+	}{
+		{"empty", "", false},
+		{"same", `"abc"`, true},
+		{"different", `"abd"`, false},
+		{"weak", `W/"abc"`, true},
+		{"list", `"x", "abc"`, true},
+		{"list with weak", `"x",W/"abc"`, true},
+		{"list without match", `"x", "y"`, false},
+		{"any", "*", true},
+		{"unquoted", "abc", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := etagMatches(tt.ifNoneMatch, etag); got != tt.want {
+				t.Errorf("etagMatches(%q) = %v, want %v", tt.ifNoneMatch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportETag(t *testing.T) {
+	tests := []struct {
+		name   string
+		etag   string
+		format string
+		want   string
+	}{
+		{"without etag", "", "json", ""},
+		{"json", "abc", "json", `"abc"`},
+		{"default format", "abc", "", `"abc"`},
+		{"csv", "abc", "csv", `"abc-csv"`},
+		{"upper case format", "abc", "XLSX", `"abc-xlsx"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &entities.ReportResponse{Metadata: entities.ReportMetadata{ETag: tt.etag, Format: tt.format}}
+			if got := reportETag(report); got != tt.want {
+				t.Errorf("reportETag = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportCacheHeaders(t *testing.T) {
+	app, queries := newTestApp(t)
+
+	get := func(path, ifNoneMatch string) (int, map[string]string) {
+		req := httptest.NewRequest("GET", path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set(fiber.HeaderIfNoneMatch, ifNoneMatch)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, map[string]string{
+			"etag":          resp.Header.Get(fiber.HeaderETag),
+			"cache-control": resp.Header.Get(fiber.HeaderCacheControl),
+			"x-cache":       resp.Header.Get("X-Cache"),
+		}
+	}
+
+	status, headers := get("/reports/items", "")
+	if status != fiber.StatusOK || headers["etag"] == "" || headers["x-cache"] != "MISS" {
+		t.Fatalf("first GET = %d %v", status, headers)
+	}
+	if !strings.HasPrefix(headers["cache-control"], "private, max-age=") {
+		t.Errorf("Cache-Control = %q", headers["cache-control"])
+	}
+	etag := headers["etag"]
+
+	status, headers = get("/reports/items", etag)
+	if status != fiber.StatusNotModified || headers["etag"] != etag || headers["x-cache"] != "HIT" {
+		t.Errorf("conditional GET = %d %v, want 304 with %s", status, headers, etag)
+	}
+
+	status, _ = get("/reports/items", `W/`+etag)
+	if status != fiber.StatusNotModified {
+		t.Errorf("conditional GET with weak etag = %d, want 304", status)
+	}
+
+	status, headers = get("/reports/items?format=csv", etag)
+	if status != fiber.StatusOK || headers["etag"] != strings.TrimSuffix(etag, `"`)+`-csv"` {
+		t.Errorf("csv GET = %d %v, want 200 with csv etag", status, headers)
+	}
+
+	if n := queries.Load(); n != 1 {
+		t.Errorf("queries executed = %d, want 1", n)
+	}
+
+	// POST recebe os cabeçalhos de cache, mas não ETag nem 304
+	req := httptest.NewRequest("POST", "/reports/items", strings.NewReader(`{}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set(fiber.HeaderIfNoneMatch, etag)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK || resp.Header.Get(fiber.HeaderETag) != "" {
+		t.Errorf("POST = %d with ETag %q, want 200 without ETag", resp.StatusCode, resp.Header.Get(fiber.HeaderETag))
+	}
+	if resp.Header.Get("X-Cache") != "HIT" || !strings.HasPrefix(resp.Header.Get(fiber.HeaderCacheControl), "private, max-age=") {
+		t.Errorf("POST cache headers = %v", resp.Header)
+	}
+}
+
+func TestReportAuth(t *testing.T) {
+	app, _ := newTestApp(t, "secret")
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		status  int
+		code    string
+	}{
+		{"public report", "GET", "/reports/items", nil, fiber.StatusOK, ""},
+		{"missing credentials", "GET", "/reports/private_items", nil, fiber.StatusUnauthorized, "unauthorized"},
+		{"missing credentials on POST", "POST", "/reports/private_items", nil, fiber.StatusUnauthorized, "unauthorized"},
+		{"bearer token", "GET", "/reports/private_items", map[string]string{"Authorization": "Bearer secret"}, fiber.StatusOK, ""},
+		{"api key header", "GET", "/reports/private_items", map[string]string{"X-API-Key": "secret"}, fiber.StatusOK, ""},
+		{"key not allowed", "GET", "/reports/private_items", map[string]string{"Authorization": "Bearer other"}, fiber.StatusForbidden, "forbidden"},
+		{"unknown report", "GET", "/reports/missing", nil, fiber.StatusNotFound, "report_not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{}`))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("%s %s: %v", tt.method, tt.path, err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d (%s)", resp.StatusCode, tt.status, body)
+			}
+			if tt.code != "" && !strings.Contains(string(body), `"code":"`+tt.code+`"`) {
+				t.Errorf("body = %s, want code %s", body, tt.code)
+			}
+		})
+	}
+}
+
+// newTestApp monta as rotas de relatório sobre um banco falso que responde a
+// qualquer query com as mesmas linhas; o contador indica quantas queries
+// chegaram ao banco. O relatório private_items exige autenticação.
+func newTestApp(t *testing.T, apiKeys ...string) (*fiber.App, *atomic.Int64) {
+	t.Helper()
+
+	dir := t.TempDir()
+	configs := map[string]string{
+		"items": `{
+			"name": "items",
+			"description": "Itens",
+			"query": "SELECT id, name FROM items ORDER BY id",
+			"cache_ttl": "5m",
+			"output": {"formats": ["json", "csv"]}
+		}`,
+		"private_items": `{
+			"name": "private_items",
+			"description": "Itens com autenticação",
+			"query": "SELECT id, name FROM items ORDER BY id",
+			"security": {"require_auth": true},
+			"output": {"formats": ["json"]}
+		}`,
+	}
+	for name, config := range configs {
+		if err := os.WriteFile(filepath.Join(dir, name+".json"), []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	queries := &atomic.Int64{}
+	db := &testDatabase{db: sql.OpenDB(testConnector{queries: queries})}
+	service := usecase.NewReportService(db, cache.NewMemoryCache(), dir)
+	service.SetAPIKeys(apiKeys)
+	handler := NewReportHandler(service)
+
+	app := fiber.New()
+	app.Get("/reports/:report_id", handler.GetReport)
+	app.Post("/reports/:report_id", handler.PostReport)
+	return app, queries
+}
+
+type testDatabase struct{ db *sql.DB }
+
+func (d *testDatabase) NewDB() (entities.Database, error) { return d, nil }
+func (d *testDatabase) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return d.db.QueryContext(ctx, query)
+}
+func (d *testDatabase) QueryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return d.db.QueryRowContext(ctx, query)
+}
+func (d *testDatabase) Dialect() string           { return "postgres" }
+func (d *testDatabase) Health() entities.DBHealth { return entities.DBHealth{} }
+func (d *testDatabase) Close() error              { return d.db.Close() }
+
+type testConnector struct{ queries *atomic.Int64 }
+
+func (c testConnector) Connect(context.Context) (driver.Conn, error) { return testConn(c), nil }
+func (c testConnector) Driver() driver.Driver                        { return nil }
+
+type testConn testConnector
+
+func (c testConn) Prepare(query string) (driver.Stmt, error) { return testStmt(c), nil }
+func (c testConn) Close() error                              { return nil }
+func (c testConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+type testStmt testConn
+
+func (s testStmt) Close() error                                    { return nil }
+func (s testStmt) NumInput() int                                   { return -1 }
+func (s testStmt) Exec(args []driver.Value) (driver.Result, error) { return nil, driver.ErrSkip }
+func (s testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.queries.Add(1)
+	return &testRows{rows: [][]driver.Value{{int64(1), "a"}, {int64(2), "b"}}}, nil
+}
+
+type testRows struct {
+	rows [][]driver.Value
+	next int
+}
+
+func (r *testRows) Columns() []string { return []string{"id", "name"} }
+func (r *testRows) Close() error      { return nil }
+func (r *testRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"context"
 	"database/sql"
 	"time"
 )
@@ -14,8 +15,9 @@ type DBHealth struct {
 
 type Database interface {
 	NewDB() (Database, error)
-	Query(query string, args ...interface{}) (*sql.Rows, error)
-	QueryRow(query string, args ...interface{}) *sql.Row
+	Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(ctx context.Context, query string, args ...interface{}) *sql.Row
+	Dialect() string
 	Health() DBHealth
 	Close() error
 }
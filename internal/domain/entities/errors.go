@@ -0,0 +1,79 @@
+package entities
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrorKind classifica as falhas de geração de relatório para que a camada
+// HTTP escolha o status adequado.
+type ErrorKind string
+
+const (
+	ErrorNotFound      ErrorKind = "report_not_found"
+	ErrorParamNotFound ErrorKind = "param_not_found"
+	ErrorValidation    ErrorKind = "validation_failed"
+	ErrorExecution     ErrorKind = "execution_failed"
+	ErrorTimeout       ErrorKind = "execution_timeout"
+	ErrorUnauthorized  ErrorKind = "unauthorized"
+	ErrorForbidden     ErrorKind = "forbidden"
+)
+
+type ReportError struct {
+	Kind    ErrorKind
+	Message string
+	Err     error
+}
+
+func (e *ReportError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *ReportError) Unwrap() error {
+	return e.Err
+}
+
+// FieldError descreve a falha de uma regra em um parâmetro. Em regras entre
+// parâmetros, Param contém os nomes separados por vírgula.
+type FieldError struct {
+	Param   string      `json:"param"`
+	Rule    string      `json:"rule"`
+	Message string      `json:"message"`
+	Value   interface{} `json:"value,omitempty"`
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// ValidationError agrupa todas as falhas de validação de uma requisição
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fieldErr := range e.Errors {
+		messages[i] = fieldErr.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ErrorKindOf retorna a classificação do erro, considerando erros de
+// validação e ReportError encadeados
+func ErrorKindOf(err error) ErrorKind {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return ErrorValidation
+	}
+
+	var reportErr *ReportError
+	if errors.As(err, &reportErr) {
+		return reportErr.Kind
+	}
+
+	return ErrorExecution
+}
@@ -15,14 +15,66 @@ type Query interface {
 }
 
 type QueryConfig struct {
-	Name        string         `json:"name"`
-	Version     string         `json:"version"`
-	Description string         `json:"description"`
-	Query       string         `json:"query"`
-	Parameters  []ParamConfig  `json:"params"`
-	Output      OutputConfig   `json:"output"`
-	Security    SecurityConfig `json:"security,omitempty"`
-	CacheTTL    string         `json:"cache_ttl,omitempty"`
+	Name        string            `json:"name"`
+	Version     string            `json:"version"`
+	Description string            `json:"description"`
+	Query       string            `json:"query"`
+	Parameters  []ParamConfig     `json:"params"`
+	Output      OutputConfig      `json:"output"`
+	Security    SecurityConfig    `json:"security,omitempty"`
+	CacheTTL    string            `json:"cache_ttl,omitempty"`
+	Timezone    string            `json:"timezone,omitempty"`
+	Warmup      *WarmupConfig     `json:"warmup,omitempty"`
+	Rules       []CrossParamRule  `json:"rules,omitempty"`
+	Pagination  *PaginationConfig `json:"pagination,omitempty"`
+	// Datasource escolhe uma conexão registrada além da padrão
+	Datasource string `json:"datasource,omitempty"`
+	// Sections torna o relatório composto: em vez de Query, executa os
+	// relatórios das seções com os parâmetros compartilhados, em paralelo
+	// quando Parallel é verdadeiro
+	Sections []SectionConfig `json:"sections,omitempty"`
+	Parallel bool            `json:"parallel,omitempty"`
+}
+
+// SectionConfig é uma seção de um relatório composto: o relatório Report
+// recebe os parâmetros do composto que declara, além dos valores fixos de
+// Params
+type SectionConfig struct {
+	Name   string                 `json:"name"`
+	Report string                 `json:"report"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// PaginationConfig ajusta a paginação do relatório. Key é uma coluna única e
+// ordenável do resultado: quando definida, as páginas são ordenadas por ela e
+// a navegação por cursor (keyset) fica disponível. Count executa uma query de
+// contagem para informar o total de linhas.
+type PaginationConfig struct {
+	DefaultPageSize int    `json:"default_page_size,omitempty"`
+	MaxPageSize     int    `json:"max_page_size,omitempty"`
+	Key             string `json:"key,omitempty"`
+	Count           bool   `json:"count,omitempty"`
+}
+
+// CrossParamRule relaciona vários parâmetros. Tipos suportados:
+//   - "order": os valores de Params devem estar em ordem crescente
+//   - "max_interval": Params[1] no máximo Max após Params[0] (ex.: "366d")
+//   - "mutually_exclusive": no máximo um dos Params pode ser informado
+//   - "required_together": os Params devem ser informados todos ou nenhum
+type CrossParamRule struct {
+	Type    string   `json:"type"`
+	Params  []string `json:"params"`
+	Max     string   `json:"max,omitempty"`
+	Message string   `json:"message,omitempty"`
+}
+
+// WarmupConfig declara conjuntos de parâmetros executados antecipadamente
+// para popular o cache. Schedule aceita horários diários ("06:00,12:30") ou
+// um intervalo ("@every 1h").
+type WarmupConfig struct {
+	Schedule  string                   `json:"schedule"`
+	OnStartup bool                     `json:"on_startup,omitempty"`
+	Params    []map[string]interface{} `json:"params"`
 }
 
 type ParamConfig struct {
@@ -32,12 +84,125 @@ type ParamConfig struct {
 	Default     interface{}            `json:"default,omitempty"`
 	Description string                 `json:"description,omitempty"`
 	Validation  map[string]interface{} `json:"validation,omitempty"`
+	Options     *OptionsConfig         `json:"options,omitempty"`
+}
+
+// OptionsConfig define uma query de lookup (no mesmo datasource do relatório)
+// que fornece os valores permitidos de um parâmetro enum e seus rótulos.
+// Parâmetros em DependsOn podem ser referenciados na query (@param) para
+// filtrar as opções em cascata; pais opcionais não informados são enviados
+// como NULL (ex.: "@state IS NULL OR state = @state").
+type OptionsConfig struct {
+	Query       string   `json:"query"`
+	ValueColumn string   `json:"value_column,omitempty"`
+	LabelColumn string   `json:"label_column,omitempty"`
+	CacheTTL    string   `json:"cache_ttl,omitempty"`
+	DependsOn   []string `json:"depends_on,omitempty"`
+}
+
+type ParamOption struct {
+	Value interface{} `json:"value"`
+	Label string      `json:"label"`
 }
 
+// OptionsLoader carrega as opções de um parâmetro do relatório informado
+type OptionsLoader func(report string, param ParamConfig, params map[string]interface{}) ([]ParamOption, error)
+
+// OutputConfig define os formatos e campos do resultado. Sortable e
+// Selectable listam, pelos nomes mapeados, os campos que o cliente pode usar
+// em sort e fields.
 type OutputConfig struct {
 	Formats      []string               `json:"formats"`
 	FieldMapping map[string]string      `json:"field_mapping,omitempty"`
 	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+	Labels       map[string]string      `json:"labels,omitempty"`
+	Sortable     []string               `json:"sortable,omitempty"`
+	Selectable   []string               `json:"selectable,omitempty"`
+	// Decimals define como colunas decimais são retornadas: "number"
+	// (padrão, número exato) ou "string"
+	Decimals string `json:"decimals,omitempty"`
+	// Formatting formata, por campo mapeado, os valores exibidos em CSV,
+	// XLSX e PDF; o JSON mantém os valores originais
+	Formatting map[string]ColumnFormat `json:"formatting,omitempty"`
+	Locale     string                  `json:"locale,omitempty"`
+	// Computed declara colunas calculadas por linha, adicionadas ao fim do
+	// resultado na ordem declarada
+	Computed []ComputedColumn `json:"computed,omitempty"`
+	// Grouping agrupa as linhas (formato records) e calcula subtotais por
+	// grupo e o total geral
+	Grouping *GroupingConfig `json:"grouping,omitempty"`
+	// Pivot transforma os valores de um campo em colunas (tabela cruzada)
+	Pivot *PivotConfig `json:"pivot,omitempty"`
+	// Nest agrupa as linhas de joins em objetos aninhados (mestre-detalhe)
+	Nest *NestConfig `json:"nest,omitempty"`
+}
+
+// ComputedColumn é uma coluna derivada de uma expressão sobre as colunas da
+// linha (nomes originais, incluindo calculadas anteriores) e os parâmetros
+// (@param). Ex.: "revenue - cost", "first_name + ' ' + last_name".
+type ComputedColumn struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+}
+
+// ColumnFormat descreve a formatação de exibição de uma coluna. Type aceita
+// number, currency, percent, date, datetime, bool e text.
+type ColumnFormat struct {
+	Type       string `json:"type,omitempty"`
+	Precision  *int   `json:"precision,omitempty"`
+	Currency   string `json:"currency,omitempty"`
+	Locale     string `json:"locale,omitempty"`
+	Layout     string `json:"layout,omitempty"`
+	TrueLabel  string `json:"true_label,omitempty"`
+	FalseLabel string `json:"false_label,omitempty"`
+	Null       string `json:"null,omitempty"`
+}
+
+// GroupingConfig agrupa o resultado pelos campos mapeados de By, em níveis
+// aninhados na ordem declarada. Os grupos seguem a ordem em que aparecem no
+// resultado, então o SQL deve ordenar pelos mesmos campos.
+type GroupingConfig struct {
+	By         []string          `json:"by"`
+	Aggregates []AggregateConfig `json:"aggregates,omitempty"`
+}
+
+// AggregateConfig calcula Function (sum, count, avg, min ou max) sobre os
+// valores não nulos de um campo mapeado. Name é a chave do resultado em
+// totals; o padrão é "<function>_<field>".
+type AggregateConfig struct {
+	Field    string `json:"field"`
+	Function string `json:"function"`
+	Name     string `json:"name,omitempty"`
+}
+
+// PivotConfig gera uma tabela cruzada: uma linha por combinação dos campos
+// de Rows e uma coluna por valor distinto de Column, com o agregado
+// (Aggregate, padrão "sum") de Value. Combinações sem dados recebem Fill.
+// Os campos são os nomes mapeados.
+type PivotConfig struct {
+	Rows      []string    `json:"rows"`
+	Column    string      `json:"column"`
+	Value     string      `json:"value"`
+	Aggregate string      `json:"aggregate,omitempty"`
+	Fill      interface{} `json:"fill,omitempty"`
+}
+
+// NestConfig descreve um nível do resultado aninhado: Key são os campos que
+// identificam o objeto, Fields os demais campos do objeto e Children as
+// coleções filhas, cada uma com seu Name (ex.: lojas → pedidos → itens). Sem
+// Fields, o último nível recebe todos os campos não usados pelos níveis
+// acima. Os campos são os nomes mapeados.
+type NestConfig struct {
+	Name     string       `json:"name,omitempty"`
+	Key      []string     `json:"key"`
+	Fields   []string     `json:"fields,omitempty"`
+	Children []NestConfig `json:"children,omitempty"`
+}
+
+// SortField é uma coluna do resultado (nome original) usada na ordenação
+type SortField struct {
+	Column string
+	Desc   bool
 }
 
 type SecurityConfig struct {
@@ -55,14 +220,101 @@ type ParamRule struct {
 	Max      interface{} `json:"max,omitempty"`
 }
 
+// ReportOptions reúne as opções de geração do relatório que não são
+// parâmetros da query
+type ReportOptions struct {
+	Format   string
+	Timezone string
+	Page     *PageRequest
+	// Campos mapeados; em Sort o prefixo "-" indica ordem decrescente
+	Sort   []string
+	Fields []string
+	// Shape escolhe o formato de Data: "records" (lista de objetos, padrão)
+	// ou "table" (colunas + linhas em arrays)
+	Shape string
+}
+
+const (
+	ShapeRecords = "records"
+	ShapeTable   = "table"
+)
+
+// PageRequest pede uma página do relatório por número (Page) ou pelo cursor
+// retornado em ReportMetadata.NextCursor
+type PageRequest struct {
+	Page     int
+	PageSize int
+	Cursor   string
+}
+
 type ReportMetadata struct {
 	Report      string                 `json:"report"`
 	Params      map[string]interface{} `json:"params"`
 	GeneratedAt time.Time              `json:"generated_at"`
+	ExpiresAt   time.Time              `json:"expires_at"`
 	Format      string                 `json:"format"`
+	Timezone    string                 `json:"timezone,omitempty"`
+	ETag        string                 `json:"etag,omitempty"`
+	CacheHit    bool                   `json:"cache_hit"`
+	Page        int                    `json:"page,omitempty"`
+	PageSize    int                    `json:"page_size,omitempty"`
+	Total       *int64                 `json:"total,omitempty"`
+	HasMore     bool                   `json:"has_more,omitempty"`
+	NextCursor  string                 `json:"next_cursor,omitempty"`
+	Sort        []string               `json:"sort,omitempty"`
+	Fields      []string               `json:"fields,omitempty"`
+	Shape       string                 `json:"shape,omitempty"`
 }
 
+// ReportResponse traz o resultado em Data ou, em relatórios compostos, em
+// Sections
 type ReportResponse struct {
-	Metadata ReportMetadata `json:"metadata"`
-	Data     interface{}    `json:"data"`
+	Metadata ReportMetadata  `json:"metadata"`
+	Columns  []ColumnInfo    `json:"columns,omitempty"`
+	Data     interface{}     `json:"data,omitempty"`
+	Sections []ReportSection `json:"sections,omitempty"`
+}
+
+// ReportSection é o resultado de uma seção de um relatório composto
+type ReportSection struct {
+	Name     string       `json:"name"`
+	Report   string       `json:"report"`
+	CacheHit bool         `json:"cache_hit"`
+	Columns  []ColumnInfo `json:"columns,omitempty"`
+	Data     interface{}  `json:"data"`
+}
+
+// ColumnInfo descreve uma coluna do resultado, na ordem do SQL. Name é o nome
+// mapeado (field_mapping) e Source o nome retornado pelo banco.
+type ColumnInfo struct {
+	Name     string `json:"name"`
+	Source   string `json:"source"`
+	Type     string `json:"type,omitempty"`
+	Nullable *bool  `json:"nullable,omitempty"`
+	Label    string `json:"label"`
+}
+
+// GroupedData é o resultado agrupado por output.grouping, com os agregados
+// do total geral em Totals
+type GroupedData struct {
+	Groups []Group                `json:"groups"`
+	Totals map[string]interface{} `json:"totals"`
+}
+
+// Group reúne as linhas com o mesmo valor de Field. O último nível contém as
+// linhas (Rows) e os demais, os subgrupos (Groups).
+type Group struct {
+	Field  string                   `json:"field"`
+	Value  interface{}              `json:"value"`
+	Count  int                      `json:"count"`
+	Totals map[string]interface{}   `json:"totals"`
+	Groups []Group                  `json:"groups,omitempty"`
+	Rows   []map[string]interface{} `json:"rows,omitempty"`
+}
+
+// TableData é o formato compacto do resultado: nomes das colunas e linhas
+// como arrays, preservando a ordem
+type TableData struct {
+	Columns []string        `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
 }
@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"reports-system/internal/domain/entities"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Marcadores gravados no primeiro byte de cada valor armazenado
+const (
+	markerRaw  byte = 0x00
+	markerGzip byte = 0x01
+	markerZstd byte = 0x02
+)
+
+const DefaultCompressionThreshold = 1024
+
+// CompressedCache envolve outro CacheProvider comprimindo valores maiores
+// que o threshold. A descompressão é transparente para quem chama Get, e
+// entradas sem marcador (gravadas sem compressão) são lidas como estão.
+type CompressedCache struct {
+	inner     entities.CacheProvider
+	marker    byte
+	threshold int
+	encoder   *zstd.Encoder
+	decoder   *zstd.Decoder
+}
+
+func NewCompressedCache(inner entities.CacheProvider, algorithm string, threshold int) (entities.CacheProvider, error) {
+	if threshold <= 0 {
+		threshold = DefaultCompressionThreshold
+	}
+
+	c := &CompressedCache{
+		inner:     inner,
+		threshold: threshold,
+	}
+
+	// O decoder zstd é sempre criado para conseguir ler entradas gravadas
+	// com outra configuração (ex.: cache remoto compartilhado)
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zstd decoder: %w", err)
+	}
+	c.decoder = decoder
+
+	switch strings.ToLower(algorithm) {
+	case "gzip":
+		c.marker = markerGzip
+	case "zstd":
+		encoder, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd encoder: %w", err)
+		}
+		c.encoder = encoder
+		c.marker = markerZstd
+	case "", "none":
+		c.marker = markerRaw
+	default:
+		return nil, fmt.Errorf("unsupported cache compression: %s", algorithm)
+	}
+
+	return c, nil
+}
+
+func (c *CompressedCache) Get(key string) ([]byte, error) {
+	stored, err := c.inner.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stored) == 0 {
+		return nil, errors.New("invalid cache entry")
+	}
+
+	payload := stored[1:]
+	switch stored[0] {
+	case markerRaw:
+		return payload, nil
+	case markerGzip:
+		reader, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress cache entry: %w", err)
+		}
+		defer reader.Close()
+		value, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress cache entry: %w", err)
+		}
+		return value, nil
+	case markerZstd:
+		value, err := c.decoder.DecodeAll(payload, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress cache entry: %w", err)
+		}
+		return value, nil
+	default:
+		// Entradas gravadas antes da compressão ser habilitada não têm
+		// marcador; os valores em cache (JSON) nunca começam pelos marcadores
+		return stored, nil
+	}
+}
+
+func (c *CompressedCache) Set(key string, value []byte, ttl time.Duration) error {
+	if c.marker == markerRaw || len(value) < c.threshold {
+		return c.inner.Set(key, append([]byte{markerRaw}, value...), ttl)
+	}
+
+	var encoded []byte
+	switch c.marker {
+	case markerGzip:
+		var buf bytes.Buffer
+		buf.WriteByte(markerGzip)
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write(value); err != nil {
+			return fmt.Errorf("failed to compress cache entry: %w", err)
+		}
+		if err := writer.Close(); err != nil {
+			return fmt.Errorf("failed to compress cache entry: %w", err)
+		}
+		encoded = buf.Bytes()
+	case markerZstd:
+		encoded = c.encoder.EncodeAll(value, []byte{markerZstd})
+	}
+
+	return c.inner.Set(key, encoded, ttl)
+}
+
+func (c *CompressedCache) Delete(key string) error {
+	return c.inner.Delete(key)
+}
@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestCompressedCache(t *testing.T, algorithm string, threshold int) (*CompressedCache, *MemoryCache) {
+	t.Helper()
+	inner := NewMemoryCache().(*MemoryCache)
+	provider, err := NewCompressedCache(inner, algorithm, threshold)
+	if err != nil {
+		t.Fatalf("NewCompressedCache: %v", err)
+	}
+	return provider.(*CompressedCache), inner
+}
+
+func TestCompressedCacheRoundTrip(t *testing.T) {
+	large := []byte(`{"data":[` + strings.Repeat(`{"region":"Sul","total":10},`, 100) + `{}]}`)
+	small := []byte(`{"data":[]}`)
+
+	tests := []struct {
+		name       string
+		algorithm  string
+		value      []byte
+		wantMarker byte
+	}{
+		{"gzip", "gzip", large, markerGzip},
+		{"upper case algorithm", "GZIP", large, markerGzip},
+		{"zstd", "zstd", large, markerZstd},
+		{"gzip below threshold", "gzip", small, markerRaw},
+		{"zstd below threshold", "zstd", small, markerRaw},
+		{"none", "none", large, markerRaw},
+		{"empty algorithm", "", large, markerRaw},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, inner := newTestCompressedCache(t, tt.algorithm, 64)
+
+			if err := cache.Set("key", tt.value, time.Minute); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+
+			stored, err := inner.Get("key")
+			if err != nil {
+				t.Fatalf("inner Get: %v", err)
+			}
+			if stored[0] != tt.wantMarker {
+				t.Errorf("marker = %#x, want %#x", stored[0], tt.wantMarker)
+			}
+			if tt.wantMarker != markerRaw && len(stored) >= len(tt.value) {
+				t.Errorf("stored %d bytes for a %d bytes value", len(stored), len(tt.value))
+			}
+
+			got, err := cache.Get("key")
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if !bytes.Equal(got, tt.value) {
+				t.Errorf("Get = %q, want %q", got, tt.value)
+			}
+		})
+	}
+}
+
+func TestCompressedCacheReadsOtherEncodings(t *testing.T) {
+	value := []byte(strings.Repeat("report ", 1000))
+	gzipCache, shared := newTestCompressedCache(t, "gzip", 0)
+	gzipCache.Set("gzip", value, time.Minute)
+
+	zstdCache, err := NewCompressedCache(shared, "zstd", 0)
+	if err != nil {
+		t.Fatalf("NewCompressedCache: %v", err)
+	}
+	zstdCache.Set("zstd", value, time.Minute)
+
+	// Cada configuração lê as entradas gravadas pela outra
+	for _, key := range []string{"gzip", "zstd"} {
+		for name, reader := range map[string]interface {
+			Get(string) ([]byte, error)
+		}{"gzip": gzipCache, "zstd": zstdCache} {
+			got, err := reader.Get(key)
+			if err != nil || !bytes.Equal(got, value) {
+				t.Errorf("%s cache reading %s entry = %q, %v", name, key, got, err)
+			}
+		}
+	}
+}
+
+func TestCompressedCacheLegacyEntries(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored []byte
+		want   []byte
+	}{
+		{"raw marker", append([]byte{markerRaw}, `{"a":1}`...), []byte(`{"a":1}`)},
+		{"without marker", []byte(`{"a":1}`), []byte(`{"a":1}`)},
+		{"array without marker", []byte(`[1,2]`), []byte(`[1,2]`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, inner := newTestCompressedCache(t, "zstd", 0)
+			inner.Set("key", tt.stored, time.Minute)
+
+			got, err := cache.Get("key")
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("Get = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompressedCacheCorruptEntries(t *testing.T) {
+	value := []byte(strings.Repeat("report ", 1000))
+	gzipCache, _ := newTestCompressedCache(t, "gzip", 0)
+	gzipCache.Set("key", value, time.Minute)
+	gzipped, _ := gzipCache.inner.Get("key")
+	if gzipped[0] != markerGzip {
+		t.Fatalf("marker = %#x, want gzip", gzipped[0])
+	}
+
+	tests := []struct {
+		name   string
+		stored []byte
+	}{
+		{"empty", []byte{}},
+		{"gzip garbage", append([]byte{markerGzip}, "not gzip"...)},
+		{"truncated gzip", gzipped[:len(gzipped)/2]},
+		{"zstd garbage", append([]byte{markerZstd}, "not zstd"...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, inner := newTestCompressedCache(t, "gzip", 0)
+			inner.Set("key", tt.stored, time.Minute)
+
+			if got, err := cache.Get("key"); err == nil {
+				t.Errorf("Get = %q, want error", got)
+			}
+		})
+	}
+}
+
+func TestNewCompressedCacheUnsupported(t *testing.T) {
+	if _, err := NewCompressedCache(NewMemoryCache(), "brotli", 0); err == nil {
+		t.Error("NewCompressedCache(brotli) succeeded")
+	}
+}
@@ -0,0 +1,333 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"reports-system/internal/domain/entities"
+)
+
+const (
+	fileCacheExt    = ".cache"
+	fileCacheTmpExt = ".tmp"
+)
+
+// Cabeçalho de cada arquivo: magic (4) + expiresAt em unix nano (8) +
+// tamanho da chave (4) + crc32 do payload (4), seguido da chave e do payload.
+var fileCacheMagic = []byte("GRC1")
+
+const fileCacheHeaderSize = 4 + 8 + 4 + 4
+
+// FileCache persiste as entradas em disco para que sobrevivam a reinícios.
+// Um índice em memória mantém tamanho, expiração e último acesso de cada
+// entrada para aplicar o limite de tamanho sem varrer o diretório.
+type FileCache struct {
+	dir       string
+	maxBytes  int64
+	totalSize int64
+	index     map[string]*fileEntry
+	mu        sync.Mutex
+}
+
+type fileEntry struct {
+	path       string
+	size       int64
+	expiresAt  time.Time
+	accessedAt time.Time
+}
+
+func NewFileCache(dir string, maxBytes int64) (entities.CacheProvider, error) {
+	if dir == "" {
+		return nil, errors.New("cache directory is required")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	cache := &FileCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		index:    make(map[string]*fileEntry),
+	}
+
+	if err := cache.recover(); err != nil {
+		return nil, fmt.Errorf("failed to recover cache directory: %w", err)
+	}
+
+	// Cleanup goroutine
+	go cache.cleanup()
+
+	return cache, nil
+}
+
+func (f *FileCache) Get(key string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	entry, exists := f.index[key]
+	if !exists {
+		return nil, errors.New("key not found")
+	}
+
+	if time.Now().After(entry.expiresAt) {
+		f.remove(key)
+		return nil, errors.New("key expired")
+	}
+
+	data, err := os.ReadFile(entry.path)
+	if err != nil {
+		f.remove(key)
+		return nil, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+
+	storedKey, _, value, err := decodeFileEntry(data)
+	if err != nil || storedKey != key {
+		f.remove(key)
+		return nil, errors.New("corrupt cache entry")
+	}
+
+	entry.accessedAt = time.Now()
+	return value, nil
+}
+
+func (f *FileCache) Set(key string, value []byte, ttl time.Duration) error {
+	expiresAt := time.Now().Add(ttl)
+	data := encodeFileEntry(key, value, expiresAt)
+
+	if f.maxBytes > 0 && int64(len(data)) > f.maxBytes {
+		return errors.New("value exceeds cache size limit")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := f.pathFor(key)
+	if err := writeFileAtomic(f.dir, path, data); err != nil {
+		return err
+	}
+
+	if old, exists := f.index[key]; exists {
+		f.totalSize -= old.size
+	}
+
+	f.index[key] = &fileEntry{
+		path:       path,
+		size:       int64(len(data)),
+		expiresAt:  expiresAt,
+		accessedAt: time.Now(),
+	}
+	f.totalSize += int64(len(data))
+
+	f.evict(key)
+	return nil
+}
+
+func (f *FileCache) Delete(key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.remove(key)
+	return nil
+}
+
+func (f *FileCache) pathFor(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(f.dir, fmt.Sprintf("%x%s", hash, fileCacheExt))
+}
+
+// remove apaga a entrada do índice e do disco. Deve ser chamado com o lock.
+func (f *FileCache) remove(key string) {
+	entry, exists := f.index[key]
+	if !exists {
+		return
+	}
+
+	os.Remove(entry.path)
+	f.totalSize -= entry.size
+	delete(f.index, key)
+}
+
+// evict remove entradas expiradas e, se ainda necessário, as menos
+// acessadas recentemente até respeitar maxBytes. A chave recém gravada é
+// preservada. Deve ser chamado com o lock.
+func (f *FileCache) evict(keep string) {
+	if f.maxBytes <= 0 || f.totalSize <= f.maxBytes {
+		return
+	}
+
+	now := time.Now()
+	for key, entry := range f.index {
+		if now.After(entry.expiresAt) {
+			f.remove(key)
+		}
+	}
+
+	if f.totalSize <= f.maxBytes {
+		return
+	}
+
+	keys := make([]string, 0, len(f.index))
+	for key := range f.index {
+		if key != keep {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return f.index[keys[i]].accessedAt.Before(f.index[keys[j]].accessedAt)
+	})
+
+	for _, key := range keys {
+		if f.totalSize <= f.maxBytes {
+			break
+		}
+		f.remove(key)
+	}
+}
+
+// recover reconstrói o índice a partir do diretório, descartando arquivos
+// temporários de escritas interrompidas, entradas expiradas e corrompidas.
+func (f *FileCache) recover() error {
+	files, err := os.ReadDir(f.dir)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(f.dir, file.Name())
+		if strings.HasSuffix(file.Name(), fileCacheTmpExt) {
+			os.Remove(path)
+			continue
+		}
+		if !strings.HasSuffix(file.Name(), fileCacheExt) {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			os.Remove(path)
+			continue
+		}
+
+		key, expiresAt, _, err := decodeFileEntry(data)
+		if err != nil || now.After(expiresAt) || f.pathFor(key) != path {
+			os.Remove(path)
+			continue
+		}
+
+		accessedAt := expiresAt
+		if info, err := file.Info(); err == nil {
+			accessedAt = info.ModTime()
+		}
+
+		f.index[key] = &fileEntry{
+			path:       path,
+			size:       int64(len(data)),
+			expiresAt:  expiresAt,
+			accessedAt: accessedAt,
+		}
+		f.totalSize += int64(len(data))
+	}
+
+	f.evict("")
+	return nil
+}
+
+func (f *FileCache) cleanup() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		f.mu.Lock()
+		now := time.Now()
+		for key, entry := range f.index {
+			if now.After(entry.expiresAt) {
+				f.remove(key)
+			}
+		}
+		f.mu.Unlock()
+	}
+}
+
+func encodeFileEntry(key string, value []byte, expiresAt time.Time) []byte {
+	var buf bytes.Buffer
+	buf.Grow(fileCacheHeaderSize + len(key) + len(value))
+
+	buf.Write(fileCacheMagic)
+	binary.Write(&buf, binary.BigEndian, expiresAt.UnixNano())
+	binary.Write(&buf, binary.BigEndian, uint32(len(key)))
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(value))
+	buf.WriteString(key)
+	buf.Write(value)
+
+	return buf.Bytes()
+}
+
+func decodeFileEntry(data []byte) (string, time.Time, []byte, error) {
+	if len(data) < fileCacheHeaderSize || !bytes.Equal(data[:4], fileCacheMagic) {
+		return "", time.Time{}, nil, errors.New("invalid cache file header")
+	}
+
+	expiresAt := time.Unix(0, int64(binary.BigEndian.Uint64(data[4:12])))
+	keyLen := int(binary.BigEndian.Uint32(data[12:16]))
+	checksum := binary.BigEndian.Uint32(data[16:20])
+
+	if len(data) < fileCacheHeaderSize+keyLen {
+		return "", time.Time{}, nil, errors.New("truncated cache file")
+	}
+
+	key := string(data[fileCacheHeaderSize : fileCacheHeaderSize+keyLen])
+	value := data[fileCacheHeaderSize+keyLen:]
+	if crc32.ChecksumIEEE(value) != checksum {
+		return "", time.Time{}, nil, errors.New("cache file checksum mismatch")
+	}
+
+	return key, expiresAt, value, nil
+}
+
+// writeFileAtomic grava em um arquivo temporário no mesmo diretório e o
+// renomeia, evitando que leitores vejam uma entrada parcialmente escrita.
+func writeFileAtomic(dir, path string, data []byte) error {
+	tmp, err := os.CreateTemp(dir, "entry-*"+fileCacheTmpExt)
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to sync cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to close cache file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to commit cache file: %w", err)
+	}
+
+	return nil
+}
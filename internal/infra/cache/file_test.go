@@ -0,0 +1,234 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestFileCache(t *testing.T, dir string, maxBytes int64) *FileCache {
+	t.Helper()
+	provider, err := NewFileCache(dir, maxBytes)
+	if err != nil {
+		t.Fatalf("NewFileCache: %v", err)
+	}
+	return provider.(*FileCache)
+}
+
+func TestFileCacheSetGet(t *testing.T) {
+	cache := newTestFileCache(t, t.TempDir(), 0)
+
+	if err := cache.Set("report:1", []byte("payload"), time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	value, err := cache.Get("report:1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(value) != "payload" {
+		t.Errorf("Get = %q, want %q", value, "payload")
+	}
+
+	if err := cache.Delete("report:1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := cache.Get("report:1"); err == nil {
+		t.Error("Get after Delete succeeded")
+	}
+}
+
+func TestFileCacheExpired(t *testing.T) {
+	cache := newTestFileCache(t, t.TempDir(), 0)
+
+	if err := cache.Set("k", []byte("v"), -time.Second); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, err := cache.Get("k"); err == nil {
+		t.Error("Get of expired entry succeeded")
+	}
+	if _, err := os.Stat(cache.pathFor("k")); !os.IsNotExist(err) {
+		t.Error("expired entry file was not removed")
+	}
+}
+
+func TestFileCacheRecover(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, dir string)
+		key     string
+		found   bool
+	}{
+		{
+			name: "valid entry",
+			prepare: func(t *testing.T, dir string) {
+				writeEntry(t, dir, "k", "v", time.Now().Add(time.Hour))
+			},
+			key:   "k",
+			found: true,
+		},
+		{
+			name: "expired entry",
+			prepare: func(t *testing.T, dir string) {
+				writeEntry(t, dir, "k", "v", time.Now().Add(-time.Hour))
+			},
+			key: "k",
+		},
+		{
+			name: "checksum mismatch",
+			prepare: func(t *testing.T, dir string) {
+				path := writeEntry(t, dir, "k", "value", time.Now().Add(time.Hour))
+				data, _ := os.ReadFile(path)
+				data[len(data)-1] ^= 0xff
+				os.WriteFile(path, data, 0o644)
+			},
+			key: "k",
+		},
+		{
+			name: "truncated header",
+			prepare: func(t *testing.T, dir string) {
+				path := writeEntry(t, dir, "k", "v", time.Now().Add(time.Hour))
+				os.WriteFile(path, []byte("GRC1"), 0o644)
+			},
+			key: "k",
+		},
+		{
+			name: "file name does not match key",
+			prepare: func(t *testing.T, dir string) {
+				path := writeEntry(t, dir, "k", "v", time.Now().Add(time.Hour))
+				os.Rename(path, filepath.Join(dir, "other"+fileCacheExt))
+			},
+			key: "k",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tt.prepare(t, dir)
+
+			cache := newTestFileCache(t, dir, 0)
+			_, err := cache.Get(tt.key)
+			if found := err == nil; found != tt.found {
+				t.Errorf("found = %v, want %v (err: %v)", found, tt.found, err)
+			}
+
+			// Apenas entradas válidas permanecem no diretório
+			files, _ := os.ReadDir(dir)
+			if want := len(cache.index); len(files) != want {
+				t.Errorf("directory has %d files, want %d", len(files), want)
+			}
+		})
+	}
+}
+
+func TestFileCacheRecoverRemovesTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	tmp := filepath.Join(dir, "entry-123"+fileCacheTmpExt)
+	if err := os.WriteFile(tmp, []byte("partial"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	newTestFileCache(t, dir, 0)
+
+	if _, err := os.Stat(tmp); !os.IsNotExist(err) {
+		t.Error("temporary file was not removed")
+	}
+}
+
+func TestFileCacheRecoverTotalSize(t *testing.T) {
+	dir := t.TempDir()
+	first := newTestFileCache(t, dir, 0)
+	first.Set("a", []byte("1234567890"), time.Hour)
+	first.Set("b", []byte("1234567890"), time.Hour)
+
+	second := newTestFileCache(t, dir, 0)
+	if second.totalSize != first.totalSize {
+		t.Errorf("recovered totalSize = %d, want %d", second.totalSize, first.totalSize)
+	}
+	if len(second.index) != 2 {
+		t.Errorf("recovered %d entries, want 2", len(second.index))
+	}
+}
+
+func TestFileCacheEviction(t *testing.T) {
+	value := []byte("1234567890")
+	entrySize := int64(fileCacheHeaderSize + 1 + len(value))
+
+	tests := []struct {
+		name    string
+		touch   string
+		evicted string
+	}{
+		{name: "least recently written", evicted: "a"},
+		{name: "read refreshes access", touch: "a", evicted: "b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := newTestFileCache(t, t.TempDir(), 2*entrySize)
+
+			cache.Set("a", value, time.Hour)
+			cache.Set("b", value, time.Hour)
+			if tt.touch != "" {
+				if _, err := cache.Get(tt.touch); err != nil {
+					t.Fatalf("Get(%q): %v", tt.touch, err)
+				}
+			}
+			cache.Set("c", value, time.Hour)
+
+			if _, err := cache.Get(tt.evicted); err == nil {
+				t.Errorf("%q was not evicted", tt.evicted)
+			}
+			if cache.totalSize > cache.maxBytes {
+				t.Errorf("totalSize %d exceeds maxBytes %d", cache.totalSize, cache.maxBytes)
+			}
+			if _, err := cache.Get("c"); err != nil {
+				t.Errorf("newest entry was evicted: %v", err)
+			}
+		})
+	}
+}
+
+func TestFileCacheValueTooLarge(t *testing.T) {
+	cache := newTestFileCache(t, t.TempDir(), 16)
+	if err := cache.Set("k", []byte("value larger than the limit"), time.Hour); err == nil {
+		t.Error("Set accepted a value larger than maxBytes")
+	}
+}
+
+func TestFileEntryRoundTrip(t *testing.T) {
+	expiresAt := time.Unix(0, time.Now().UnixNano())
+	tests := []struct {
+		key   string
+		value []byte
+	}{
+		{key: "k", value: []byte("v")},
+		{key: "empty", value: []byte{}},
+		{key: "", value: []byte("no key")},
+		{key: "ação", value: []byte{0, 1, 2, 0xff}},
+	}
+
+	for _, tt := range tests {
+		key, gotExpires, value, err := decodeFileEntry(encodeFileEntry(tt.key, tt.value, expiresAt))
+		if err != nil {
+			t.Errorf("decode(%q): %v", tt.key, err)
+			continue
+		}
+		if key != tt.key || string(value) != string(tt.value) || !gotExpires.Equal(expiresAt) {
+			t.Errorf("round trip of %q = (%q, %v, %q)", tt.key, key, gotExpires, value)
+		}
+	}
+}
+
+// writeEntry grava uma entrada no formato do cache, como faria Set
+func writeEntry(t *testing.T, dir, key, value string, expiresAt time.Time) string {
+	t.Helper()
+	cache := &FileCache{dir: dir}
+	path := cache.pathFor(key)
+	if err := os.WriteFile(path, encodeFileEntry(key, []byte(value), expiresAt), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
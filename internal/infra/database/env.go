@@ -0,0 +1,12 @@
+package database
+
+import "os"
+
+// getenv lê uma variável da conexão (DB_HOST, DB_USER, ...). Datasources
+// adicionais usam o próprio prefixo (ex.: DB_LEGACY_HOST).
+func getenv(prefix, key string) string {
+	if prefix == "" {
+		prefix = "DB_"
+	}
+	return os.Getenv(prefix + key)
+}
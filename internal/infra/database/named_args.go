@@ -0,0 +1,126 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// bindPositional converte parâmetros nomeados (@nome + sql.Named) para os
+// placeholders posicionais do Postgres ($1, $2, ...). Literais entre aspas,
+// strings com dollar quoting ($$...$$, $tag$...$tag$), identificadores entre
+// aspas duplas e comentários (-- e /* */) não são alterados.
+func bindPositional(query string, args []interface{}) (string, []interface{}) {
+	named := make(map[string]interface{})
+	for _, arg := range args {
+		if n, ok := arg.(sql.NamedArg); ok {
+			named[n.Name] = n.Value
+		}
+	}
+	if len(named) == 0 {
+		return query, args
+	}
+
+	var out strings.Builder
+	var positional []interface{}
+	indexes := make(map[string]int)
+
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+
+		switch {
+		case ch == '\'' || ch == '"':
+			end := i + 1
+			for end < len(query) && query[end] != ch {
+				end++
+			}
+			if end >= len(query) {
+				end = len(query) - 1
+			}
+			out.WriteString(query[i : end+1])
+			i = end
+			continue
+		case ch == '-' && i+1 < len(query) && query[i+1] == '-':
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			out.WriteString(query[i : i+end])
+			i += end - 1
+			continue
+		case ch == '/' && i+1 < len(query) && query[i+1] == '*':
+			end := blockCommentEnd(query, i)
+			out.WriteString(query[i:end])
+			i = end - 1
+			continue
+		case ch == '$' && (i == 0 || !isIdentChar(query[i-1])):
+			if tag, ok := dollarQuoteTag(query[i:]); ok {
+				end := len(query)
+				if closing := strings.Index(query[i+len(tag):], tag); closing >= 0 {
+					end = i + len(tag) + closing + len(tag)
+				}
+				out.WriteString(query[i:end])
+				i = end - 1
+				continue
+			}
+		case ch == '@':
+			end := i + 1
+			for end < len(query) && isIdentChar(query[end]) {
+				end++
+			}
+			name := query[i+1 : end]
+			if value, ok := named[name]; ok {
+				index, seen := indexes[name]
+				if !seen {
+					positional = append(positional, value)
+					index = len(positional)
+					indexes[name] = index
+				}
+				out.WriteString(fmt.Sprintf("$%d", index))
+				i = end - 1
+				continue
+			}
+		}
+
+		out.WriteByte(ch)
+	}
+
+	return out.String(), positional
+}
+
+// blockCommentEnd retorna a posição após o comentário /* */ iniciado em
+// start; no Postgres os comentários de bloco podem ser aninhados
+func blockCommentEnd(query string, start int) int {
+	depth := 0
+	for i := start; i+1 < len(query); i++ {
+		switch {
+		case query[i] == '/' && query[i+1] == '*':
+			depth++
+			i++
+		case query[i] == '*' && query[i+1] == '/':
+			depth--
+			i++
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(query)
+}
+
+// dollarQuoteTag reconhece o delimitador de uma string com dollar quoting
+// ($$ ou $tag$) no início de s. Placeholders ($1) não são delimitadores.
+func dollarQuoteTag(s string) (string, bool) {
+	end := 1
+	for end < len(s) && isIdentChar(s[end]) {
+		end++
+	}
+	if end >= len(s) || s[end] != '$' || (end > 1 && s[1] >= '0' && s[1] <= '9') {
+		return "", false
+	}
+	return s[:end+1], true
+}
+
+func isIdentChar(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+}
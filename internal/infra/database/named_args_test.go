@@ -0,0 +1,107 @@
+package database
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestBindPositional(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		args     []interface{}
+		want     string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "repeated parameter",
+			query:    "SELECT * FROM t WHERE a = @a OR b = @a AND c = @c",
+			args:     []interface{}{sql.Named("a", 1), sql.Named("c", "x")},
+			want:     "SELECT * FROM t WHERE a = $1 OR b = $1 AND c = $2",
+			wantArgs: []interface{}{1, "x"},
+		},
+		{
+			name:     "unknown parameter",
+			query:    "SELECT @a, @other",
+			args:     []interface{}{sql.Named("a", 1)},
+			want:     "SELECT $1, @other",
+			wantArgs: []interface{}{1},
+		},
+		{
+			name:     "quoted literals and identifiers",
+			query:    `SELECT '@a', "@a", 'it''s @a' FROM t WHERE a = @a`,
+			args:     []interface{}{sql.Named("a", 1)},
+			want:     `SELECT '@a', "@a", 'it''s @a' FROM t WHERE a = $1`,
+			wantArgs: []interface{}{1},
+		},
+		{
+			name:     "line comment",
+			query:    "SELECT 1 -- @a\nWHERE a = @a",
+			args:     []interface{}{sql.Named("a", 1)},
+			want:     "SELECT 1 -- @a\nWHERE a = $1",
+			wantArgs: []interface{}{1},
+		},
+		{
+			name:     "block comment",
+			query:    "SELECT /* @a */ @a",
+			args:     []interface{}{sql.Named("a", 1)},
+			want:     "SELECT /* @a */ $1",
+			wantArgs: []interface{}{1},
+		},
+		{
+			name:     "nested block comment",
+			query:    "SELECT /* a /* @a */ @a */ @a",
+			args:     []interface{}{sql.Named("a", 1)},
+			want:     "SELECT /* a /* @a */ @a */ $1",
+			wantArgs: []interface{}{1},
+		},
+		{
+			name:     "unterminated block comment",
+			query:    "SELECT @a /* @a",
+			args:     []interface{}{sql.Named("a", 1)},
+			want:     "SELECT $1 /* @a",
+			wantArgs: []interface{}{1},
+		},
+		{
+			name:     "dollar quoted string",
+			query:    "SELECT $$ '@a' $$, @a",
+			args:     []interface{}{sql.Named("a", 1)},
+			want:     "SELECT $$ '@a' $$, $1",
+			wantArgs: []interface{}{1},
+		},
+		{
+			name:     "tagged dollar quoted string",
+			query:    "SELECT $fn$ $$ @a $$ $fn$, @a",
+			args:     []interface{}{sql.Named("a", 1)},
+			want:     "SELECT $fn$ $$ @a $$ $fn$, $1",
+			wantArgs: []interface{}{1},
+		},
+		{
+			name:     "dollar in identifier",
+			query:    "SELECT a$b$ FROM t WHERE a = @a",
+			args:     []interface{}{sql.Named("a", 1)},
+			want:     "SELECT a$b$ FROM t WHERE a = $1",
+			wantArgs: []interface{}{1},
+		},
+		{
+			name:     "without named args",
+			query:    "SELECT $1",
+			args:     []interface{}{1},
+			want:     "SELECT $1",
+			wantArgs: []interface{}{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotArgs := bindPositional(tt.query, tt.args)
+			if got != tt.want {
+				t.Errorf("query = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", gotArgs, tt.wantArgs)
+			}
+		})
+	}
+}
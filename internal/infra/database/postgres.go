@@ -1,9 +1,9 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"os"
 	"strconv"
 	"time"
 
@@ -13,15 +13,17 @@ import (
 )
 
 type PostgresDB struct {
-	db *sql.DB
+	// Prefix das variáveis de ambiente da conexão (padrão "DB_")
+	Prefix string
+	db     *sql.DB
 }
 
 func (p *PostgresDB) NewDB() (entities.Database, error) {
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
+	host := getenv(p.Prefix, "HOST")
+	port := getenv(p.Prefix, "PORT")
+	user := getenv(p.Prefix, "USER")
+	password := getenv(p.Prefix, "PASSWORD")
+	dbname := getenv(p.Prefix, "NAME")
 
 	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		host, port, user, password, dbname)
@@ -32,12 +34,12 @@ func (p *PostgresDB) NewDB() (entities.Database, error) {
 	}
 
 	// Configurar pool de conexões
-	maxConns, _ := strconv.Atoi(os.Getenv("DB_MAX_CONNS"))
+	maxConns, _ := strconv.Atoi(getenv(p.Prefix, "MAX_CONNS"))
 	if maxConns == 0 {
 		maxConns = 10
 	}
 
-	idleConns, _ := strconv.Atoi(os.Getenv("DB_IDLE_CONNS"))
+	idleConns, _ := strconv.Atoi(getenv(p.Prefix, "IDLE_CONNS"))
 	if idleConns == 0 {
 		idleConns = 5
 	}
@@ -50,15 +52,22 @@ func (p *PostgresDB) NewDB() (entities.Database, error) {
 		return nil, err
 	}
 
-	return &PostgresDB{db: db}, nil
+	return &PostgresDB{Prefix: p.Prefix, db: db}, nil
 }
 
-func (p *PostgresDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
-	return p.db.Query(query, args...)
+func (p *PostgresDB) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	// lib/pq não suporta sql.Named: converter @param para $n
+	query, args = bindPositional(query, args)
+	return p.db.QueryContext(ctx, query, args...)
 }
 
-func (p *PostgresDB) QueryRow(query string, args ...interface{}) *sql.Row {
-	return p.db.QueryRow(query, args...)
+func (p *PostgresDB) QueryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	query, args = bindPositional(query, args)
+	return p.db.QueryRowContext(ctx, query, args...)
+}
+
+func (p *PostgresDB) Dialect() string {
+	return "postgres"
 }
 
 func (p *PostgresDB) Health() entities.DBHealth {
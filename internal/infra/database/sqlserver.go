@@ -1,9 +1,9 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"os"
 	"reports-system/internal/domain/entities"
 	"strconv"
 	"time"
@@ -12,15 +12,17 @@ import (
 )
 
 type SqlServerDB struct {
-	db *sql.DB
+	// Prefix das variáveis de ambiente da conexão (padrão "DB_")
+	Prefix string
+	db     *sql.DB
 }
 
 func (p *SqlServerDB) NewDB() (entities.Database, error) {
-	host := os.Getenv("DB_HOST")
-	//port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
+	host := getenv(p.Prefix, "HOST")
+	//port := getenv(p.Prefix, "PORT")
+	user := getenv(p.Prefix, "USER")
+	password := getenv(p.Prefix, "PASSWORD")
+	dbname := getenv(p.Prefix, "NAME")
 
 	connStr := fmt.Sprintf("sqlserver://%s:%s@%s?database=%s&encrypt=disable", user, password, host, dbname)
 	//fmt.Println("Connecting to SQL Server with connection string:", connStr)
@@ -30,12 +32,12 @@ func (p *SqlServerDB) NewDB() (entities.Database, error) {
 	}
 
 	// Configurar pool de conexões
-	maxConns, _ := strconv.Atoi(os.Getenv("DB_MAX_CONNS"))
+	maxConns, _ := strconv.Atoi(getenv(p.Prefix, "MAX_CONNS"))
 	if maxConns == 0 {
 		maxConns = 10
 	}
 
-	idleConns, _ := strconv.Atoi(os.Getenv("DB_IDLE_CONNS"))
+	idleConns, _ := strconv.Atoi(getenv(p.Prefix, "IDLE_CONNS"))
 	if idleConns == 0 {
 		idleConns = 5
 	}
@@ -48,16 +50,20 @@ func (p *SqlServerDB) NewDB() (entities.Database, error) {
 		return nil, err
 	}
 
-	return &SqlServerDB{db: db}, nil
+	return &SqlServerDB{Prefix: p.Prefix, db: db}, nil
 }
 
-func (p *SqlServerDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+func (p *SqlServerDB) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
 	fmt.Println("Executing query:", query, "with args:", args)
-	return p.db.Query(query, args...)
+	return p.db.QueryContext(ctx, query, args...)
 }
 
-func (p *SqlServerDB) QueryRow(query string, args ...interface{}) *sql.Row {
-	return p.db.QueryRow(query, args...)
+func (p *SqlServerDB) QueryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return p.db.QueryRowContext(ctx, query, args...)
+}
+
+func (p *SqlServerDB) Dialect() string {
+	return "sqlserver"
 }
 
 func (p *SqlServerDB) Health() entities.DBHealth {
@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"reports-system/internal/domain/entities"
+	"reports-system/pkg/query"
+)
+
+// StartCacheWarming inicia um worker para cada relatório com warmup
+// configurado. Os workers executam os conjuntos de parâmetros declarados no
+// horário agendado e gravam o resultado no cache. ReloadQueries reinicia os
+// workers com a nova configuração.
+func (s *ReportService) StartCacheWarming() {
+	s.warmupMu.Lock()
+	defer s.warmupMu.Unlock()
+
+	if s.warmupStop != nil {
+		return
+	}
+	s.startWarmupWorkers()
+}
+
+func (s *ReportService) StopCacheWarming() {
+	s.warmupMu.Lock()
+	defer s.warmupMu.Unlock()
+
+	if s.warmupStop == nil {
+		return
+	}
+	close(s.warmupStop)
+	s.warmupStop = nil
+}
+
+// restartCacheWarming troca os workers em execução pelos da configuração
+// atual; sem warmup iniciado não faz nada
+func (s *ReportService) restartCacheWarming() {
+	s.warmupMu.Lock()
+	defer s.warmupMu.Unlock()
+
+	if s.warmupStop == nil {
+		return
+	}
+	close(s.warmupStop)
+	s.startWarmupWorkers()
+}
+
+// startWarmupWorkers deve ser chamado com warmupMu
+func (s *ReportService) startWarmupWorkers() {
+	s.warmupStop = make(chan struct{})
+
+	_, configs := s.snapshot()
+	for name, conf := range configs {
+		if conf.Warmup == nil || len(conf.Warmup.Params) == 0 {
+			continue
+		}
+		go s.runWarmupWorker(name, *conf.Warmup, s.warmupStop)
+	}
+}
+
+func (s *ReportService) runWarmupWorker(reportID string, warmup entities.WarmupConfig, stop <-chan struct{}) {
+	if warmup.OnStartup {
+		s.WarmReport(reportID)
+	}
+
+	for {
+		next, err := query.NextScheduledRun(warmup.Schedule, time.Now())
+		if err != nil {
+			log.Printf("Cache warmup for '%s' disabled: %v", reportID, err)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.WarmReport(reportID)
+		}
+	}
+}
+
+// WarmReport executa todos os conjuntos de parâmetros de warmup do relatório,
+// ignorando o conteúdo atual do cache.
+func (s *ReportService) WarmReport(reportID string) error {
+	query, conf, exists := s.report(reportID)
+	if !exists {
+		return fmt.Errorf("report '%s' not found", reportID)
+	}
+	if conf.Warmup == nil {
+		return fmt.Errorf("report '%s' has no warmup configured", reportID)
+	}
+
+	var lastErr error
+	for i, warmupParams := range conf.Warmup.Params {
+		// Copiar para que a resolução de defaults não altere a configuração
+		params := make(map[string]interface{}, len(warmupParams))
+		for name, value := range warmupParams {
+			params[name] = value
+		}
+
+		if err := query.Validate(params); err != nil {
+			lastErr = fmt.Errorf("warmup params set %d: validation error: %w", i, err)
+			log.Printf("Cache warmup for '%s' failed: %v", reportID, lastErr)
+			continue
+		}
+
+		options := entities.ReportOptions{Format: "json", Shape: entities.ShapeRecords}
+		cacheKey := s.generateCacheKey(reportID, params, queryTimezone(query), options)
+		if _, err := s.executeReport(reportID, query, params, options, cacheKey); err != nil {
+			lastErr = fmt.Errorf("warmup params set %d: %w", i, err)
+			log.Printf("Cache warmup for '%s' failed: %v", reportID, lastErr)
+			continue
+		}
+
+		log.Printf("Cache warmed for '%s' (params set %d)", reportID, i)
+	}
+
+	return lastErr
+}
@@ -0,0 +1,207 @@
+package usecase
+
+import (
+	"database/sql/driver"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"reports-system/internal/domain/entities"
+)
+
+func warmupConfig(sqlQuery string, warmup *entities.WarmupConfig) entities.QueryConfig {
+	return entities.QueryConfig{
+		Name:  "sales",
+		Query: sqlQuery,
+		Parameters: []entities.ParamConfig{
+			{Name: "status", Type: "string", Default: "all"},
+			{Name: "region", Type: "string"},
+		},
+		Output: entities.OutputConfig{
+			Formats:  []string{"json", "csv"},
+			Sortable: []string{"total"},
+		},
+		CacheTTL: "1h",
+		Warmup:   warmup,
+	}
+}
+
+var salesResult = fakeResult{match: "FROM sales", columns: []string{"region", "total"}, rows: [][]driver.Value{{"Sul", int64(10)}}}
+
+// waitFor aguarda até que cond seja verdadeira, falhando após o prazo
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCacheWarmingSchedule(t *testing.T) {
+	tests := []struct {
+		name   string
+		warmup entities.WarmupConfig
+		// execuções esperadas enquanto os workers estão ativos
+		atLeast int
+		atMost  int
+	}{
+		{"interval", entities.WarmupConfig{Schedule: "@every 10ms", Params: []map[string]interface{}{{}}}, 3, -1},
+		{"every params set", entities.WarmupConfig{Schedule: "@every 10ms", Params: []map[string]interface{}{{}, {"status": "shipped"}}}, 4, -1},
+		{"on startup", entities.WarmupConfig{Schedule: "06:00", OnStartup: true, Params: []map[string]interface{}{{}}}, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newFakeDB(salesResult)
+			service, _ := newTestService(t, db, warmupConfig("SELECT region, total FROM sales", &tt.warmup))
+
+			service.StartCacheWarming()
+			// Uma segunda chamada não inicia outros workers
+			service.StartCacheWarming()
+			waitFor(t, "warmup runs", func() bool { return len(db.executed("FROM sales")) >= tt.atLeast })
+			if tt.atMost >= 0 {
+				time.Sleep(50 * time.Millisecond)
+				if n := len(db.executed("FROM sales")); n > tt.atMost {
+					t.Errorf("warmup runs = %d, want at most %d", n, tt.atMost)
+				}
+			}
+
+			service.StopCacheWarming()
+			time.Sleep(30 * time.Millisecond)
+			stopped := len(db.executed("FROM sales"))
+			time.Sleep(50 * time.Millisecond)
+			if n := len(db.executed("FROM sales")); n != stopped {
+				t.Errorf("warmup ran %d times after StopCacheWarming", n-stopped)
+			}
+		})
+	}
+}
+
+func TestReloadRestartsCacheWarming(t *testing.T) {
+	db := newFakeDB(salesResult, fakeResult{match: "FROM sales_v2", columns: []string{"region", "total"}})
+	every := &entities.WarmupConfig{Schedule: "@every 10ms", Params: []map[string]interface{}{{}}}
+	service, dir := newTestService(t, db, warmupConfig("SELECT region, total FROM sales", every))
+
+	service.StartCacheWarming()
+	defer service.StopCacheWarming()
+	waitFor(t, "warmup with the first config", func() bool { return countExact(db, "FROM sales") > 0 })
+
+	writeTestConfigs(t, dir, warmupConfig("SELECT region, total FROM sales_v2", every))
+	if err := service.ReloadQueries(); err != nil {
+		t.Fatalf("ReloadQueries: %v", err)
+	}
+
+	waitFor(t, "warmup with the reloaded config", func() bool { return len(db.executed("FROM sales_v2")) >= 2 })
+	time.Sleep(30 * time.Millisecond)
+	before := countExact(db, "FROM sales")
+	time.Sleep(50 * time.Millisecond)
+	if after := countExact(db, "FROM sales"); after != before {
+		t.Errorf("worker with the old config still running (%d -> %d runs)", before, after)
+	}
+}
+
+// countExact conta as queries sobre a tabela indicada, sem incluir tabelas
+// com o mesmo prefixo (sales e sales_v2)
+func countExact(db *fakeDB, from string) int {
+	count := 0
+	for _, q := range db.executed(from) {
+		if !strings.Contains(q.sql, from+"_") {
+			count++
+		}
+	}
+	return count
+}
+
+func TestWarmReportCacheKey(t *testing.T) {
+	db := newFakeDB(salesResult)
+	warmup := &entities.WarmupConfig{Schedule: "06:00", Params: []map[string]interface{}{{}, {"status": "shipped"}}}
+	service, _ := newTestService(t, db, warmupConfig("SELECT region, total FROM sales", warmup))
+
+	if err := service.WarmReport("sales"); err != nil {
+		t.Fatalf("WarmReport: %v", err)
+	}
+	if n := len(db.executed("FROM sales")); n != 2 {
+		t.Fatalf("warmup queries = %d, want 2", n)
+	}
+
+	tests := []struct {
+		name    string
+		params  map[string]interface{}
+		options entities.ReportOptions
+		hit     bool
+	}{
+		{"empty params", map[string]interface{}{}, entities.ReportOptions{}, true},
+		{"explicit default", map[string]interface{}{"status": "all"}, entities.ReportOptions{}, true},
+		{"warmed params", map[string]interface{}{"status": "shipped"}, entities.ReportOptions{}, true},
+		{"records shape", map[string]interface{}{"status": "shipped"}, entities.ReportOptions{Shape: entities.ShapeRecords}, true},
+		{"other format", map[string]interface{}{}, entities.ReportOptions{Format: "csv"}, true},
+		{"other params", map[string]interface{}{"status": "pending"}, entities.ReportOptions{}, false},
+		{"extra param", map[string]interface{}{"region": "Sul"}, entities.ReportOptions{}, false},
+		{"sorted", map[string]interface{}{}, entities.ReportOptions{Sort: []string{"total"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := service.GetReport("sales", tt.params, tt.options)
+			if err != nil {
+				t.Fatalf("GetReport: %v", err)
+			}
+			if report.Metadata.CacheHit != tt.hit {
+				t.Errorf("CacheHit = %v, want %v", report.Metadata.CacheHit, tt.hit)
+			}
+		})
+	}
+}
+
+func TestWarmReportErrors(t *testing.T) {
+	db := newFakeDB(fakeResult{match: "FROM sales", err: errFake})
+	warmup := &entities.WarmupConfig{Schedule: "06:00", Params: []map[string]interface{}{{}}}
+	other := entities.QueryConfig{Name: "other", Query: "SELECT 1"}
+	service, _ := newTestService(t, db, warmupConfig("SELECT region, total FROM sales", warmup), other)
+
+	tests := []struct {
+		reportID string
+	}{{"sales"}, {"other"}, {"missing"}}
+
+	for _, tt := range tests {
+		t.Run(tt.reportID, func(t *testing.T) {
+			if err := service.WarmReport(tt.reportID); err == nil {
+				t.Errorf("WarmReport(%s) succeeded", tt.reportID)
+			}
+		})
+	}
+}
+
+// Recargas trocam as queries enquanto requisições e o warmup as leem; rodar
+// com -race para detectar acessos sem sincronização
+func TestReloadDuringWarmup(t *testing.T) {
+	db := newFakeDB(salesResult)
+	warmup := &entities.WarmupConfig{Schedule: "@every 1ms", Params: []map[string]interface{}{{}}}
+	service, _ := newTestService(t, db, warmupConfig("SELECT region, total FROM sales", warmup))
+
+	service.StartCacheWarming()
+	defer service.StopCacheWarming()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				service.WarmReport("sales")
+				service.GetReport("sales", map[string]interface{}{}, entities.ReportOptions{})
+				service.GetAvailableReports()
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		if err := service.ReloadQueries(); err != nil {
+			t.Fatalf("ReloadQueries: %v", err)
+		}
+	}
+	wg.Wait()
+}
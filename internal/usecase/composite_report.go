@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"reports-system/internal/domain/entities"
+)
+
+// executeComposite executa as seções de um relatório composto. Cada seção
+// segue o fluxo normal do seu relatório (validação, datasource, cache e
+// transformações); o composto apenas reúne os resultados na ordem declarada.
+func (s *ReportService) executeComposite(reportID string, query entities.Query, params map[string]interface{}, options entities.ReportOptions) (*entities.ReportResponse, error) {
+	conf := s.queryConfig(reportID)
+
+	format, err := s.resolveFormat(reportID, options.Format)
+	if err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	options.Format = format
+
+	if options.Page != nil || len(options.Sort) > 0 || len(options.Fields) > 0 {
+		return nil, fmt.Errorf("validation error: %w", &entities.ValidationError{Errors: []entities.FieldError{{
+			Param:   "page,sort,fields",
+			Rule:    "unsupported",
+			Message: "pagination, sort and field selection are not supported by composite reports",
+		}}})
+	}
+
+	// As seções usam o fuso do composto (requisição ou configuração)
+	sectionOptions := entities.ReportOptions{Format: "json", Timezone: queryTimezone(query), Shape: options.Shape}
+
+	sections := make([]entities.ReportSection, len(conf.Sections))
+	expires := make([]time.Time, len(conf.Sections))
+	errs := make([]error, len(conf.Sections))
+
+	run := func(i int) {
+		section := conf.Sections[i]
+		response, err := s.GetReport(section.Report, s.sectionParams(section, params), sectionOptions)
+		if err != nil {
+			errs[i] = fmt.Errorf("section '%s': %w", section.Name, err)
+			return
+		}
+
+		sections[i] = entities.ReportSection{
+			Name:     section.Name,
+			Report:   section.Report,
+			CacheHit: response.Metadata.CacheHit,
+			Columns:  response.Columns,
+			Data:     response.Data,
+		}
+		expires[i] = response.Metadata.ExpiresAt
+	}
+
+	if conf.Parallel {
+		var wg sync.WaitGroup
+		for i := range conf.Sections {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				run(i)
+			}(i)
+		}
+		wg.Wait()
+	} else {
+		for i := range conf.Sections {
+			run(i)
+			if errs[i] != nil {
+				break
+			}
+		}
+	}
+
+	// Com seções em paralelo, o erro reportado é o da primeira seção
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// O composto expira junto com a primeira seção e só é um acerto de
+	// cache se todas as seções foram
+	generatedAt := time.Now()
+	expiresAt := generatedAt.Add(query.CacheTTL())
+	cacheHit := true
+	for i, section := range sections {
+		if expires[i].Before(expiresAt) {
+			expiresAt = expires[i]
+		}
+		cacheHit = cacheHit && section.CacheHit
+	}
+
+	cacheKey := s.generateCacheKey(reportID, params, queryTimezone(query), options)
+	return &entities.ReportResponse{
+		Metadata: entities.ReportMetadata{
+			Report:      reportID,
+			Params:      params,
+			GeneratedAt: generatedAt,
+			ExpiresAt:   expiresAt,
+			Format:      options.Format,
+			Timezone:    queryTimezone(query),
+			ETag:        s.generateETag(cacheKey, sections),
+			CacheHit:    cacheHit,
+			Shape:       options.Shape,
+		},
+		Sections: sections,
+	}, nil
+}
+
+// sectionParams monta os parâmetros da seção: os do composto declarados pelo
+// relatório da seção, sobrepostos pelos valores fixos da seção. Cada seção
+// recebe um mapa próprio, já que a validação converte os valores.
+func (s *ReportService) sectionParams(section entities.SectionConfig, params map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+
+	for _, param := range s.queryConfig(section.Report).Parameters {
+		if value, ok := params[param.Name]; ok {
+			result[param.Name] = value
+		}
+	}
+	for name, value := range section.Params {
+		result[name] = value
+	}
+
+	return result
+}
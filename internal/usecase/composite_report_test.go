@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	"reports-system/internal/domain/entities"
+)
+
+func compositeConfigs(parallel bool) []entities.QueryConfig {
+	return []entities.QueryConfig{
+		{
+			Name:  "sales",
+			Query: "SELECT region, total FROM sales WHERE region = @region AND status = @status",
+			Parameters: []entities.ParamConfig{
+				{Name: "region", Type: "string", Required: true},
+				{Name: "status", Type: "string", Default: "all"},
+			},
+		},
+		{
+			Name:       "stock",
+			Query:      "SELECT region, items FROM stock WHERE region = @region",
+			Parameters: []entities.ParamConfig{{Name: "region", Type: "string", Required: true}},
+			Datasource: "warehouse",
+		},
+		{
+			Name:       "overview",
+			Parameters: []entities.ParamConfig{{Name: "region", Type: "string", Required: true}},
+			Sections: []entities.SectionConfig{
+				{Name: "vendas", Report: "sales", Params: map[string]interface{}{"status": "paid"}},
+				{Name: "estoque", Report: "stock"},
+			},
+			Parallel: parallel,
+		},
+	}
+}
+
+func TestCompositeReport(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		name := "sequential"
+		if parallel {
+			name = "parallel"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			main := newFakeDB(salesResult)
+			warehouse := newFakeDB(fakeResult{match: "FROM stock", columns: []string{"region", "items"}, rows: [][]driver.Value{{"Sul", int64(4)}}})
+			service, _ := newTestService(t, main, compositeConfigs(parallel)...)
+			service.RegisterDatasource("warehouse", warehouse)
+			if err := service.ValidateDatasources(); err != nil {
+				t.Fatalf("ValidateDatasources: %v", err)
+			}
+
+			report, err := service.GetReport("overview", map[string]interface{}{"region": "Sul"}, entities.ReportOptions{})
+			if err != nil {
+				t.Fatalf("GetReport: %v", err)
+			}
+
+			var names []string
+			for _, section := range report.Sections {
+				names = append(names, section.Name+":"+section.Report)
+			}
+			if want := []string{"vendas:sales", "estoque:stock"}; !reflect.DeepEqual(names, want) {
+				t.Errorf("sections = %v, want %v", names, want)
+			}
+
+			// Cada seção roda no seu datasource, com os parâmetros
+			// compartilhados e os fixos da seção
+			sales, stock := main.executed("FROM sales"), warehouse.executed("FROM stock")
+			if len(sales) != 1 || len(stock) != 1 || len(main.executed("FROM stock")) != 0 {
+				t.Fatalf("queries: sales = %d, stock = %d, stock on main = %d", len(sales), len(stock), len(main.executed("FROM stock")))
+			}
+			if args := namedArgs(sales[0]); !reflect.DeepEqual(args, map[string]interface{}{"region": "Sul", "status": "paid"}) {
+				t.Errorf("sales args = %v", args)
+			}
+			if args := namedArgs(stock[0]); !reflect.DeepEqual(args, map[string]interface{}{"region": "Sul"}) {
+				t.Errorf("stock args = %v", args)
+			}
+
+			if report.Metadata.CacheHit {
+				t.Error("first composite read is a cache hit")
+			}
+			report, err = service.GetReport("overview", map[string]interface{}{"region": "Sul"}, entities.ReportOptions{})
+			if err != nil {
+				t.Fatalf("second GetReport: %v", err)
+			}
+			if !report.Metadata.CacheHit || !report.Sections[0].CacheHit || !report.Sections[1].CacheHit {
+				t.Error("second composite read is not a cache hit")
+			}
+		})
+	}
+}
+
+func TestCompositeReportErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  map[string]interface{}
+		options entities.ReportOptions
+		kind    entities.ErrorKind
+		message string
+	}{
+		{"failing section", map[string]interface{}{"region": "Sul"}, entities.ReportOptions{}, entities.ErrorExecution, "section 'estoque'"},
+		{"missing shared param", map[string]interface{}{}, entities.ReportOptions{}, entities.ErrorValidation, "region"},
+		{"sort", map[string]interface{}{"region": "Sul"}, entities.ReportOptions{Sort: []string{"total"}}, entities.ErrorValidation, "composite"},
+		{"fields", map[string]interface{}{"region": "Sul"}, entities.ReportOptions{Fields: []string{"total"}}, entities.ErrorValidation, "composite"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := newTestService(t, newFakeDB(salesResult), compositeConfigs(false)...)
+			service.RegisterDatasource("warehouse", newFakeDB(fakeResult{match: "FROM stock", err: errFake}))
+
+			_, err := service.GetReport("overview", tt.params, tt.options)
+			if entities.ErrorKindOf(err) != tt.kind || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("error = %v, want kind %s mentioning %q", err, tt.kind, tt.message)
+			}
+		})
+	}
+}
+
+func TestValidateDatasources(t *testing.T) {
+	service, dir := newTestService(t, newFakeDB(), compositeConfigs(false)...)
+
+	if err := service.ValidateDatasources(); err == nil || !strings.Contains(err.Error(), "warehouse") {
+		t.Fatalf("ValidateDatasources error = %v, want missing warehouse", err)
+	}
+	if _, err := service.GetReport("stock", map[string]interface{}{"region": "Sul"}, entities.ReportOptions{}); entities.ErrorKindOf(err) != entities.ErrorExecution {
+		t.Errorf("GetReport without datasource = %v, want execution error", err)
+	}
+
+	service.RegisterDatasource("warehouse", newFakeDB())
+	if err := service.ValidateDatasources(); err != nil {
+		t.Fatalf("ValidateDatasources: %v", err)
+	}
+
+	// Após a validação, recargas com datasources desconhecidos são
+	// rejeitadas e mantêm a configuração anterior
+	broken := compositeConfigs(false)[1]
+	broken.Datasource = "archive"
+	writeTestConfigs(t, dir, broken)
+	if err := service.ReloadQueries(); err == nil || !strings.Contains(err.Error(), "archive") {
+		t.Errorf("ReloadQueries error = %v, want missing archive", err)
+	}
+	if conf := service.queryConfig("stock"); conf.Datasource != "warehouse" {
+		t.Errorf("stock datasource = %q after failed reload, want warehouse", conf.Datasource)
+	}
+}
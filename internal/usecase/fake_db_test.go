@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"reports-system/internal/domain/entities"
+	"reports-system/internal/infra/cache"
+	"reports-system/pkg/query"
+)
+
+var errFake = errors.New("fake database failure")
+
+// fakeResult é devolvido pelo fakeDB para as queries cujo SQL contém match
+type fakeResult struct {
+	match   string
+	columns []string
+	// types informa o DatabaseTypeName de cada coluna
+	types []string
+	rows  [][]driver.Value
+	err   error
+}
+
+type fakeQuery struct {
+	sql  string
+	args []interface{}
+}
+
+// fakeDB implementa entities.Database sobre um driver em memória e registra
+// as queries recebidas
+type fakeDB struct {
+	db      *sql.DB
+	dialect string
+
+	mu      sync.Mutex
+	results []fakeResult
+	queries []fakeQuery
+}
+
+func newFakeDB(results ...fakeResult) *fakeDB {
+	f := &fakeDB{dialect: query.DialectPostgres, results: results}
+	f.db = sql.OpenDB(fakeConnector{f})
+	return f
+}
+
+// executed retorna as queries recebidas cujo SQL contém match
+func (f *fakeDB) executed(match string) []fakeQuery {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var queries []fakeQuery
+	for _, q := range f.queries {
+		if strings.Contains(q.sql, match) {
+			queries = append(queries, q)
+		}
+	}
+	return queries
+}
+
+func (f *fakeDB) result(sqlQuery string) (fakeResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, result := range f.results {
+		if strings.Contains(sqlQuery, result.match) {
+			return result, result.err
+		}
+	}
+	return fakeResult{}, fmt.Errorf("no fake result for %q", sqlQuery)
+}
+
+func (f *fakeDB) NewDB() (entities.Database, error) { return f, nil }
+
+// Query registra os argumentos (inclusive sql.Named) e executa o SQL no
+// driver em memória, que só considera o texto da query
+func (f *fakeDB) Query(ctx context.Context, sqlQuery string, args ...interface{}) (*sql.Rows, error) {
+	f.mu.Lock()
+	f.queries = append(f.queries, fakeQuery{sql: sqlQuery, args: args})
+	f.mu.Unlock()
+	return f.db.QueryContext(ctx, sqlQuery)
+}
+
+func (f *fakeDB) QueryRow(ctx context.Context, sqlQuery string, args ...interface{}) *sql.Row {
+	f.mu.Lock()
+	f.queries = append(f.queries, fakeQuery{sql: sqlQuery, args: args})
+	f.mu.Unlock()
+	return f.db.QueryRowContext(ctx, sqlQuery)
+}
+
+func (f *fakeDB) Dialect() string           { return f.dialect }
+func (f *fakeDB) Health() entities.DBHealth { return entities.DBHealth{} }
+func (f *fakeDB) Close() error              { return f.db.Close() }
+
+type fakeConnector struct{ db *fakeDB }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn(c), nil }
+func (c fakeConnector) Driver() driver.Driver                        { return nil }
+
+type fakeConn fakeConnector
+
+func (c fakeConn) Prepare(sqlQuery string) (driver.Stmt, error) {
+	return fakeStmt{db: c.db, sql: sqlQuery}, nil
+}
+func (c fakeConn) Close() error              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error) { return nil, driver.ErrSkip }
+
+type fakeStmt struct {
+	db  *fakeDB
+	sql string
+}
+
+func (s fakeStmt) Close() error                                    { return nil }
+func (s fakeStmt) NumInput() int                                   { return -1 }
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) { return nil, driver.ErrSkip }
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	result, err := s.db.result(s.sql)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{result: result}, nil
+}
+
+type fakeRows struct {
+	result fakeResult
+	next   int
+}
+
+func (r *fakeRows) Columns() []string { return r.result.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.rows) {
+		return io.EOF
+	}
+	copy(dest, r.result.rows[r.next])
+	r.next++
+	return nil
+}
+
+func (r *fakeRows) ColumnTypeDatabaseTypeName(index int) string {
+	if index < len(r.result.types) {
+		return r.result.types[index]
+	}
+	return ""
+}
+
+// newTestService grava as configurações em um diretório temporário e cria o
+// serviço com cache em memória. O diretório é retornado para testes de
+// recarga.
+func newTestService(t *testing.T, db entities.Database, configs ...entities.QueryConfig) (*ReportService, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeTestConfigs(t, dir, configs...)
+	return NewReportService(db, cache.NewMemoryCache(), dir), dir
+}
+
+func writeTestConfigs(t *testing.T, dir string, configs ...entities.QueryConfig) {
+	t.Helper()
+
+	for _, config := range configs {
+		data, err := json.Marshal(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, config.Name+".json"), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
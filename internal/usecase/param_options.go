@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"reports-system/internal/domain/entities"
+	"reports-system/pkg/query"
+)
+
+const defaultOptionsTTL = 10 * time.Minute
+
+// optionsQuery é implementado pelas queries que expõem opções de parâmetros
+type optionsQuery interface {
+	Options(paramName string, params map[string]interface{}) ([]entities.ParamOption, error)
+}
+
+// GetParamOptions retorna as opções de um parâmetro para montagem de
+// dropdowns na interface
+func (s *ReportService) GetParamOptions(reportID, paramName string, params map[string]interface{}) ([]entities.ParamOption, error) {
+	reportQuery, _, exists := s.report(reportID)
+	if !exists {
+		return nil, &entities.ReportError{Kind: entities.ErrorNotFound, Message: fmt.Sprintf("report '%s' not found", reportID)}
+	}
+
+	source, ok := reportQuery.(optionsQuery)
+	if !ok {
+		return nil, &entities.ReportError{Kind: entities.ErrorNotFound, Message: fmt.Sprintf("report '%s' does not expose parameter options", reportID)}
+	}
+
+	options, err := source.Options(paramName, params)
+	if err != nil {
+		if kind := entities.ErrorKindOf(err); kind == entities.ErrorParamNotFound || kind == entities.ErrorValidation || kind == entities.ErrorTimeout {
+			return nil, err
+		}
+		return nil, executionError("failed to load parameter options", err)
+	}
+
+	return options, nil
+}
+
+// loadParamOptions executa a query de opções no datasource do relatório,
+// mantendo o resultado em cache pelo TTL configurado no parâmetro
+func (s *ReportService) loadParamOptions(reportID string, param entities.ParamConfig, params map[string]interface{}) ([]entities.ParamOption, error) {
+	cacheKey := s.optionsCacheKey(reportID, param.Name, params)
+	if cached, err := s.cache.Get(cacheKey); err == nil {
+		// UseNumber mantém os valores numéricos exatos (json.Number), como
+		// na leitura de relatórios do cache
+		var options []entities.ParamOption
+		decoder := json.NewDecoder(bytes.NewReader(cached))
+		decoder.UseNumber()
+		if err := decoder.Decode(&options); err == nil {
+			return options, nil
+		}
+	}
+
+	db, err := s.database(reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.queryContext()
+	defer cancel()
+
+	sqlQuery, args := query.BindNamedParams(param.Options.Query, params)
+	rows, err := db.Query(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, queryError(ctx, "options query error", err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns: %w", err)
+	}
+	columns := make([]string, len(columnTypes))
+	for i, columnType := range columnTypes {
+		columns[i] = columnType.Name()
+	}
+	mapper := s.valueMapper(reportID, columnTypes)
+
+	valueIndex, labelIndex := 0, 0
+	if len(columns) > 1 {
+		labelIndex = 1
+	}
+	for i, col := range columns {
+		if col == param.Options.ValueColumn {
+			valueIndex = i
+		}
+		if col == param.Options.LabelColumn {
+			labelIndex = i
+		}
+	}
+
+	options := make([]entities.ParamOption, 0)
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, queryError(ctx, "failed to scan row", err)
+		}
+
+		mapper.MapRow(values)
+		options = append(options, entities.ParamOption{Value: values[valueIndex], Label: fmt.Sprintf("%v", values[labelIndex])})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, queryError(ctx, "failed to read options", err)
+	}
+
+	ttl := defaultOptionsTTL
+	if param.Options.CacheTTL != "" {
+		if duration, err := time.ParseDuration(param.Options.CacheTTL); err == nil {
+			ttl = duration
+		}
+	}
+
+	if optionsBytes, err := json.Marshal(options); err == nil {
+		s.cache.Set(cacheKey, optionsBytes, ttl)
+	}
+
+	return options, nil
+}
+
+func (s *ReportService) optionsCacheKey(reportID, paramName string, params map[string]interface{}) string {
+	// A validação passa nil quando não há pais e o endpoint um map vazio: as
+	// duas formas devem compartilhar a entrada de cache
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	paramBytes, _ := json.Marshal(params)
+
+	hash := sha256.New()
+	hash.Write([]byte(reportID))
+	hash.Write([]byte{0})
+	hash.Write([]byte(paramName))
+	hash.Write([]byte{0})
+	hash.Write(paramBytes)
+	return fmt.Sprintf("options:%s:%s:%x", reportID, paramName, hash.Sum(nil))
+}
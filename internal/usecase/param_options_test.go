@@ -0,0 +1,247 @@
+package usecase
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+
+	"reports-system/internal/domain/entities"
+)
+
+func ordersConfig() entities.QueryConfig {
+	return entities.QueryConfig{
+		Name:  "orders",
+		Query: "SELECT id, total FROM orders WHERE status = @status",
+		Parameters: []entities.ParamConfig{
+			{Name: "status", Type: "enum", Required: true, Options: &entities.OptionsConfig{
+				Query:       "SELECT name, code FROM statuses ORDER BY name",
+				ValueColumn: "code",
+				LabelColumn: "name",
+				CacheTTL:    "1m",
+			}},
+			{Name: "kind", Type: "enum", Validation: map[string]interface{}{"values": []interface{}{"retail", "wholesale"}}},
+		},
+	}
+}
+
+var (
+	statusesResult = fakeResult{match: "FROM statuses", columns: []string{"name", "code"}, rows: [][]driver.Value{{"Pago", "paid"}, {"Aberto", "open"}}}
+	ordersResult   = fakeResult{match: "FROM orders", columns: []string{"id", "total"}, rows: [][]driver.Value{{int64(1), int64(10)}}}
+)
+
+// namedArgs indexa os argumentos sql.Named de uma query pelo nome
+func namedArgs(q fakeQuery) map[string]interface{} {
+	args := make(map[string]interface{}, len(q.args))
+	for _, arg := range q.args {
+		if named, ok := arg.(sql.NamedArg); ok {
+			args[named.Name] = named.Value
+		}
+	}
+	return args
+}
+
+func TestGetParamOptions(t *testing.T) {
+	service, _ := newTestService(t, newFakeDB(statusesResult), ordersConfig())
+
+	tests := []struct {
+		name     string
+		reportID string
+		param    string
+		want     []entities.ParamOption
+		kind     entities.ErrorKind
+	}{
+		{"lookup query", "orders", "status", []entities.ParamOption{{Value: "paid", Label: "Pago"}, {Value: "open", Label: "Aberto"}}, ""},
+		{"static enum", "orders", "kind", []entities.ParamOption{{Value: "retail", Label: "retail"}, {Value: "wholesale", Label: "wholesale"}}, ""},
+		{"unknown param", "orders", "missing", nil, entities.ErrorParamNotFound},
+		{"unknown report", "missing", "status", nil, entities.ErrorNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, err := service.GetParamOptions(tt.reportID, tt.param, map[string]interface{}{})
+			if tt.kind != "" {
+				if entities.ErrorKindOf(err) != tt.kind {
+					t.Errorf("error = %v, want kind %s", err, tt.kind)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetParamOptions: %v", err)
+			}
+			if !reflect.DeepEqual(options, tt.want) {
+				t.Errorf("options = %v, want %v", options, tt.want)
+			}
+		})
+	}
+}
+
+func TestParamOptionsCache(t *testing.T) {
+	db := newFakeDB(statusesResult, ordersResult)
+	service, _ := newTestService(t, db, ordersConfig())
+
+	for i := 0; i < 2; i++ {
+		if _, err := service.GetParamOptions("orders", "status", map[string]interface{}{}); err != nil {
+			t.Fatalf("GetParamOptions: %v", err)
+		}
+	}
+	// A validação do relatório reaproveita as opções em cache
+	if _, err := service.GetReport("orders", map[string]interface{}{"status": "paid"}, entities.ReportOptions{}); err != nil {
+		t.Fatalf("GetReport: %v", err)
+	}
+
+	if n := len(db.executed("FROM statuses")); n != 1 {
+		t.Errorf("options queries = %d, want 1", n)
+	}
+}
+
+func TestReportOptionsValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []fakeResult
+		status  string
+		// kind esperado; vazio quando o relatório é gerado
+		kind entities.ErrorKind
+	}{
+		{"valid option", []fakeResult{statusesResult, ordersResult}, "paid", ""},
+		{"unknown option", []fakeResult{statusesResult, ordersResult}, "closed", entities.ErrorValidation},
+		{"label is not a value", []fakeResult{statusesResult, ordersResult}, "Pago", entities.ErrorValidation},
+		{"options query fails", []fakeResult{{match: "FROM statuses", err: errFake}, ordersResult}, "paid", entities.ErrorExecution},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newFakeDB(tt.results...)
+			service, _ := newTestService(t, db, ordersConfig())
+
+			_, err := service.GetReport("orders", map[string]interface{}{"status": tt.status}, entities.ReportOptions{})
+			if tt.kind == "" {
+				if err != nil {
+					t.Fatalf("GetReport: %v", err)
+				}
+				return
+			}
+			if kind := entities.ErrorKindOf(err); kind != tt.kind {
+				t.Fatalf("error = %v (%s), want kind %s", err, kind, tt.kind)
+			}
+			if len(db.executed("FROM orders")) != 0 {
+				t.Error("report query executed after failed validation")
+			}
+			var validationErr *entities.ValidationError
+			if tt.kind == entities.ErrorValidation && (!errors.As(err, &validationErr) || validationErr.Errors[0].Rule != "options") {
+				t.Errorf("error = %v, want options rule", err)
+			}
+		})
+	}
+}
+
+func locationsConfig() entities.QueryConfig {
+	lookup := func(sqlQuery string, dependsOn ...string) *entities.OptionsConfig {
+		return &entities.OptionsConfig{Query: sqlQuery, ValueColumn: "code", LabelColumn: "name", DependsOn: dependsOn}
+	}
+	return entities.QueryConfig{
+		Name:  "customers",
+		Query: "SELECT id, name FROM customers WHERE country = @country",
+		Parameters: []entities.ParamConfig{
+			{Name: "country", Type: "enum", Required: true, Options: lookup("SELECT code, name FROM countries")},
+			{Name: "state", Type: "enum", Options: lookup("SELECT code, name FROM states WHERE country = @country", "country")},
+			{Name: "city", Type: "enum", Options: lookup("SELECT code, name FROM cities WHERE country = @country AND (@state IS NULL OR state = @state)", "country", "state")},
+		},
+	}
+}
+
+func locationsDB() *fakeDB {
+	columns := []string{"code", "name"}
+	return newFakeDB(
+		fakeResult{match: "FROM countries", columns: columns, rows: [][]driver.Value{{"BR", "Brasil"}, {"AR", "Argentina"}}},
+		fakeResult{match: "FROM states", columns: columns, rows: [][]driver.Value{{"SP", "São Paulo"}, {"PR", "Paraná"}}},
+		fakeResult{match: "FROM cities", columns: columns, rows: [][]driver.Value{{"CWB", "Curitiba"}}},
+		fakeResult{match: "FROM customers", columns: []string{"id", "name"}, rows: [][]driver.Value{{int64(1), "ACME"}}},
+	)
+}
+
+func TestCascadingParamOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		param  string
+		params map[string]interface{}
+		// argumentos esperados na query de opções
+		args map[string]interface{}
+		kind entities.ErrorKind
+	}{
+		{"filtered by parent", "state", map[string]interface{}{"country": "BR"}, map[string]interface{}{"country": "BR"}, ""},
+		{"optional parent sent as NULL", "city", map[string]interface{}{"country": "BR"}, map[string]interface{}{"country": "BR", "state": nil}, ""},
+		{"empty parent sent as NULL", "city", map[string]interface{}{"country": "BR", "state": ""}, map[string]interface{}{"country": "BR", "state": nil}, ""},
+		{"every parent", "city", map[string]interface{}{"country": "BR", "state": "PR"}, map[string]interface{}{"country": "BR", "state": "PR"}, ""},
+		{"required parent missing", "state", map[string]interface{}{}, nil, entities.ErrorValidation},
+		{"invalid parent", "state", map[string]interface{}{"country": "XX"}, nil, entities.ErrorValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := locationsDB()
+			service, _ := newTestService(t, db, locationsConfig())
+
+			options, err := service.GetParamOptions("customers", tt.param, tt.params)
+			if tt.kind != "" {
+				if entities.ErrorKindOf(err) != tt.kind {
+					t.Errorf("error = %v, want kind %s", err, tt.kind)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetParamOptions: %v", err)
+			}
+			if len(options) == 0 {
+				t.Error("no options returned")
+			}
+
+			queries := db.executed(map[string]string{"state": "FROM states", "city": "FROM cities"}[tt.param])
+			if len(queries) != 1 {
+				t.Fatalf("options queries = %d, want 1", len(queries))
+			}
+			if args := namedArgs(queries[0]); !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("options args = %v, want %v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestCascadingParamValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]interface{}
+		// parâmetro rejeitado; vazio quando o relatório é gerado
+		invalid string
+	}{
+		{"consistent values", map[string]interface{}{"country": "BR", "state": "SP"}, ""},
+		{"child outside parent", map[string]interface{}{"country": "BR", "state": "RJ"}, "state"},
+		{"grandchild with NULL parent", map[string]interface{}{"country": "BR", "city": "CWB"}, ""},
+		{"grandchild outside parents", map[string]interface{}{"country": "BR", "state": "PR", "city": "SAO"}, "city"},
+		{"invalid root", map[string]interface{}{"country": "XX"}, "country"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := locationsDB()
+			service, _ := newTestService(t, db, locationsConfig())
+
+			_, err := service.GetReport("customers", tt.params, entities.ReportOptions{})
+			if tt.invalid == "" {
+				if err != nil {
+					t.Fatalf("GetReport: %v", err)
+				}
+				return
+			}
+
+			var validationErr *entities.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("error = %v, want validation error", err)
+			}
+			if param := validationErr.Errors[0].Param; param != tt.invalid {
+				t.Errorf("invalid param = %s, want %s", param, tt.invalid)
+			}
+		})
+	}
+}
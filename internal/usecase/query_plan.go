@@ -0,0 +1,274 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"reports-system/internal/domain/entities"
+	"reports-system/pkg/query"
+)
+
+// queryPlan reúne as transformações pedidas pelo cliente sobre o SQL do
+// relatório (ordenação, paginação e seleção de campos) e o estado da página
+// retornada
+type queryPlan struct {
+	query   string
+	args    []interface{}
+	page    *entities.PageRequest
+	key     string
+	total   *int64
+	hasMore bool
+	cursor  string
+	columns []string
+	sort    []string
+	fields  []string
+	shape   string
+}
+
+// resolveOptions valida o formato, a paginação, a ordenação e os campos
+// pedidos e retorna as opções normalizadas
+func (s *ReportService) resolveOptions(reportID string, options entities.ReportOptions) (entities.ReportOptions, error) {
+	conf := s.queryConfig(reportID)
+
+	format, err := s.resolveFormat(reportID, options.Format)
+	if err != nil {
+		return options, err
+	}
+	options.Format = format
+
+	if options.Page != nil {
+		page, err := query.ResolvePage(&conf, *options.Page)
+		if err != nil {
+			return options, err
+		}
+		options.Page = &page
+
+		if page.Cursor != "" && len(options.Sort) > 0 {
+			return options, &entities.ValidationError{Errors: []entities.FieldError{{
+				Param:   "sort,cursor",
+				Rule:    "unsupported",
+				Message: "sort is not supported with cursor pagination",
+			}}}
+		}
+	}
+
+	// O pivot agrega todas as linhas da query: páginas e seleção de campos
+	// se aplicariam às linhas originais, e não às da tabela cruzada
+	if conf.Output.Pivot != nil && (options.Page != nil || len(options.Fields) > 0) {
+		return options, &entities.ValidationError{Errors: []entities.FieldError{{
+			Param:   "page,fields",
+			Rule:    "unsupported",
+			Message: "pagination and field selection are not supported by pivot reports",
+		}}}
+	}
+
+	// Subtotais e totais são calculados sobre todas as linhas da query e
+	// dependem dos campos de grupo e agregados
+	if conf.Output.Grouping != nil {
+		if options.Page != nil {
+			return options, &entities.ValidationError{Errors: []entities.FieldError{{
+				Param:   "page",
+				Rule:    "unsupported",
+				Message: "pagination is not supported by grouped reports",
+			}}}
+		}
+		if err := requireFields(options.Fields, query.GroupingFields(conf.Output.Grouping), "grouping"); err != nil {
+			return options, err
+		}
+	}
+
+	// Os objetos aninhados são montados a partir das linhas planas do join:
+	// uma página cortaria as coleções de um objeto entre páginas
+	if conf.Output.Nest != nil {
+		if options.Page != nil {
+			return options, &entities.ValidationError{Errors: []entities.FieldError{{
+				Param:   "page",
+				Rule:    "unsupported",
+				Message: "pagination is not supported by nested reports",
+			}}}
+		}
+		if err := requireFields(options.Fields, query.NestFields(*conf.Output.Nest), "nest"); err != nil {
+			return options, err
+		}
+	}
+
+	if _, err := query.ResolveSort(&conf, options.Sort); err != nil {
+		return options, err
+	}
+	if _, err := query.ResolveFields(&conf, options.Fields); err != nil {
+		return options, err
+	}
+
+	switch options.Shape {
+	case "":
+		options.Shape = entities.ShapeRecords
+	case entities.ShapeRecords:
+	case entities.ShapeTable:
+		// Agrupamento e aninhamento geram objetos aninhados, sem formato
+		// compacto
+		if conf.Output.Grouping != nil || conf.Output.Nest != nil {
+			return options, &entities.ValidationError{Errors: []entities.FieldError{{
+				Param:   "shape",
+				Rule:    "unsupported",
+				Message: "the table shape is not supported by grouped or nested reports",
+				Value:   options.Shape,
+			}}}
+		}
+	default:
+		return options, &entities.ValidationError{Errors: []entities.FieldError{{
+			Param:   "shape",
+			Rule:    "values",
+			Message: fmt.Sprintf("shape must be '%s' or '%s'", entities.ShapeRecords, entities.ShapeTable),
+			Value:   options.Shape,
+		}}}
+	}
+
+	return options, nil
+}
+
+// requireFields verifica se a seleção de campos do cliente, quando
+// informada, inclui os campos usados por uma transformação do relatório
+func requireFields(selected, required []string, transform string) error {
+	if len(selected) == 0 {
+		return nil
+	}
+
+	var fieldErrors []entities.FieldError
+	for _, field := range required {
+		if !slices.ContainsFunc(selected, func(name string) bool { return strings.TrimSpace(name) == field }) {
+			fieldErrors = append(fieldErrors, entities.FieldError{
+				Param:   "fields",
+				Rule:    "required",
+				Message: fmt.Sprintf("field '%s' is required by the report %s", field, transform),
+				Value:   field,
+			})
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return &entities.ValidationError{Errors: fieldErrors}
+	}
+	return nil
+}
+
+// planQuery aplica ordenação e paginação ao SQL do relatório no dialeto do
+// banco e, se configurado, executa a contagem total de linhas. As opções já
+// foram validadas por resolveOptions.
+func (s *ReportService) planQuery(ctx context.Context, db entities.Database, reportID string, sqlQuery string, args []interface{}, options entities.ReportOptions) (*queryPlan, error) {
+	conf := s.queryConfig(reportID)
+	plan := &queryPlan{query: sqlQuery, args: args, page: options.Page, sort: options.Sort, fields: options.Fields, shape: options.Shape}
+
+	sort, err := query.ResolveSort(&conf, options.Sort)
+	if err != nil {
+		return nil, err
+	}
+	if plan.columns, err = query.ResolveFields(&conf, options.Fields); err != nil {
+		return nil, err
+	}
+	plan.query = query.SortQuery(db.Dialect(), sqlQuery, sort)
+
+	if options.Page == nil {
+		return plan, nil
+	}
+
+	// Com ordenação do cliente as páginas seguem essa ordem, e não a da
+	// coluna Key, então não há cursor para a próxima página. resolveOptions
+	// só aceita páginas nos relatórios com paginação configurada.
+	pagination := conf.Pagination
+	if len(sort) > 0 {
+		unkeyed := *pagination
+		unkeyed.Key = ""
+		pagination = &unkeyed
+	}
+
+	plan.query, plan.args, err = query.PaginateQuery(db.Dialect(), pagination, plan.query, args, *options.Page)
+	if err != nil {
+		return nil, executionError("pagination error", err)
+	}
+
+	plan.key = pagination.Key
+	if pagination.Count {
+		var total int64
+		if err := db.QueryRow(ctx, query.CountQuery(db.Dialect(), sqlQuery), args...).Scan(&total); err != nil {
+			return nil, queryError(ctx, "count query error", err)
+		}
+		plan.total = &total
+	}
+
+	return plan, nil
+}
+
+// apply descarta a linha extra buscada para detectar a próxima página, gera o
+// cursor a partir da coluna Key e mantém apenas os campos selecionados
+func (p *queryPlan) apply(columns []string, rows [][]interface{}) ([]string, [][]interface{}, error) {
+	if p.page != nil && len(rows) > p.page.PageSize {
+		rows = rows[:p.page.PageSize]
+		p.hasMore = true
+
+		if p.key != "" {
+			index := slices.Index(columns, p.key)
+			if index < 0 {
+				return nil, nil, fmt.Errorf("pagination key column '%s' not found in result", p.key)
+			}
+			p.cursor = query.EncodeCursor(rows[len(rows)-1][index])
+		}
+	}
+
+	if len(p.columns) == 0 {
+		return columns, rows, nil
+	}
+
+	indexes := make([]int, len(p.columns))
+	for i, column := range p.columns {
+		if indexes[i] = slices.Index(columns, column); indexes[i] < 0 {
+			return nil, nil, fmt.Errorf("selected column '%s' not found in result", column)
+		}
+	}
+
+	selected := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		selected[i] = make([]interface{}, len(indexes))
+		for j, index := range indexes {
+			selected[i][j] = row[index]
+		}
+	}
+
+	return p.columns, selected, nil
+}
+
+// selectInfo mantém no schema apenas os campos selecionados, na ordem pedida
+func (p *queryPlan) selectInfo(info []entities.ColumnInfo) []entities.ColumnInfo {
+	if len(p.columns) == 0 || len(info) == 0 {
+		return info
+	}
+
+	selected := make([]entities.ColumnInfo, 0, len(p.columns))
+	for _, column := range p.columns {
+		for _, item := range info {
+			if item.Source == column {
+				selected = append(selected, item)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+func (p *queryPlan) fill(metadata *entities.ReportMetadata) {
+	metadata.Sort = p.sort
+	metadata.Fields = p.fields
+	metadata.Shape = p.shape
+
+	if p.page == nil {
+		return
+	}
+	if p.page.Cursor == "" {
+		metadata.Page = p.page.Page
+	}
+	metadata.PageSize = p.page.PageSize
+	metadata.Total = p.total
+	metadata.HasMore = p.hasMore
+	metadata.NextCursor = p.cursor
+}
@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"reports-system/internal/domain/entities"
+)
+
+func TestSelectInfo(t *testing.T) {
+	info := []entities.ColumnInfo{
+		{Name: "region", Source: "region"},
+		{Name: "total_sales", Source: "total"},
+		{Name: "orders", Source: "orders"},
+	}
+
+	tests := []struct {
+		name    string
+		columns []string
+		info    []entities.ColumnInfo
+		want    []string
+	}{
+		{"no selection", nil, info, []string{"region", "total_sales", "orders"}},
+		{"selection order", []string{"total", "region"}, info, []string{"total_sales", "region"}},
+		{"without schema", []string{"total"}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := &queryPlan{columns: tt.columns}
+			var got []string
+			for _, column := range plan.selectInfo(tt.info) {
+				got = append(got, column.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectInfo = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportShape(t *testing.T) {
+	config := entities.QueryConfig{
+		Name:  "sales",
+		Query: "SELECT region, total, orders FROM sales",
+		Output: entities.OutputConfig{
+			FieldMapping: map[string]string{"total": "total_sales"},
+			Labels:       map[string]string{"total": "Total de vendas"},
+			Selectable:   []string{"region", "total_sales", "orders"},
+		},
+	}
+	result := fakeResult{
+		match:   "FROM sales",
+		columns: []string{"region", "total", "orders"},
+		types:   []string{"VARCHAR", "INT8", "INT4"},
+		rows:    [][]driver.Value{{"Sul", int64(10), int64(2)}, {"Norte", int64(3), int64(1)}},
+	}
+
+	tests := []struct {
+		name    string
+		options entities.ReportOptions
+		columns []entities.ColumnInfo
+		rows    [][]interface{}
+	}{
+		{
+			"table",
+			entities.ReportOptions{Shape: entities.ShapeTable},
+			[]entities.ColumnInfo{
+				{Name: "region", Source: "region", Type: "VARCHAR", Label: "region"},
+				{Name: "total_sales", Source: "total", Type: "INT8", Label: "Total de vendas"},
+				{Name: "orders", Source: "orders", Type: "INT4", Label: "orders"},
+			},
+			[][]interface{}{{"Sul", int64(10), int64(2)}, {"Norte", int64(3), int64(1)}},
+		},
+		{
+			"table with selected fields",
+			entities.ReportOptions{Shape: entities.ShapeTable, Fields: []string{"orders", "region"}},
+			[]entities.ColumnInfo{
+				{Name: "orders", Source: "orders", Type: "INT4", Label: "orders"},
+				{Name: "region", Source: "region", Type: "VARCHAR", Label: "region"},
+			},
+			[][]interface{}{{int64(2), "Sul"}, {int64(1), "Norte"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := newTestService(t, newFakeDB(result), config)
+
+			report, err := service.GetReport("sales", map[string]interface{}{}, tt.options)
+			if err != nil {
+				t.Fatalf("GetReport: %v", err)
+			}
+			if !reflect.DeepEqual(report.Columns, tt.columns) {
+				t.Errorf("Columns = %+v, want %+v", report.Columns, tt.columns)
+			}
+
+			table, ok := report.Data.(*entities.TableData)
+			if !ok {
+				t.Fatalf("Data = %T, want *entities.TableData", report.Data)
+			}
+			var names []string
+			for _, column := range tt.columns {
+				names = append(names, column.Name)
+			}
+			if !reflect.DeepEqual(table.Columns, names) {
+				t.Errorf("table columns = %v, want %v", table.Columns, names)
+			}
+			if !reflect.DeepEqual(table.Rows, tt.rows) {
+				t.Errorf("table rows = %v, want %v", table.Rows, tt.rows)
+			}
+		})
+	}
+
+	t.Run("unknown shape", func(t *testing.T) {
+		service, _ := newTestService(t, newFakeDB(result), config)
+		_, err := service.GetReport("sales", map[string]interface{}{}, entities.ReportOptions{Shape: "matrix"})
+		if entities.ErrorKindOf(err) != entities.ErrorValidation {
+			t.Errorf("error = %v, want validation error", err)
+		}
+	})
+}
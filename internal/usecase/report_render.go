@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"reports-system/internal/domain/entities"
+	"reports-system/pkg/query"
+	"reports-system/pkg/report"
+)
+
+// RenderReport gera o arquivo de exportação do relatório (csv, xlsx, pdf, html),
+// aplicando a formatação de output.formatting aos valores exibidos
+func (s *ReportService) RenderReport(response *entities.ReportResponse, format string) ([]byte, report.Renderer, error) {
+	renderer, ok := report.Get(format)
+	if !ok {
+		return nil, nil, &entities.ValidationError{Errors: []entities.FieldError{{
+			Param:   "format",
+			Rule:    "values",
+			Message: "unsupported export format '" + format + "'",
+			Value:   format,
+		}}}
+	}
+
+	doc, err := s.reportDocument(response)
+	if err != nil {
+		return nil, nil, executionError("failed to prepare report output", err)
+	}
+
+	var buf bytes.Buffer
+	if err := renderer.Render(&buf, doc); err != nil {
+		return nil, nil, executionError("failed to render report", err)
+	}
+
+	return buf.Bytes(), renderer, nil
+}
+
+// resolveFormat valida o formato pedido antes da execução: json é a resposta
+// da API e os demais precisam estar em output.formats e ter um renderer
+func (s *ReportService) resolveFormat(reportID, format string) (string, error) {
+	format = strings.ToLower(format)
+	if format == "" || format == "json" {
+		return "json", nil
+	}
+
+	formats := s.queryConfig(reportID).Output.Formats
+	if _, ok := report.Get(format); !ok || !slices.Contains(formats, format) {
+		return format, &entities.ValidationError{Errors: []entities.FieldError{{
+			Param:   "format",
+			Rule:    "values",
+			Message: fmt.Sprintf("format '%s' is not available for this report", format),
+			Value:   format,
+		}}}
+	}
+	return format, nil
+}
+
+func (s *ReportService) reportDocument(response *entities.ReportResponse) (*report.Document, error) {
+	conf := s.queryConfig(response.Metadata.Report)
+
+	title := conf.Description
+	if title == "" {
+		title = response.Metadata.Report
+	}
+
+	var sections []report.Section
+	if len(response.Sections) > 0 {
+		// Relatório composto: uma seção por relatório, com a formatação dele
+		for _, item := range response.Sections {
+			section, err := s.reportSection(item.Report, item.Name, item.Columns, item.Data)
+			if err != nil {
+				return nil, fmt.Errorf("section '%s': %w", item.Name, err)
+			}
+			sections = append(sections, section)
+		}
+	} else {
+		section, err := s.reportSection(response.Metadata.Report, response.Metadata.Report, response.Columns, response.Data)
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, section)
+	}
+
+	return &report.Document{
+		Title:       title,
+		GeneratedAt: response.Metadata.GeneratedAt,
+		Sections:    sections,
+	}, nil
+}
+
+// reportSection monta a seção do resultado de um relatório conforme o seu
+// output (formatação, pivot, agrupamento ou aninhamento)
+func (s *ReportService) reportSection(reportID, name string, columns []entities.ColumnInfo, data interface{}) (report.Section, error) {
+	conf := s.queryConfig(reportID)
+
+	formatter := query.NewFormatter(conf.Output)
+	if conf.Output.Pivot != nil {
+		names := make([]string, len(columns))
+		for i, column := range columns {
+			names[i] = column.Name
+		}
+		formatter = query.PivotFormatter(conf.Output, names)
+	}
+
+	switch {
+	case conf.Output.Grouping != nil:
+		return report.NewGroupedSection(name, columns, data, *conf.Output.Grouping, formatter)
+	case conf.Output.Nest != nil:
+		// Exportação tabular: uma linha por objeto do último nível
+		rows, err := query.FlattenNested(*conf.Output.Nest, data)
+		if err != nil {
+			return report.Section{}, err
+		}
+		return report.NewSection(name, columns, rows, formatter)
+	}
+	return report.NewSection(name, columns, data, formatter)
+}
@@ -1,9 +1,15 @@
 package usecase
 
 import (
-	"crypto/md5"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"reports-system/internal/domain/entities"
@@ -12,21 +18,42 @@ import (
 
 type ReportService struct {
 	db          entities.Database
+	datasources map[string]entities.Database
 	cache       entities.CacheProvider
+	// queries e queriesConf são substituídos a cada recarga e nunca
+	// alterados no lugar; o acesso passa por mu (report, queryConfig e
+	// snapshot)
+	mu          sync.RWMutex
 	queries     map[string]entities.Query
 	queriesConf map[string]entities.QueryConfig
 	loader      *query.ConfigLoader
+	warmupMu    sync.Mutex
+	warmupStop  chan struct{}
+	// Após ValidateDatasources, recargas também verificam os datasources
+	checkDatasources bool
+	// Prazo de execução das queries de relatório e de opções
+	queryTimeout time.Duration
+	// Credenciais aceitas nos relatórios com security.require_auth; vazio
+	// aceita qualquer credencial (validada por um gateway, por exemplo)
+	apiKeys map[string]bool
 }
 
+const defaultQueryTimeout = 30 * time.Second
+
 func NewReportService(db entities.Database, cache entities.CacheProvider, configPath string) *ReportService {
 	service := &ReportService{
-		db:          db,
-		cache:       cache,
-		queries:     make(map[string]entities.Query),
-		queriesConf: make(map[string]entities.QueryConfig),
-		loader:      query.NewConfigLoader(configPath),
+		db:           db,
+		datasources:  make(map[string]entities.Database),
+		cache:        cache,
+		queries:      make(map[string]entities.Query),
+		queriesConf:  make(map[string]entities.QueryConfig),
+		loader:       query.NewConfigLoader(configPath),
+		queryTimeout: defaultQueryTimeout,
 	}
 
+	// Opções dinâmicas de parâmetros usam o mesmo banco e cache do serviço
+	service.loader.SetOptionsLoader(service.loadParamOptions)
+
 	// Carregar queries do diretório de configuração
 	error := service.LoadQueries()
 	if error != nil {
@@ -42,50 +69,287 @@ func (s *ReportService) LoadQueries() error {
 	if err != nil {
 		return fmt.Errorf("failed to load queries: %w", err)
 	}
+	if s.checkDatasources {
+		if err := s.validateDatasources(queriesConf); err != nil {
+			return fmt.Errorf("failed to load queries: %w", err)
+		}
+	}
 
+	s.mu.Lock()
 	s.queries = queries
 	s.queriesConf = queriesConf
+	s.mu.Unlock()
 	return nil
 }
 
 func (s *ReportService) RegisterQuery(q entities.Query) {
-	s.queries[q.Name()] = q
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	queries := make(map[string]entities.Query, len(s.queries)+1)
+	for name, existing := range s.queries {
+		queries[name] = existing
+	}
+	queries[q.Name()] = q
+	s.queries = queries
 }
 
-func (s *ReportService) GetReport(reportID string, params map[string]interface{}, format string) (*entities.ReportResponse, error) {
+// report retorna a query e a configuração carregadas do relatório
+func (s *ReportService) report(reportID string) (entities.Query, entities.QueryConfig, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	query, exists := s.queries[reportID]
+	return query, s.queriesConf[reportID], exists
+}
+
+// queryConfig retorna a configuração carregada do relatório
+func (s *ReportService) queryConfig(reportID string) entities.QueryConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.queriesConf[reportID]
+}
+
+// snapshot retorna as queries e configurações carregadas; os mapas não são
+// alterados após a carga e podem ser percorridos sem o lock
+func (s *ReportService) snapshot() (map[string]entities.Query, map[string]entities.QueryConfig) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.queries, s.queriesConf
+}
+
+// SetQueryTimeout define o prazo de execução das queries; ao ser excedido a
+// requisição falha com execution_timeout
+func (s *ReportService) SetQueryTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		s.queryTimeout = timeout
+	}
+}
+
+// SetAPIKeys define as credenciais aceitas pelos relatórios que exigem
+// autenticação
+func (s *ReportService) SetAPIKeys(keys []string) {
+	s.apiKeys = make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if key = strings.TrimSpace(key); key != "" {
+			s.apiKeys[key] = true
+		}
+	}
+}
+
+// Authorize verifica as credenciais da requisição nos relatórios com
+// security.require_auth. Relatórios inexistentes não são tratados aqui para
+// que a requisição falhe com report_not_found.
+func (s *ReportService) Authorize(reportID, credentials string) error {
+	if !s.queryConfig(reportID).Security.RequireAuth {
+		return nil
+	}
+	if credentials == "" {
+		return &entities.ReportError{Kind: entities.ErrorUnauthorized, Message: fmt.Sprintf("report '%s' requires authentication", reportID)}
+	}
+	if len(s.apiKeys) > 0 && !s.apiKeys[credentials] {
+		return &entities.ReportError{Kind: entities.ErrorForbidden, Message: fmt.Sprintf("credentials not allowed for report '%s'", reportID)}
+	}
+	return nil
+}
+
+// queryContext retorna o contexto com o prazo de execução das queries
+func (s *ReportService) queryContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), s.queryTimeout)
+}
+
+// RegisterDatasource registra uma conexão adicional, usada pelos relatórios
+// que a indicam em "datasource"
+func (s *ReportService) RegisterDatasource(name string, db entities.Database) {
+	s.datasources[name] = db
+}
+
+// ValidateDatasources verifica se os datasources indicados pelos relatórios
+// foram registrados. Deve ser chamado na inicialização, após os
+// RegisterDatasource; recargas posteriores também fazem a verificação.
+func (s *ReportService) ValidateDatasources() error {
+	s.checkDatasources = true
+	_, configs := s.snapshot()
+	return s.validateDatasources(configs)
+}
+
+func (s *ReportService) validateDatasources(configs map[string]entities.QueryConfig) error {
+	for name, conf := range configs {
+		if conf.Datasource == "" {
+			continue
+		}
+		if _, ok := s.datasources[conf.Datasource]; !ok {
+			return fmt.Errorf("report %s: datasource '%s' is not configured", name, conf.Datasource)
+		}
+	}
+	return nil
+}
+
+// database retorna a conexão do relatório: a do seu datasource ou a padrão
+func (s *ReportService) database(reportID string) (entities.Database, error) {
+	name := s.queryConfig(reportID).Datasource
+	if name == "" {
+		return s.db, nil
+	}
+	db, ok := s.datasources[name]
+	if !ok {
+		return nil, &entities.ReportError{Kind: entities.ErrorExecution, Message: fmt.Sprintf("datasource '%s' is not configured", name)}
+	}
+	return db, nil
+}
+
+// timezoneQuery é implementado pelas queries que aceitam o fuso da requisição
+type timezoneQuery interface {
+	WithTimezone(timezone string) (entities.Query, error)
+	Timezone() string
+}
+
+func (s *ReportService) GetReport(reportID string, params map[string]interface{}, options entities.ReportOptions) (*entities.ReportResponse, error) {
+	query, conf, exists := s.report(reportID)
 	if !exists {
-		return nil, fmt.Errorf("report '%s' not found", reportID)
+		return nil, &entities.ReportError{Kind: entities.ErrorNotFound, Message: fmt.Sprintf("report '%s' not found", reportID)}
+	}
+
+	if options.Timezone != "" {
+		tzQuery, ok := query.(timezoneQuery)
+		if !ok {
+			return nil, fmt.Errorf("validation error: %w", timezoneError(options.Timezone, "report does not support timezones"))
+		}
+		zoned, err := tzQuery.WithTimezone(options.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("validation error: %w", timezoneError(options.Timezone, err.Error()))
+		}
+		query = zoned
 	}
 
 	if err := query.Validate(params); err != nil {
+		// Falhas ao carregar opções de parâmetros são erros de execução
+		if entities.ErrorKindOf(err) != entities.ErrorValidation {
+			return nil, err
+		}
 		return nil, fmt.Errorf("validation error: %w", err)
 	}
 
+	// Relatórios compostos reúnem os resultados de outros relatórios
+	if len(conf.Sections) > 0 {
+		return s.executeComposite(reportID, query, params, options)
+	}
+
+	options, err := s.resolveOptions(reportID, options)
+	if err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if _, ok := query.(tableQuery); !ok && options.Shape == entities.ShapeTable {
+		return nil, fmt.Errorf("validation error: %w", &entities.ValidationError{Errors: []entities.FieldError{{
+			Param:   "shape",
+			Rule:    "unsupported",
+			Message: "report does not support the table shape",
+			Value:   options.Shape,
+		}}})
+	}
+
 	// Verificar cache
-	cacheKey := s.generateCacheKey(reportID, params)
+	cacheKey := s.generateCacheKey(reportID, params, queryTimezone(query), options)
 	if cached, err := s.cache.Get(cacheKey); err == nil {
+		// UseNumber preserva decimais exatos (json.Number) ao ler do cache
 		var response entities.ReportResponse
-		if err := json.Unmarshal(cached, &response); err == nil {
+		decoder := json.NewDecoder(bytes.NewReader(cached))
+		decoder.UseNumber()
+		if err := decoder.Decode(&response); err == nil {
+			response.Metadata.CacheHit = true
+			response.Metadata.Format = options.Format
 			return &response, nil
 		}
 	}
 
+	return s.executeReport(reportID, query, params, options, cacheKey)
+}
+
+func timezoneError(timezone, message string) error {
+	return &entities.ValidationError{Errors: []entities.FieldError{{
+		Param:   "timezone",
+		Rule:    "timezone",
+		Message: message,
+		Value:   timezone,
+	}}}
+}
+
+// tableQuery é implementado pelas queries que descrevem as colunas do
+// resultado e geram o formato compacto (table)
+type tableQuery interface {
+	DescribeColumns(columnTypes []*sql.ColumnType) []entities.ColumnInfo
+	TransformTable(columns []string, rows [][]interface{}) (*entities.TableData, error)
+}
+
+// computedQuery é implementado pelas queries com colunas calculadas a partir
+// das demais colunas e dos parâmetros
+type computedQuery interface {
+	ComputeColumns(columns []string, rows [][]interface{}, params map[string]interface{}) ([]string, [][]interface{}, error)
+}
+
+// groupingQuery é implementado pelas queries que agrupam os registros e
+// calculam subtotais
+type groupingQuery interface {
+	GroupResults(records []map[string]interface{}) (interface{}, error)
+}
+
+// nestingQuery é implementado pelas queries que aninham registros
+// (mestre-detalhe)
+type nestingQuery interface {
+	NestResults(records []map[string]interface{}) (interface{}, error)
+}
+
+// pivotQuery é implementado pelas queries que geram tabelas cruzadas
+type pivotQuery interface {
+	PivotResults(columns []string, info []entities.ColumnInfo, rows [][]interface{}) ([]string, []entities.ColumnInfo, [][]interface{}, error)
+}
+
+// queryTimezone retorna o fuso efetivo da query, se ela suportar fusos
+func queryTimezone(query entities.Query) string {
+	if tzQuery, ok := query.(timezoneQuery); ok {
+		return tzQuery.Timezone()
+	}
+	return ""
+}
+
+// executeReport executa a query, transforma o resultado e o grava no cache
+func (s *ReportService) executeReport(reportID string, query entities.Query, params map[string]interface{}, options entities.ReportOptions, cacheKey string) (*entities.ReportResponse, error) {
+	db, err := s.database(reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	// O prazo vale para a contagem, a query e a leitura das linhas
+	ctx, cancel := s.queryContext()
+	defer cancel()
+
 	// Executar query
 	sqlQuery, args := query.BuildQuery(params)
-	fmt.Printf("Executing query: %s with args: %v\n", sqlQuery, args)
-	rows, err := s.db.Query(sqlQuery, args...)
+
+	// Ordenação, paginação e seleção de campos pedidas pelo cliente
+	plan, err := s.planQuery(ctx, db, reportID, sqlQuery, args, options)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(ctx, plan.query, plan.args...)
 	if err != nil {
-		return nil, fmt.Errorf("query execution error: %w", err)
+		return nil, queryError(ctx, "query execution error", err)
 	}
 	defer rows.Close()
 
 	// Processar resultados
-	columns, err := rows.Columns()
+	columnTypes, err := rows.ColumnTypes()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get columns: %w", err)
+		return nil, executionError("failed to get columns", err)
+	}
+	columns := make([]string, len(columnTypes))
+	for i, columnType := range columnTypes {
+		columns[i] = columnType.Name()
 	}
 
+	// Normalizar os valores pelo tipo da coluna (decimais, UUIDs, JSON, ...)
+	mapper := s.valueMapper(reportID, columnTypes)
+
 	var allRows [][]interface{}
 	for rows.Next() {
 		values := make([]interface{}, len(columns))
@@ -95,27 +359,86 @@ func (s *ReportService) GetReport(reportID string, params map[string]interface{}
 		}
 
 		if err := rows.Scan(pointers...); err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
+			return nil, queryError(ctx, "failed to scan row", err)
 		}
 
+		mapper.MapRow(values)
 		allRows = append(allRows, values)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, queryError(ctx, "failed to read rows", err)
+	}
+
+	// Colunas calculadas (output.computed) usam a linha completa, antes da
+	// seleção de campos
+	if computer, ok := query.(computedQuery); ok {
+		if columns, allRows, err = computer.ComputeColumns(columns, allRows, params); err != nil {
+			return nil, executionError("computed columns error", err)
+		}
+	}
+
+	var columnInfo []entities.ColumnInfo
+	tabular, isTabular := query.(tableQuery)
+	if isTabular {
+		columnInfo = tabular.DescribeColumns(columnTypes)
+	}
+
+	if columns, allRows, err = plan.apply(columns, allRows); err != nil {
+		return nil, executionError("failed to apply output options", err)
+	}
+	columnInfo = plan.selectInfo(columnInfo)
+
+	// Tabela cruzada (output.pivot), com colunas geradas a partir dos dados
+	if pivoter, ok := query.(pivotQuery); ok {
+		if columns, columnInfo, allRows, err = pivoter.PivotResults(columns, columnInfo, allRows); err != nil {
+			return nil, executionError("pivot error", err)
+		}
+	}
 
 	// Transformar dados
-	data, err := query.TransformResults(columns, allRows)
+	var data interface{}
+	if options.Shape == entities.ShapeTable && isTabular {
+		data, err = tabular.TransformTable(columns, allRows)
+	} else {
+		data, err = query.TransformResults(columns, allRows)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("transformation error: %w", err)
+		return nil, executionError("transformation error", err)
+	}
+
+	// Subtotais e total geral (output.grouping)
+	if grouper, ok := query.(groupingQuery); ok {
+		if records, ok := data.([]map[string]interface{}); ok {
+			if data, err = grouper.GroupResults(records); err != nil {
+				return nil, executionError("grouping error", err)
+			}
+		}
 	}
 
+	// Objetos aninhados (output.nest)
+	if nester, ok := query.(nestingQuery); ok {
+		if records, ok := data.([]map[string]interface{}); ok {
+			if data, err = nester.NestResults(records); err != nil {
+				return nil, executionError("nesting error", err)
+			}
+		}
+	}
+
+	generatedAt := time.Now()
 	response := &entities.ReportResponse{
 		Metadata: entities.ReportMetadata{
 			Report:      reportID,
 			Params:      params,
-			GeneratedAt: time.Now(),
-			Format:      format,
+			GeneratedAt: generatedAt,
+			ExpiresAt:   generatedAt.Add(query.CacheTTL()),
+			Format:      options.Format,
+			Timezone:    queryTimezone(query),
+			ETag:        s.generateETag(cacheKey, data),
 		},
-		Data: data,
+		Columns: columnInfo,
+		Data:    data,
 	}
+	plan.fill(&response.Metadata)
 
 	// Salvar no cache
 	if responseBytes, err := json.Marshal(response); err == nil {
@@ -125,21 +448,83 @@ func (s *ReportService) GetReport(reportID string, params map[string]interface{}
 	return response, nil
 }
 
-func (s *ReportService) generateCacheKey(reportID string, params map[string]interface{}) string {
-	paramBytes, _ := json.Marshal(params)
-	hash := md5.Sum(append([]byte(reportID), paramBytes...))
-	return fmt.Sprintf("report:%x", hash)
+func (s *ReportService) valueMapper(reportID string, columnTypes []*sql.ColumnType) *query.ValueMapper {
+	return query.NewValueMapper(columnTypes, s.queryConfig(reportID).Output.Decimals)
+}
+
+func executionError(message string, err error) error {
+	return &entities.ReportError{Kind: entities.ErrorExecution, Message: message, Err: err}
+}
+
+// queryError classifica as falhas de uma query: com o prazo do contexto
+// esgotado o driver cancela a query e a falha é um timeout
+func queryError(ctx context.Context, message string, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &entities.ReportError{Kind: entities.ErrorTimeout, Message: message, Err: err}
+	}
+	return executionError(message, err)
+}
+
+func (s *ReportService) generateCacheKey(reportID string, params map[string]interface{}, timezone string, options entities.ReportOptions) string {
+	// Normalizar os parâmetros pelo tipo declarado para que valores
+	// equivalentes (ex.: ?ctr=1 e {"ctr": true}) gerem a mesma chave
+	var config *entities.QueryConfig
+	version := ""
+	if _, conf, ok := s.report(reportID); ok {
+		config = &conf
+		version = conf.Version
+	}
+
+	// json.Marshal ordena as chaves do map, garantindo uma serialização estável
+	paramBytes, _ := json.Marshal(query.CanonicalParams(config, params))
+
+	hash := sha256.New()
+	hash.Write([]byte(reportID))
+	hash.Write([]byte{0})
+	hash.Write([]byte(version))
+	hash.Write([]byte{0})
+	hash.Write([]byte(timezone))
+	hash.Write([]byte{0})
+	if page := options.Page; page != nil {
+		// Cada página é uma entrada de cache distinta
+		hash.Write([]byte(fmt.Sprintf("%d:%d:%s", page.Page, page.PageSize, page.Cursor)))
+	}
+	hash.Write([]byte{0})
+	hash.Write([]byte(strings.Join(options.Sort, ",")))
+	hash.Write([]byte{0})
+	hash.Write([]byte(strings.Join(options.Fields, ",")))
+	hash.Write([]byte{0})
+	hash.Write([]byte(options.Shape))
+	hash.Write([]byte{0})
+	hash.Write(paramBytes)
+	return fmt.Sprintf("report:%s:%x", reportID, hash.Sum(nil))
+}
+
+// generateETag calcula o hash do conteúdo do relatório. A chave de cache entra
+// no hash para que parâmetros diferentes com o mesmo resultado não colidam.
+func (s *ReportService) generateETag(cacheKey string, data interface{}) string {
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return ""
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(cacheKey))
+	hash.Write([]byte{0})
+	hash.Write(dataBytes)
+	return fmt.Sprintf("%x", hash.Sum(nil))
 }
 
 func (s *ReportService) GetAvailableReports() map[string]interface{} {
+	queries, configs := s.snapshot()
 	reports := make(map[string]interface{})
-	for name, query := range s.queries {
+	for name, query := range queries {
 		reports[name] = map[string]interface{}{
 			"name":        query.Name(),
 			"description": query.Description(),
 			"formats":     query.OutputFormats(),
-			"query":       s.queriesConf[name].Query,
-			"params":      s.queriesConf[name].Parameters,
+			"query":       configs[name].Query,
+			"params":      configs[name].Parameters,
 		}
 		//reports[query.] = query.Description()
 	}
@@ -172,5 +557,9 @@ func (s *ReportService) GetReportMetadata() map[string]interface{} {
 }
 
 func (s *ReportService) ReloadQueries() error {
-	return s.LoadQueries()
+	if err := s.LoadQueries(); err != nil {
+		return err
+	}
+	s.restartCacheWarming()
+	return nil
 }
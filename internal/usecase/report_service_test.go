@@ -0,0 +1,278 @@
+package usecase
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"reports-system/internal/domain/entities"
+)
+
+func TestAuthorize(t *testing.T) {
+	service := &ReportService{queriesConf: map[string]entities.QueryConfig{
+		"public":  {Name: "public"},
+		"private": {Name: "private", Security: entities.SecurityConfig{RequireAuth: true}},
+	}}
+
+	tests := []struct {
+		name        string
+		apiKeys     []string
+		reportID    string
+		credentials string
+		want        entities.ErrorKind
+	}{
+		{"public report", nil, "public", "", ""},
+		{"unknown report", nil, "missing", "", ""},
+		{"missing credentials", nil, "private", "", entities.ErrorUnauthorized},
+		{"any credentials without keys", nil, "private", "token", ""},
+		{"allowed key", []string{"k1", " k2 "}, "private", "k2", ""},
+		{"key not allowed", []string{"k1"}, "private", "other", entities.ErrorForbidden},
+		{"missing credentials with keys", []string{"k1"}, "private", "", entities.ErrorUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service.SetAPIKeys(tt.apiKeys)
+			err := service.Authorize(tt.reportID, tt.credentials)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Authorize: %v", err)
+				}
+				return
+			}
+			if err == nil || entities.ErrorKindOf(err) != tt.want {
+				t.Errorf("Authorize error = %v, want kind %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateCacheKey(t *testing.T) {
+	config := entities.QueryConfig{
+		Name:  "sales",
+		Query: "SELECT region, total FROM sales",
+		Parameters: []entities.ParamConfig{
+			{Name: "limit", Type: "int", Default: 10},
+			{Name: "regions", Type: "list<string>"},
+			{Name: "status", Type: "string"},
+		},
+	}
+	service, _ := newTestService(t, newFakeDB(), config)
+
+	key := func(params map[string]interface{}, timezone string, options entities.ReportOptions) string {
+		t.Helper()
+		q, _, ok := service.report("sales")
+		if !ok {
+			t.Fatal("report sales not loaded")
+		}
+		if err := q.Validate(params); err != nil {
+			t.Fatalf("Validate(%v): %v", params, err)
+		}
+		return service.generateCacheKey("sales", params, timezone, options)
+	}
+	base := func() map[string]interface{} {
+		return map[string]interface{}{"status": "paid", "regions": []interface{}{"Sul", "Norte"}, "limit": "1"}
+	}
+	baseKey := key(base(), "UTC", entities.ReportOptions{})
+
+	tests := []struct {
+		name     string
+		params   map[string]interface{}
+		timezone string
+		options  entities.ReportOptions
+		same     bool
+	}{
+		{"same params", base(), "UTC", entities.ReportOptions{}, true},
+		{"other key order", map[string]interface{}{"limit": "1", "regions": []interface{}{"Sul", "Norte"}, "status": "paid"}, "UTC", entities.ReportOptions{}, true},
+		{"other list order", map[string]interface{}{"status": "paid", "regions": []interface{}{"Norte", "Sul"}, "limit": "1"}, "UTC", entities.ReportOptions{}, true},
+		{"number instead of text", map[string]interface{}{"status": "paid", "regions": []interface{}{"Sul", "Norte"}, "limit": float64(1)}, "UTC", entities.ReportOptions{}, true},
+		{"other value", map[string]interface{}{"status": "paid", "regions": []interface{}{"Sul", "Norte"}, "limit": "2"}, "UTC", entities.ReportOptions{}, false},
+		{"sort", base(), "UTC", entities.ReportOptions{Sort: []string{"total"}}, false},
+		{"fields", base(), "UTC", entities.ReportOptions{Fields: []string{"region"}}, false},
+		{"page", base(), "UTC", entities.ReportOptions{Page: &entities.PageRequest{Page: 2, PageSize: 10}}, false},
+		{"timezone", base(), "America/Sao_Paulo", entities.ReportOptions{}, false},
+		{"shape", base(), "UTC", entities.ReportOptions{Shape: entities.ShapeRecords}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := key(tt.params, tt.timezone, tt.options)
+			if (got == baseKey) != tt.same {
+				t.Errorf("key = %s, base = %s, same = %v, want %v", got, baseKey, got == baseKey, tt.same)
+			}
+		})
+	}
+}
+
+func TestReportTimezone(t *testing.T) {
+	if _, err := time.LoadLocation("America/Sao_Paulo"); err != nil {
+		t.Skip("timezone data unavailable")
+	}
+
+	stored := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	config := entities.QueryConfig{
+		Name:       "events",
+		Query:      "SELECT id, created_at FROM events WHERE day = @day",
+		Parameters: []entities.ParamConfig{{Name: "day", Type: "date", Required: true}},
+		Timezone:   "America/Sao_Paulo",
+	}
+
+	tests := []struct {
+		name     string
+		timezone string
+		zone     string
+		hour     int
+	}{
+		{"report default", "", "America/Sao_Paulo", 9},
+		{"request timezone", "Asia/Tokyo", "Asia/Tokyo", 21},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newFakeDB(fakeResult{match: "FROM events", columns: []string{"id", "created_at"}, rows: [][]driver.Value{{int64(1), stored}}})
+			service, _ := newTestService(t, db, config)
+
+			report, err := service.GetReport("events", map[string]interface{}{"day": "2024-03-01"}, entities.ReportOptions{Timezone: tt.timezone})
+			if err != nil {
+				t.Fatalf("GetReport: %v", err)
+			}
+			if report.Metadata.Timezone != tt.zone {
+				t.Errorf("Metadata.Timezone = %q, want %q", report.Metadata.Timezone, tt.zone)
+			}
+
+			day, ok := namedArgs(db.executed("FROM events")[0])["day"].(time.Time)
+			if !ok || day.Location().String() != tt.zone || day.Hour() != 0 {
+				t.Errorf("day bound as %v, want midnight in %s", day, tt.zone)
+			}
+
+			created := report.Data.([]map[string]interface{})[0]["created_at"].(time.Time)
+			if !created.Equal(stored) || created.Hour() != tt.hour {
+				t.Errorf("created_at = %v, want %v at hour %d", created, stored, tt.hour)
+			}
+		})
+	}
+
+	t.Run("invalid timezone", func(t *testing.T) {
+		service, _ := newTestService(t, newFakeDB(), config)
+		_, err := service.GetReport("events", map[string]interface{}{"day": "2024-03-01"}, entities.ReportOptions{Timezone: "Mars/Olympus"})
+
+		var validationErr *entities.ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Errors[0].Param != "timezone" {
+			t.Errorf("error = %v, want timezone validation error", err)
+		}
+	})
+}
+
+func TestReportSortAndFields(t *testing.T) {
+	config := entities.QueryConfig{
+		Name:  "sales",
+		Query: "SELECT region, total, orders FROM sales ORDER BY region",
+		Output: entities.OutputConfig{
+			FieldMapping: map[string]string{"total": "total_sales"},
+			Sortable:     []string{"total_sales"},
+			Selectable:   []string{"region", "total_sales"},
+		},
+	}
+	result := fakeResult{match: "FROM sales", columns: []string{"region", "total", "orders"}, rows: [][]driver.Value{{"Sul", int64(10), int64(2)}}}
+
+	tests := []struct {
+		name    string
+		options entities.ReportOptions
+		// trecho esperado no SQL executado e campos do resultado
+		sql    string
+		fields []string
+		kind   entities.ErrorKind
+	}{
+		{"default order", entities.ReportOptions{}, "ORDER BY region", []string{"orders", "region", "total_sales"}, ""},
+		{"client sort", entities.ReportOptions{Sort: []string{"-total_sales"}}, `ORDER BY report_sorted."total" DESC`, []string{"orders", "region", "total_sales"}, ""},
+		{"selected fields", entities.ReportOptions{Fields: []string{"total_sales", "region"}}, "FROM sales", []string{"region", "total_sales"}, ""},
+		{"sort not allowed", entities.ReportOptions{Sort: []string{"orders"}}, "", nil, entities.ErrorValidation},
+		{"field not allowed", entities.ReportOptions{Fields: []string{"orders"}}, "", nil, entities.ErrorValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newFakeDB(result)
+			service, _ := newTestService(t, db, config)
+
+			report, err := service.GetReport("sales", map[string]interface{}{}, tt.options)
+			if tt.kind != "" {
+				if entities.ErrorKindOf(err) != tt.kind {
+					t.Errorf("error = %v, want kind %s", err, tt.kind)
+				}
+				if n := len(db.executed("FROM sales")); n != 0 {
+					t.Errorf("queries executed = %d, want 0", n)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetReport: %v", err)
+			}
+
+			if queries := db.executed(tt.sql); len(queries) != 1 {
+				t.Errorf("no query containing %q", tt.sql)
+			}
+			var fields []string
+			for field := range report.Data.([]map[string]interface{})[0] {
+				fields = append(fields, field)
+			}
+			slices.Sort(fields)
+			if !slices.Equal(fields, tt.fields) {
+				t.Errorf("fields = %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestReportValueTypes(t *testing.T) {
+	result := fakeResult{
+		match:   "FROM payments",
+		columns: []string{"id", "amount", "payload", "note"},
+		types:   []string{"UUID", "NUMERIC", "JSONB", "TEXT"},
+		rows: [][]driver.Value{{
+			[]byte{0x6f, 0x9a, 0x1c, 0x2e, 0x3b, 0x4d, 0x4e, 0x5f, 0x80, 0x91, 0xa2, 0xb3, 0xc4, 0xd5, 0xe6, 0xf7},
+			[]byte("1234.50"),
+			[]byte(`{"method": "pix"}`),
+			[]byte("pago"),
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		decimals string
+		amount   string
+	}{
+		{"decimals as numbers", "", `1234.50`},
+		{"decimals as strings", "string", `"1234.50"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := entities.QueryConfig{
+				Name:   "payments",
+				Query:  "SELECT id, amount, payload, note FROM payments",
+				Output: entities.OutputConfig{Decimals: tt.decimals},
+			}
+			service, _ := newTestService(t, newFakeDB(result), config)
+
+			want := `[{"amount":` + tt.amount + `,"id":"6f9a1c2e-3b4d-4e5f-8091-a2b3c4d5e6f7","note":"pago","payload":{"method":"pix"}}]`
+			// A segunda leitura vem do cache e deve gerar o mesmo JSON
+			for _, cached := range []bool{false, true} {
+				report, err := service.GetReport("payments", map[string]interface{}{}, entities.ReportOptions{})
+				if err != nil {
+					t.Fatalf("GetReport: %v", err)
+				}
+				if report.Metadata.CacheHit != cached {
+					t.Fatalf("CacheHit = %v, want %v", report.Metadata.CacheHit, cached)
+				}
+				data, _ := json.Marshal(report.Data)
+				if string(data) != want {
+					t.Errorf("data (cache hit %v) = %s, want %s", cached, data, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package query
+
+import (
+	"fmt"
+	"slices"
+
+	"reports-system/internal/domain/entities"
+)
+
+// computedColumn é uma coluna de output.computed com a expressão compilada
+type computedColumn struct {
+	name string
+	expr *Expression
+}
+
+// compileComputed compila as expressões de output.computed. Os nomes devem
+// ser únicos e simples, e as expressões só podem referenciar parâmetros
+// declarados.
+func compileComputed(config *entities.QueryConfig) ([]computedColumn, error) {
+	declared := make(map[string]bool, len(config.Parameters))
+	for _, param := range config.Parameters {
+		declared[param.Name] = true
+	}
+
+	columns := make([]computedColumn, 0, len(config.Output.Computed))
+	seen := make(map[string]bool)
+
+	for _, computed := range config.Output.Computed {
+		if !identifierPattern.MatchString(computed.Name) {
+			return nil, fmt.Errorf("invalid computed column name '%s'", computed.Name)
+		}
+		if seen[computed.Name] {
+			return nil, fmt.Errorf("duplicate computed column '%s'", computed.Name)
+		}
+		seen[computed.Name] = true
+
+		if computed.Expression == "" {
+			return nil, fmt.Errorf("computed column '%s': expression is required", computed.Name)
+		}
+		expr, err := CompileExpression(computed.Expression, declared)
+		if err != nil {
+			return nil, fmt.Errorf("computed column '%s': %w", computed.Name, err)
+		}
+
+		columns = append(columns, computedColumn{name: computed.Name, expr: expr})
+	}
+
+	return columns, nil
+}
+
+// checkComputed valida output.computed no carregamento. Colunas calculadas
+// não existem no SQL e, por isso, não podem ser ordenadas pelo cliente.
+func checkComputed(config *entities.QueryConfig) error {
+	if _, err := compileComputed(config); err != nil {
+		return err
+	}
+
+	for _, computed := range config.Output.Computed {
+		for _, field := range config.Output.Sortable {
+			if SourceColumn(config, field) == computed.Name {
+				return fmt.Errorf("computed column '%s' cannot be sortable", computed.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ComputeColumns avalia as colunas calculadas em cada linha e as adiciona ao
+// fim do resultado. As expressões enxergam as colunas pelos nomes originais,
+// com as datas já no fuso da requisição/relatório (como o cliente as recebe),
+// e os parâmetros já validados.
+func (q *ConfigQuery) ComputeColumns(columns []string, rows [][]interface{}, params map[string]interface{}) ([]string, [][]interface{}, error) {
+	if len(q.computed) == 0 {
+		return columns, rows, nil
+	}
+
+	// As colunas referenciadas só são conhecidas com o resultado: verificadas
+	// uma vez, antes das linhas
+	available := slices.Clone(columns)
+	for _, computed := range q.computed {
+		if slices.Contains(columns, computed.name) {
+			return nil, nil, fmt.Errorf("computed column '%s' conflicts with a query column", computed.name)
+		}
+		for _, column := range computed.expr.Columns() {
+			if !slices.Contains(available, column) {
+				return nil, nil, fmt.Errorf("computed column '%s': unknown column '%s'", computed.name, column)
+			}
+		}
+		available = append(available, computed.name)
+	}
+
+	extended := make([]string, len(columns), len(columns)+len(q.computed))
+	copy(extended, columns)
+	for _, computed := range q.computed {
+		extended = append(extended, computed.name)
+	}
+
+	for r, row := range rows {
+		values := make(map[string]interface{}, len(extended))
+		for i, column := range columns {
+			if i < len(row) {
+				values[column] = q.resultValue(row[i])
+			}
+		}
+
+		full := make([]interface{}, len(columns), len(extended))
+		copy(full, row)
+		for _, computed := range q.computed {
+			value, err := computed.expr.Eval(values, params)
+			if err != nil {
+				return nil, nil, fmt.Errorf("computed column '%s': %w", computed.name, err)
+			}
+			// Colunas seguintes podem usar o valor calculado
+			values[computed.name] = value
+			full = append(full, value)
+		}
+		rows[r] = full
+	}
+
+	return extended, rows, nil
+}
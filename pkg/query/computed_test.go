@@ -0,0 +1,189 @@
+package query
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"reports-system/internal/domain/entities"
+)
+
+func computedQuery(timezone string, computed ...entities.ComputedColumn) *ConfigQuery {
+	config := &entities.QueryConfig{
+		Timezone:   timezone,
+		Parameters: []entities.ParamConfig{{Name: "rate", Type: "number"}},
+		Output:     entities.OutputConfig{Computed: computed},
+	}
+	return NewConfigQuery(config).(*ConfigQuery)
+}
+
+func TestComputeColumns(t *testing.T) {
+	created := time.Date(2024, time.January, 31, 20, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		timezone string
+		computed []entities.ComputedColumn
+		columns  []string
+		rows     [][]interface{}
+		params   map[string]interface{}
+		want     []interface{}
+	}{
+		{
+			name: "chained columns",
+			computed: []entities.ComputedColumn{
+				{Name: "margin", Expression: "revenue - cost"},
+				{Name: "pct", Expression: "round(margin / revenue * 100, 1)"},
+			},
+			columns: []string{"revenue", "cost"},
+			rows:    [][]interface{}{{int64(10), 3.5}},
+			want:    []interface{}{json.Number("6.5"), json.Number("65")},
+		},
+		{
+			name:     "parameters",
+			computed: []entities.ComputedColumn{{Name: "tax", Expression: "revenue * @rate"}},
+			columns:  []string{"revenue"},
+			rows:     [][]interface{}{{int64(200)}},
+			params:   map[string]interface{}{"rate": 0.15},
+			want:     []interface{}{json.Number("30")},
+		},
+		{
+			name:     "server dates without timezone",
+			computed: []entities.ComputedColumn{{Name: "m", Expression: "month(created)"}},
+			columns:  []string{"created"},
+			rows:     [][]interface{}{{created}},
+			want:     []interface{}{json.Number("1")},
+		},
+		{
+			name:     "dates in report timezone",
+			timezone: "Asia/Tokyo",
+			computed: []entities.ComputedColumn{{Name: "m", Expression: "month(created)"}},
+			columns:  []string{"created"},
+			rows:     [][]interface{}{{created}},
+			want:     []interface{}{json.Number("2")},
+		},
+		{
+			name:     "null column",
+			computed: []entities.ComputedColumn{{Name: "total", Expression: "qty * price"}},
+			columns:  []string{"qty", "price"},
+			rows:     [][]interface{}{{int64(2), nil}},
+			want:     []interface{}{nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.timezone != "" {
+				if _, err := time.LoadLocation(tt.timezone); err != nil {
+					t.Skip("timezone data not available")
+				}
+			}
+
+			q := computedQuery(tt.timezone, tt.computed...)
+			columns, rows, err := q.ComputeColumns(tt.columns, tt.rows, tt.params)
+			if err != nil {
+				t.Fatalf("ComputeColumns: %v", err)
+			}
+
+			wantColumns := slices.Clone(tt.columns)
+			for _, computed := range tt.computed {
+				wantColumns = append(wantColumns, computed.Name)
+			}
+			if !slices.Equal(columns, wantColumns) {
+				t.Errorf("columns = %v, want %v", columns, wantColumns)
+			}
+
+			got := rows[0][len(tt.columns):]
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("computed values = %#v, want %#v", got, tt.want)
+			}
+			// As colunas originais não são alteradas
+			if !slices.Equal(rows[0][:len(tt.columns)], tt.rows[0][:len(tt.columns)]) {
+				t.Errorf("original values changed: %v", rows[0])
+			}
+		})
+	}
+}
+
+func TestComputeColumnsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		computed []entities.ComputedColumn
+		columns  []string
+		rows     [][]interface{}
+		want     string
+	}{
+		{
+			name:     "unknown column without rows",
+			computed: []entities.ComputedColumn{{Name: "c", Expression: "missing + 1"}},
+			columns:  []string{"a"},
+			want:     "unknown column 'missing'",
+		},
+		{
+			name:     "later computed column",
+			computed: []entities.ComputedColumn{{Name: "c", Expression: "d + 1"}, {Name: "d", Expression: "a"}},
+			columns:  []string{"a"},
+			want:     "unknown column 'd'",
+		},
+		{
+			name:     "conflicting name",
+			computed: []entities.ComputedColumn{{Name: "a", Expression: "1"}},
+			columns:  []string{"a"},
+			want:     "conflicts with a query column",
+		},
+		{
+			name:     "evaluation error",
+			computed: []entities.ComputedColumn{{Name: "c", Expression: "a - 1"}},
+			columns:  []string{"a"},
+			rows:     [][]interface{}{{"text"}},
+			want:     "computed column 'c': expected a number",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := computedQuery("", tt.computed...).ComputeColumns(tt.columns, tt.rows, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ComputeColumns error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckComputed(t *testing.T) {
+	tests := []struct {
+		name     string
+		computed []entities.ComputedColumn
+		sortable []string
+		mapping  map[string]string
+		valid    bool
+	}{
+		{"valid", []entities.ComputedColumn{{Name: "total", Expression: "qty * @rate"}}, []string{"qty"}, nil, true},
+		{"invalid name", []entities.ComputedColumn{{Name: "total-1", Expression: "1"}}, nil, nil, false},
+		{"duplicate name", []entities.ComputedColumn{{Name: "a", Expression: "1"}, {Name: "a", Expression: "2"}}, nil, nil, false},
+		{"empty expression", []entities.ComputedColumn{{Name: "a"}}, nil, nil, false},
+		{"syntax error", []entities.ComputedColumn{{Name: "a", Expression: "1 +"}}, nil, nil, false},
+		{"undeclared parameter", []entities.ComputedColumn{{Name: "a", Expression: "@other"}}, nil, nil, false},
+		{"sortable", []entities.ComputedColumn{{Name: "total", Expression: "1"}}, []string{"total"}, nil, false},
+		{"sortable mapped", []entities.ComputedColumn{{Name: "total", Expression: "1"}}, []string{"Total"}, map[string]string{"total": "Total"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &entities.QueryConfig{
+				Parameters: []entities.ParamConfig{{Name: "rate", Type: "number"}},
+				Output: entities.OutputConfig{
+					Computed:     tt.computed,
+					Sortable:     tt.sortable,
+					FieldMapping: tt.mapping,
+				},
+			}
+			err := checkComputed(config)
+			if valid := err == nil; valid != tt.valid {
+				t.Errorf("checkComputed valid = %v, want %v (err: %v)", valid, tt.valid, err)
+			}
+		})
+	}
+}
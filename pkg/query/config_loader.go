@@ -6,19 +6,28 @@ import (
 	"io/ioutil"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"reports-system/internal/domain/entities"
 )
 
 type ConfigLoader struct {
-	configPath string
+	configPath    string
+	optionsLoader entities.OptionsLoader
 }
 
 func NewConfigLoader(configPath string) *ConfigLoader {
 	return &ConfigLoader{configPath: configPath}
 }
 
+// SetOptionsLoader define quem executa as queries de opções dos parâmetros
+// das queries carregadas a partir de então
+func (cl *ConfigLoader) SetOptionsLoader(loader entities.OptionsLoader) {
+	cl.optionsLoader = loader
+}
+
 func (cl *ConfigLoader) LoadQueries() (map[string]entities.Query, map[string]entities.QueryConfig, error) {
 	queries := make(map[string]entities.Query)
 	configs := make(map[string]entities.QueryConfig)
@@ -35,10 +44,15 @@ func (cl *ConfigLoader) LoadQueries() (map[string]entities.Query, map[string]ent
 		}
 
 		query := NewConfigQuery(config)
+		query.(*ConfigQuery).SetOptionsLoader(cl.optionsLoader)
 		queries[config.Name] = query
 		configs[config.Name] = *config
 	}
 
+	if err := checkSectionReports(configs); err != nil {
+		return nil, nil, err
+	}
+
 	return queries, configs, nil
 }
 
@@ -66,6 +80,10 @@ func (cl *ConfigLoader) validateConfig(config *entities.QueryConfig) error {
 		return fmt.Errorf("query name is required")
 	}
 
+	if len(config.Sections) > 0 {
+		return cl.validateComposite(config)
+	}
+
 	if config.Query == "" {
 		return fmt.Errorf("query is required")
 	}
@@ -75,6 +93,190 @@ func (cl *ConfigLoader) validateConfig(config *entities.QueryConfig) error {
 		return fmt.Errorf("invalid SQL: %w", err)
 	}
 
+	if config.Timezone != "" {
+		if _, err := time.LoadLocation(config.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone: %w", err)
+		}
+	}
+
+	if err := cl.validateParams(config); err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+
+	if err := checkCrossRules(config); err != nil {
+		return fmt.Errorf("invalid rules: %w", err)
+	}
+
+	if err := cl.validateTemplate(config); err != nil {
+		return fmt.Errorf("invalid query template: %w", err)
+	}
+
+	if err := checkOutputFields(config); err != nil {
+		return fmt.Errorf("invalid output: %w", err)
+	}
+
+	if err := checkComputed(config); err != nil {
+		return fmt.Errorf("invalid computed columns: %w", err)
+	}
+
+	if config.Output.Grouping != nil {
+		if err := checkGrouping(config.Output.Grouping); err != nil {
+			return fmt.Errorf("invalid grouping: %w", err)
+		}
+	}
+
+	if config.Output.Pivot != nil {
+		if err := checkPivot(config.Output); err != nil {
+			return fmt.Errorf("invalid pivot: %w", err)
+		}
+	}
+
+	if config.Output.Nest != nil {
+		if err := checkNesting(config.Output); err != nil {
+			return fmt.Errorf("invalid nest: %w", err)
+		}
+	}
+
+	if config.Pagination != nil {
+		if err := checkPagination(config.Pagination, config.Query); err != nil {
+			return fmt.Errorf("invalid pagination: %w", err)
+		}
+	}
+
+	if config.Warmup != nil {
+		if err := cl.validateWarmup(config); err != nil {
+			return fmt.Errorf("invalid warmup: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateComposite valida um relatório composto: parâmetros compartilhados
+// e seções. Os relatórios das seções são verificados após o carregamento de
+// todos os arquivos (checkSectionReports).
+func (cl *ConfigLoader) validateComposite(config *entities.QueryConfig) error {
+	if config.Query != "" {
+		return fmt.Errorf("composite report cannot define a query")
+	}
+	if config.Pagination != nil || config.Warmup != nil {
+		return fmt.Errorf("composite report does not support pagination or warmup")
+	}
+
+	if err := cl.validateParams(config); err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+
+	if err := checkCrossRules(config); err != nil {
+		return fmt.Errorf("invalid rules: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, section := range config.Sections {
+		if section.Name == "" || section.Report == "" {
+			return fmt.Errorf("section name and report are required")
+		}
+		if seen[section.Name] {
+			return fmt.Errorf("duplicate section '%s'", section.Name)
+		}
+		seen[section.Name] = true
+	}
+
+	return nil
+}
+
+// checkSectionReports verifica se as seções dos relatórios compostos
+// referenciam relatórios existentes e não compostos, e se os parâmetros fixos
+// das seções são declarados por esses relatórios
+func checkSectionReports(configs map[string]entities.QueryConfig) error {
+	for name, config := range configs {
+		for _, section := range config.Sections {
+			target, ok := configs[section.Report]
+			if !ok {
+				return fmt.Errorf("report %s: section '%s' references unknown report '%s'", name, section.Name, section.Report)
+			}
+			if len(target.Sections) > 0 {
+				return fmt.Errorf("report %s: section '%s' references composite report '%s'", name, section.Name, section.Report)
+			}
+			for param := range section.Params {
+				if !slices.ContainsFunc(target.Parameters, func(declared entities.ParamConfig) bool { return declared.Name == param }) {
+					return fmt.Errorf("report %s: section '%s' sets parameter '%s', which is not declared by report '%s'", name, section.Name, param, section.Report)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (cl *ConfigLoader) validateParams(config *entities.QueryConfig) error {
+	query := &ConfigQuery{config: config}
+	seen := make(map[string]bool)
+
+	for _, param := range config.Parameters {
+		if seen[param.Name] {
+			return fmt.Errorf("duplicate parameter '%s'", param.Name)
+		}
+
+		// Parâmetros pai devem ser declarados antes do parâmetro dependente
+		if param.Options != nil {
+			for _, parent := range param.Options.DependsOn {
+				if !seen[parent] {
+					return fmt.Errorf("parameter '%s': options depend on '%s', which must be declared before it", param.Name, parent)
+				}
+			}
+		}
+		seen[param.Name] = true
+
+		if err := query.checkParamConfig(param); err != nil {
+			return err
+		}
+
+		if param.Options != nil {
+			if err := cl.validateSQL(param.Options.Query); err != nil {
+				return fmt.Errorf("parameter '%s': invalid options SQL: %w", param.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (cl *ConfigLoader) validateTemplate(config *entities.QueryConfig) error {
+	nodes, err := parseSQLTemplate(config.Query)
+	if err != nil {
+		return err
+	}
+
+	declared := make(map[string]bool)
+	for _, param := range config.Parameters {
+		declared[param.Name] = true
+	}
+
+	for _, name := range templateParams(nodes) {
+		if !declared[name] {
+			return fmt.Errorf("condition references undeclared parameter '%s'", name)
+		}
+	}
+
+	return nil
+}
+
+func (cl *ConfigLoader) validateWarmup(config *entities.QueryConfig) error {
+	if _, err := NextScheduledRun(config.Warmup.Schedule, time.Now()); err != nil {
+		return err
+	}
+
+	query := NewConfigQuery(config)
+	for i, warmupParams := range config.Warmup.Params {
+		params := make(map[string]interface{}, len(warmupParams))
+		for name, value := range warmupParams {
+			params[name] = value
+		}
+		if err := query.Validate(params); err != nil {
+			return fmt.Errorf("params set %d: %w", i, err)
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,77 @@
+package query
+
+import (
+	"strings"
+	"testing"
+
+	"reports-system/internal/domain/entities"
+)
+
+func TestValidateComposite(t *testing.T) {
+	sections := []entities.SectionConfig{{Name: "a", Report: "sales"}, {Name: "b", Report: "stock"}}
+
+	tests := []struct {
+		name   string
+		config entities.QueryConfig
+		// trecho esperado na mensagem; vazio quando a configuração é válida
+		err string
+	}{
+		{"valid", entities.QueryConfig{Name: "overview", Sections: sections}, ""},
+		{"with query", entities.QueryConfig{Name: "overview", Query: "SELECT 1", Sections: sections}, "cannot define a query"},
+		{"with pagination", entities.QueryConfig{Name: "overview", Sections: sections, Pagination: &entities.PaginationConfig{}}, "pagination"},
+		{"unnamed section", entities.QueryConfig{Name: "overview", Sections: []entities.SectionConfig{{Report: "sales"}}}, "name and report are required"},
+		{"duplicate section", entities.QueryConfig{Name: "overview", Sections: []entities.SectionConfig{{Name: "a", Report: "sales"}, {Name: "a", Report: "stock"}}}, "duplicate section 'a'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewConfigLoader(t.TempDir()).validateConfig(&tt.config)
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("validateConfig: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("validateConfig error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestCheckSectionReports(t *testing.T) {
+	sales := entities.QueryConfig{Name: "sales", Query: "SELECT 1", Parameters: []entities.ParamConfig{{Name: "status", Type: "string"}}}
+	composite := func(section entities.SectionConfig) map[string]entities.QueryConfig {
+		return map[string]entities.QueryConfig{
+			"sales":    sales,
+			"overview": {Name: "overview", Sections: []entities.SectionConfig{section}},
+			"nested":   {Name: "nested", Sections: []entities.SectionConfig{{Name: "s", Report: "sales"}}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		section entities.SectionConfig
+		err     string
+	}{
+		{"valid", entities.SectionConfig{Name: "s", Report: "sales", Params: map[string]interface{}{"status": "paid"}}, ""},
+		{"unknown report", entities.SectionConfig{Name: "s", Report: "missing"}, "unknown report 'missing'"},
+		{"composite section", entities.SectionConfig{Name: "s", Report: "nested"}, "composite report 'nested'"},
+		{"undeclared param", entities.SectionConfig{Name: "s", Report: "sales", Params: map[string]interface{}{"region": "Sul"}}, "parameter 'region'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSectionReports(composite(tt.section))
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("checkSectionReports: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("checkSectionReports error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -2,7 +2,9 @@ package query
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
@@ -12,13 +14,37 @@ import (
 )
 
 type ConfigQuery struct {
-	config *entities.QueryConfig
+	config        *entities.QueryConfig
+	template      []sqlNode
+	computed      []computedColumn
+	optionsLoader entities.OptionsLoader
+	// Fuso da requisição ou do relatório; nil quando nenhum foi escolhido
+	loc *time.Location
 	BaseQuery
 }
 
 func NewConfigQuery(config *entities.QueryConfig) entities.Query {
+	// O template já foi validado pelo ConfigLoader; em caso de erro o SQL é
+	// usado sem processamento
+	template, err := parseSQLTemplate(config.Query)
+	if err != nil {
+		template = []sqlNode{{text: config.Query}}
+	}
+
+	// Expressões inválidas também são rejeitadas no carregamento
+	computed, _ := compileComputed(config)
+
+	// O fuso do relatório é validado no carregamento e resolvido uma única vez
+	var loc *time.Location
+	if config.Timezone != "" {
+		loc, _ = time.LoadLocation(config.Timezone)
+	}
+
 	return &ConfigQuery{
-		config: config,
+		config:   config,
+		template: template,
+		computed: computed,
+		loc:      loc,
 	}
 }
 
@@ -30,230 +56,514 @@ func (q *ConfigQuery) Description() string {
 	return q.config.Description
 }
 
+// Validate valida todos os parâmetros e retorna um *entities.ValidationError
+// com todas as falhas encontradas, não apenas a primeira.
 func (q *ConfigQuery) Validate(params map[string]interface{}) error {
+	var fieldErrors []entities.FieldError
+
+	// Presença na requisição, antes da aplicação dos defaults
+	requested := make(map[string]bool, len(params))
+	for name := range params {
+		requested[name] = paramProvided(params, name)
+	}
+
 	for _, paramConfig := range q.config.Parameters {
 		value, exists := params[paramConfig.Name]
 
-		if paramConfig.Required && !exists {
+		if !exists {
 			// Verificar se há valor padrão
-			if paramConfig.Default != nil {
-				params[paramConfig.Name] = q.resolveDefault(paramConfig.Default)
+			if paramConfig.Default == nil {
+				if paramConfig.Required {
+					fieldErrors = append(fieldErrors, entities.FieldError{
+						Param:   paramConfig.Name,
+						Rule:    "required",
+						Message: fmt.Sprintf("required parameter '%s' is missing", paramConfig.Name),
+					})
+				}
 				continue
 			}
-			return fmt.Errorf("required parameter '%s' is missing", paramConfig.Name)
+			value = q.resolveDefault(paramConfig)
 		}
 
-		if !exists {
-			if paramConfig.Default != nil {
-				params[paramConfig.Name] = q.resolveDefault(paramConfig.Default)
+		// Listas são normalizadas para []interface{} antes do BuildQuery
+		if elemType, ok := listElementType(paramConfig.Type); ok {
+			list, err := q.validateList(paramConfig, elemType, value)
+			if err != nil {
+				if optionsFailed(err) {
+					return err
+				}
+				fieldErrors = append(fieldErrors, toFieldError(paramConfig, value, err))
+				continue
 			}
+			params[paramConfig.Name] = list
 			continue
 		}
 
 		if err := q.validateParam(paramConfig, value); err != nil {
+			if optionsFailed(err) {
+				return err
+			}
+			fieldErrors = append(fieldErrors, toFieldError(paramConfig, value, err))
+			continue
+		}
+
+		// Converter para o tipo Go canônico antes do binding
+		params[paramConfig.Name] = coerceParam(paramConfig, value, q.location())
+	}
+
+	// Regras entre parâmetros só fazem sentido com os valores já convertidos
+	if len(fieldErrors) == 0 {
+		fieldErrors = q.validateCrossRules(params, requested)
+	}
+	if len(fieldErrors) == 0 {
+		var err error
+		if fieldErrors, err = q.validateDependentOptions(params); err != nil {
 			return err
 		}
 	}
 
+	if len(fieldErrors) > 0 {
+		return &entities.ValidationError{Errors: fieldErrors}
+	}
+
 	return nil
 }
 
+// optionsFailed indica que a query de opções não pôde ser executada (banco
+// indisponível, SQL inválido): a falha não é do valor do parâmetro e
+// interrompe a validação
+func optionsFailed(err error) bool {
+	var reportErr *entities.ReportError
+	return errors.As(err, &reportErr)
+}
+
+func toFieldError(config entities.ParamConfig, value interface{}, err error) entities.FieldError {
+	if fieldErr, ok := err.(*entities.FieldError); ok {
+		return *fieldErr
+	}
+	return entities.FieldError{
+		Param:   config.Name,
+		Rule:    "invalid",
+		Message: err.Error(),
+		Value:   value,
+	}
+}
+
+// listElementType identifica os tipos de lista: "array" (lista de strings) e
+// "list<T>", onde T é qualquer tipo escalar suportado.
+func listElementType(paramType string) (string, bool) {
+	if paramType == "array" {
+		return "string", true
+	}
+	if strings.HasPrefix(paramType, "list<") && strings.HasSuffix(paramType, ">") {
+		return strings.TrimSuffix(strings.TrimPrefix(paramType, "list<"), ">"), true
+	}
+	return "", false
+}
+
+func (q *ConfigQuery) validateList(config entities.ParamConfig, elemType string, value interface{}) ([]interface{}, error) {
+	var items []interface{}
+
+	switch v := value.(type) {
+	case []interface{}:
+		items = v
+	case []string:
+		for _, item := range v {
+			items = append(items, item)
+		}
+	case string:
+		// GET: valores separados por vírgula (?regions=norte,sul)
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	default:
+		items = []interface{}{v}
+	}
+
+	if len(items) == 0 {
+		return nil, paramError(config, "min_items", value, "parameter '%s' must contain at least one value", config.Name)
+	}
+
+	if minVal, ok := config.Validation["min_items"]; ok {
+		if min, ok := minVal.(float64); ok && len(items) < int(min) {
+			return nil, paramError(config, "min_items", value, "parameter '%s' must contain at least %v values", config.Name, min)
+		}
+	}
+
+	if maxVal, ok := config.Validation["max_items"]; ok {
+		if max, ok := maxVal.(float64); ok && len(items) > int(max) {
+			return nil, paramError(config, "max_items", value, "parameter '%s' must contain at most %v values", config.Name, max)
+		}
+	}
+
+	// Validar cada elemento reaproveitando os validadores escalares
+	elemConfig := config
+	elemConfig.Type = elemType
+	coerced := make([]interface{}, len(items))
+	for i, item := range items {
+		elemConfig.Name = fmt.Sprintf("%s[%d]", config.Name, i)
+		if err := q.validateParam(elemConfig, item); err != nil {
+			return nil, err
+		}
+		coerced[i] = coerceParam(elemConfig, item, q.location())
+	}
+
+	return coerced, nil
+}
+
 func (q *ConfigQuery) validateParam(config entities.ParamConfig, value interface{}) error {
+	var err error
 	switch config.Type {
 	case "date":
-		return q.validateDate(config, value)
+		err = q.validateDate(config, value)
 	case "datetime":
-		return q.validateDateTime(config, value)
+		err = q.validateDateTime(config, value)
 	case "string":
-		return q.validateString(config, value)
+		err = q.validateString(config, value)
 	case "int":
-		return q.validateInt(config, value)
+		err = q.validateInt(config, value)
 	case "float":
-		return q.validateFloat(config, value)
+		err = q.validateFloat(config, value)
 	case "bool":
-		return q.validateBool(config, value)
+		err = q.validateBool(config, value)
 	case "enum":
-		return q.validateEnum(config, value)
+		err = q.validateEnum(config, value)
+	case "decimal":
+		err = q.validateDecimal(config, value)
 	default:
 		return fmt.Errorf("unsupported parameter type: %s", config.Type)
 	}
+	if err != nil {
+		return err
+	}
+
+	// Regras comuns a todos os tipos (min, max, regex, values, ...)
+	return q.validateRules(config, value)
 }
 
 func (q *ConfigQuery) validateDate(config entities.ParamConfig, value interface{}) error {
-	str, ok := value.(string)
-	if !ok {
-		return fmt.Errorf("parameter '%s' must be a string date", config.Name)
-	}
+	format := paramLayout(config, "2006-01-02")
 
-	format := "2006-01-02"
-	if val, ok := config.Validation["format"]; ok {
-		if formatStr, ok := val.(string); ok {
-			format = q.convertDateFormat(formatStr)
-		}
+	// Valores já convertidos (defaults, warmup) são revalidados pelo texto
+	if t, ok := value.(time.Time); ok {
+		value = t.Format(format)
 	}
 
-	date, err := time.Parse(format, str)
-	if err != nil {
-		return fmt.Errorf("parameter '%s' must be in format %s", config.Name, format)
+	str, ok := value.(string)
+	if !ok {
+		return paramError(config, "type", value, "parameter '%s' must be a string date", config.Name)
 	}
 
-	// Validar range
-	if minVal, ok := config.Validation["min"]; ok {
-		if minStr, ok := minVal.(string); ok {
-			minDate, err := time.Parse(format, minStr)
-			if err == nil && date.Before(minDate) {
-				return fmt.Errorf("parameter '%s' must be after %s", config.Name, minStr)
-			}
-		}
+	if _, err := time.ParseInLocation(format, str, q.location()); err != nil {
+		return paramError(config, "format", value, "parameter '%s' must be in format %s", config.Name, format)
 	}
 
 	return nil
 }
 
 func (q *ConfigQuery) validateString(config entities.ParamConfig, value interface{}) error {
-	str, ok := value.(string)
-	if !ok {
-		return fmt.Errorf("parameter '%s' must be a string", config.Name)
-	}
-
-	if regexVal, ok := config.Validation["regex"]; ok {
-		if regexStr, ok := regexVal.(string); ok {
-			regex, err := regexp.Compile(regexStr)
-			if err != nil {
-				return fmt.Errorf("invalid regex for parameter '%s'", config.Name)
-			}
-			if !regex.MatchString(str) {
-				return fmt.Errorf("parameter '%s' does not match required pattern", config.Name)
-			}
-		}
+	if _, ok := value.(string); !ok {
+		return paramError(config, "type", value, "parameter '%s' must be a string", config.Name)
 	}
 
 	return nil
 }
 
 func (q *ConfigQuery) validateInt(config entities.ParamConfig, value interface{}) error {
-	var intVal int64
-
 	switch v := value.(type) {
-	case int:
-		intVal = int64(v)
-	case int64:
-		intVal = v
+	case int, int64:
+		return nil
 	case float64:
-		intVal = int64(v)
+		if v != math.Trunc(v) {
+			return paramError(config, "type", value, "parameter '%s' must be an integer", config.Name)
+		}
 	case string:
-		val, err := strconv.ParseInt(v, 10, 64)
-		if err != nil {
-			return fmt.Errorf("parameter '%s' must be an integer", config.Name)
+		if _, err := strconv.ParseInt(v, 10, 64); err != nil {
+			return paramError(config, "type", value, "parameter '%s' must be an integer", config.Name)
 		}
-		intVal = val
 	default:
-		return fmt.Errorf("parameter '%s' must be an integer", config.Name)
+		return paramError(config, "type", value, "parameter '%s' must be an integer", config.Name)
 	}
 
-	// Validar range
-	if minVal, ok := config.Validation["min"]; ok {
-		if min, ok := minVal.(float64); ok && intVal < int64(min) {
-			return fmt.Errorf("parameter '%s' must be at least %v", config.Name, min)
-		}
-	}
+	return nil
+}
 
-	if maxVal, ok := config.Validation["max"]; ok {
-		if max, ok := maxVal.(float64); ok && intVal > int64(max) {
-			return fmt.Errorf("parameter '%s' must be at most %v", config.Name, max)
+func (q *ConfigQuery) validateFloat(config entities.ParamConfig, value interface{}) error {
+	switch v := value.(type) {
+	case float64, int, int64:
+		return nil
+	case string:
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return paramError(config, "type", value, "parameter '%s' must be a number", config.Name)
 		}
+	default:
+		return paramError(config, "type", value, "parameter '%s' must be a number", config.Name)
 	}
 
 	return nil
 }
 
-func (q *ConfigQuery) validateFloat(config entities.ParamConfig, value interface{}) error {
-	var floatVal float64
-
+func (q *ConfigQuery) validateBool(config entities.ParamConfig, value interface{}) error {
 	switch v := value.(type) {
+	case bool:
+		return nil
 	case float64:
-		floatVal = v
-	case int:
-		floatVal = float64(v)
+		if v != 0 && v != 1 {
+			return paramError(config, "type", value, "parameter '%s' must be a boolean", config.Name)
+		}
 	case string:
-		val, err := strconv.ParseFloat(v, 64)
+		_, err := strconv.ParseBool(v)
 		if err != nil {
-			return fmt.Errorf("parameter '%s' must be a number", config.Name)
+			return paramError(config, "type", value, "parameter '%s' must be a boolean", config.Name)
 		}
-		floatVal = val
 	default:
-		return fmt.Errorf("parameter '%s' must be a number", config.Name)
-	}
-
-	// Validar range
-	if minVal, ok := config.Validation["min"]; ok {
-		if min, ok := minVal.(float64); ok && floatVal < min {
-			return fmt.Errorf("parameter '%s' must be at least %v", config.Name, min)
-		}
+		return paramError(config, "type", value, "parameter '%s' must be a boolean", config.Name)
 	}
 
 	return nil
 }
 
-func (q *ConfigQuery) validateBool(config entities.ParamConfig, value interface{}) error {
+func (q *ConfigQuery) validateDecimal(config entities.ParamConfig, value interface{}) error {
 	switch v := value.(type) {
-	case bool:
+	case float64, int, int64:
 		return nil
 	case string:
-		_, err := strconv.ParseBool(v)
-		if err != nil {
-			return fmt.Errorf("parameter '%s' must be a boolean", config.Name)
+		if !decimalPattern.MatchString(strings.TrimSpace(v)) {
+			return paramError(config, "type", value, "parameter '%s' must be a decimal number", config.Name)
 		}
 	default:
-		return fmt.Errorf("parameter '%s' must be a boolean", config.Name)
+		return paramError(config, "type", value, "parameter '%s' must be a decimal number", config.Name)
 	}
 
 	return nil
 }
 
+// validateEnum exige a regra 'values' ou uma query de opções. A pertinência
+// a 'values' é verificada em validateRules, igual aos demais tipos.
 func (q *ConfigQuery) validateEnum(config entities.ParamConfig, value interface{}) error {
-	enumValues, ok := config.Validation["values"]
-	if !ok {
+	if config.Options != nil {
+		// Opções dependentes de outros parâmetros são verificadas em
+		// validateDependentOptions, após todos os valores serem convertidos
+		if len(config.Options.DependsOn) > 0 {
+			return nil
+		}
+		return q.validateOptions(config, value, nil)
+	}
+
+	if _, ok := config.Validation["values"]; !ok {
 		return fmt.Errorf("enum parameter '%s' must have 'values' validation", config.Name)
 	}
 
-	values, ok := enumValues.([]interface{})
-	if !ok {
-		return fmt.Errorf("enum values must be an array")
+	return nil
+}
+
+// validateOptions verifica o valor contra as opções da query de lookup. Sem
+// um OptionsLoader (ex.: validação no carregamento) a verificação é ignorada.
+func (q *ConfigQuery) validateOptions(config entities.ParamConfig, value interface{}, parents map[string]interface{}) error {
+	if q.optionsLoader == nil {
+		return nil
+	}
+
+	options, err := q.optionsLoader(q.config.Name, q.paramConfig(config), parents)
+	if err != nil {
+		return &entities.ReportError{
+			Kind:    entities.ErrorKindOf(err),
+			Message: fmt.Sprintf("failed to load options for parameter '%s'", config.Name),
+			Err:     err,
+		}
 	}
 
-	valueStr := fmt.Sprintf("%v", value)
-	for _, enumVal := range values {
-		if fmt.Sprintf("%v", enumVal) == valueStr {
+	for _, option := range options {
+		if optionMatches(value, option.Value) {
 			return nil
 		}
 	}
 
-	return fmt.Errorf("parameter '%s' must be one of: %v", config.Name, values)
+	return paramError(config, "options", value, "parameter '%s' is not a valid option", config.Name)
 }
 
-func (q *ConfigQuery) validateDateTime(config entities.ParamConfig, value interface{}) error {
-	str, ok := value.(string)
-	if !ok {
-		return fmt.Errorf("parameter '%s' must be a string datetime", config.Name)
+// optionMatches compara o valor do parâmetro com o de uma opção. Opções
+// numéricas (int64 do banco, json.Number do cache) são comparadas pelo valor
+// exato; as demais pelo texto.
+func optionMatches(value, option interface{}) bool {
+	if _, isText := option.(string); !isText {
+		if cmp, ok := compareTyped(value, option); ok {
+			return cmp == 0
+		}
+	}
+	return fmt.Sprintf("%v", value) == fmt.Sprintf("%v", option)
+}
+
+// validateDependentOptions verifica se os valores de parâmetros com opções
+// em cascata (ex.: país → estado → cidade) pertencem às opções filtradas
+// pelos valores dos parâmetros pai.
+func (q *ConfigQuery) validateDependentOptions(params map[string]interface{}) ([]entities.FieldError, error) {
+	var fieldErrors []entities.FieldError
+
+	for _, param := range q.config.Parameters {
+		if param.Options == nil || len(param.Options.DependsOn) == 0 || !paramProvided(params, param.Name) {
+			continue
+		}
+
+		parents := make(map[string]interface{}, len(param.Options.DependsOn))
+		for _, parent := range param.Options.DependsOn {
+			// Pai opcional não informado é enviado como NULL à query
+			var value interface{}
+			if paramProvided(params, parent) {
+				value = params[parent]
+			}
+			parents[parent] = value
+		}
+
+		values, isList := params[param.Name].([]interface{})
+		if !isList {
+			values = []interface{}{params[param.Name]}
+		}
+
+		for _, value := range values {
+			if err := q.validateOptions(param, value, parents); err != nil {
+				if optionsFailed(err) {
+					return nil, err
+				}
+				fieldErrors = append(fieldErrors, toFieldError(param, value, err))
+				break
+			}
+		}
 	}
 
-	format := "2006-01-02 15:04:05"
-	if val, ok := config.Validation["format"]; ok {
-		if formatStr, ok := val.(string); ok {
-			format = q.convertDateFormat(formatStr)
+	return fieldErrors, nil
+}
+
+// paramConfig retorna a declaração original do parâmetro; em listas o
+// validador recebe uma cópia com nome indexado (ex.: regions[0])
+func (q *ConfigQuery) paramConfig(config entities.ParamConfig) entities.ParamConfig {
+	name := config.Name
+	if i := strings.Index(name, "["); i >= 0 {
+		name = name[:i]
+	}
+	for _, param := range q.config.Parameters {
+		if param.Name == name {
+			return param
 		}
 	}
+	return config
+}
 
-	_, err := time.Parse(format, str)
-	if err != nil {
-		return fmt.Errorf("parameter '%s' must be in format %s", config.Name, format)
+// Options retorna as opções de um parâmetro: as da query de lookup, quando
+// configurada, ou os 'values' estáticos de um enum. Parâmetros listados em
+// depends_on são obrigatórios e filtram as opções.
+func (q *ConfigQuery) Options(paramName string, params map[string]interface{}) ([]entities.ParamOption, error) {
+	for _, param := range q.config.Parameters {
+		if param.Name != paramName {
+			continue
+		}
+
+		if param.Options != nil {
+			if q.optionsLoader == nil {
+				return nil, fmt.Errorf("options loader not configured")
+			}
+
+			parents, err := q.optionsParents(param, params)
+			if err != nil {
+				return nil, err
+			}
+			return q.optionsLoader(q.config.Name, param, parents)
+		}
+
+		values, _ := param.Validation["values"].([]interface{})
+		options := make([]entities.ParamOption, len(values))
+		for i, value := range values {
+			options[i] = entities.ParamOption{Value: value, Label: fmt.Sprintf("%v", value)}
+		}
+		return options, nil
+	}
+
+	return nil, &entities.ReportError{Kind: entities.ErrorParamNotFound, Message: fmt.Sprintf("parameter '%s' not found", paramName)}
+}
+
+// optionsParents valida e converte os valores dos parâmetros pai informados
+// na requisição de opções. Pais não informados usam o default; os opcionais
+// sem default são enviados como NULL, como na validação do relatório.
+func (q *ConfigQuery) optionsParents(param entities.ParamConfig, params map[string]interface{}) (map[string]interface{}, error) {
+	var fieldErrors []entities.FieldError
+	parents := make(map[string]interface{}, len(param.Options.DependsOn))
+
+	for _, parentName := range param.Options.DependsOn {
+		parent := q.paramConfig(entities.ParamConfig{Name: parentName})
+		value := params[parentName]
+		if !paramProvided(params, parentName) {
+			switch {
+			case parent.Default != nil:
+				value = q.resolveDefault(parent)
+			case parent.Required:
+				fieldErrors = append(fieldErrors, entities.FieldError{
+					Param:   parentName,
+					Rule:    "required",
+					Message: fmt.Sprintf("parameter '%s' is required to list options of '%s'", parentName, param.Name),
+				})
+				continue
+			default:
+				parents[parentName] = nil
+				continue
+			}
+		}
+
+		if elemType, ok := listElementType(parent.Type); ok {
+			list, err := q.validateList(parent, elemType, value)
+			if err != nil {
+				if optionsFailed(err) {
+					return nil, err
+				}
+				fieldErrors = append(fieldErrors, toFieldError(parent, value, err))
+				continue
+			}
+			parents[parentName] = list
+			continue
+		}
+
+		if err := q.validateParam(parent, value); err != nil {
+			if optionsFailed(err) {
+				return nil, err
+			}
+			fieldErrors = append(fieldErrors, toFieldError(parent, value, err))
+			continue
+		}
+		parents[parentName] = coerceParam(parent, value, q.location())
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, &entities.ValidationError{Errors: fieldErrors}
+	}
+
+	return parents, nil
+}
+
+func (q *ConfigQuery) SetOptionsLoader(loader entities.OptionsLoader) {
+	q.optionsLoader = loader
+}
+
+func (q *ConfigQuery) validateDateTime(config entities.ParamConfig, value interface{}) error {
+	format := paramLayout(config, "2006-01-02 15:04:05")
+
+	if t, ok := value.(time.Time); ok {
+		value = t.Format(format)
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return paramError(config, "type", value, "parameter '%s' must be a string datetime", config.Name)
+	}
+
+	if _, err := time.ParseInLocation(format, str, q.location()); err != nil {
+		return paramError(config, "format", value, "parameter '%s' must be in format %s", config.Name, format)
 	}
 
 	return nil
 }
 
-func (q *ConfigQuery) convertDateFormat(format string) string {
+func convertDateFormat(format string) string {
 	// Converter formatos comuns para Go
 	replacements := map[string]string{
 		"YYYY": "2006",
@@ -271,67 +581,137 @@ func (q *ConfigQuery) convertDateFormat(format string) string {
 	return result
 }
 
-func (q *ConfigQuery) resolveDefault(defaultValue interface{}) interface{} {
-	if str, ok := defaultValue.(string); ok {
-		switch str {
-		case "now()":
-			return time.Now().Format("2006-01-02")
-		default:
-			// Verificar se é uma expressão de data
-			if strings.HasPrefix(str, "now(") && strings.HasSuffix(str, ")") {
-				return q.evaluateDateExpression(str)
-			}
-		}
+// resolveDefault avalia defaults de data relativos (ex.: "now(-30d)",
+// "startOfMonth(now(-1m))") no layout do parâmetro; demais valores são
+// retornados sem alteração.
+func (q *ConfigQuery) resolveDefault(config entities.ParamConfig) interface{} {
+	str, ok := config.Default.(string)
+	if !ok || !isDateExpression(str) {
+		return config.Default
+	}
+
+	if _, ok := dateLayouts[paramBaseType(config)]; !ok {
+		return config.Default
 	}
 
-	return defaultValue
+	resolved, err := q.evaluateDateExpression(config, str)
+	if err != nil {
+		// Expressões inválidas são rejeitadas no carregamento; aqui o valor
+		// segue para a validação, que reporta o erro de formato
+		return str
+	}
+	return resolved
 }
 
-func (q *ConfigQuery) evaluateDateExpression(expr string) string {
-	// Exemplo: now(-30d) = 30 dias atrás
-	inner := strings.TrimPrefix(expr, "now(")
-	inner = strings.TrimSuffix(inner, ")")
+// evaluateDateExpression avalia a expressão no fuso do relatório e a formata
+// no layout do parâmetro
+func (q *ConfigQuery) evaluateDateExpression(config entities.ParamConfig, expr string) (string, error) {
+	evaluate, err := parseDateExpression(expr)
+	if err != nil {
+		return "", err
+	}
 
-	if inner == "" {
-		return time.Now().Format("2006-01-02")
+	// Sem fuso escolhido, "hoje" é a data do servidor
+	now := time.Now()
+	if q.loc != nil {
+		now = now.In(q.loc)
 	}
 
-	// Parse simple expressions like -30d, +7d, etc.
-	re := regexp.MustCompile(`^([+-]?\d+)([dhmy])`)
-	matches := re.FindStringSubmatch(inner)
+	layout := paramLayout(config, dateLayouts[paramBaseType(config)])
+	return evaluate(now).Format(layout), nil
+}
 
-	if len(matches) == 3 {
-		amount, _ := strconv.Atoi(matches[1])
-		unit := matches[2]
+// WithTimezone retorna uma cópia da query que interpreta parâmetros de data
+// e converte datas do resultado no fuso informado (ex.: fuso da requisição).
+// Sem fuso, a query é retornada sem alteração.
+func (q *ConfigQuery) WithTimezone(timezone string) (entities.Query, error) {
+	if timezone == "" {
+		return q, nil
+	}
 
-		now := time.Now()
-		switch unit {
-		case "d":
-			return now.AddDate(0, 0, amount).Format("2006-01-02")
-		case "m":
-			return now.AddDate(0, amount, 0).Format("2006-01-02")
-		case "y":
-			return now.AddDate(amount, 0, 0).Format("2006-01-02")
-		}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone '%s'", timezone)
 	}
 
-	return time.Now().Format("2006-01-02")
+	clone := *q
+	clone.loc = loc
+	return &clone, nil
+}
+
+// Timezone retorna o nome do fuso efetivo da query (requisição ou relatório)
+func (q *ConfigQuery) Timezone() string {
+	if loc, ok := q.resultLocation(); ok {
+		return loc.String()
+	}
+	return ""
+}
+
+// location retorna o fuso em que os parâmetros de data são interpretados:
+// o da requisição, o configurado no relatório (timezone) ou UTC
+func (q *ConfigQuery) location() *time.Location {
+	if q.loc != nil {
+		return q.loc
+	}
+	return time.UTC
+}
+
+// resultLocation retorna o fuso explicitamente escolhido para a query; só
+// nesse caso as datas do resultado são convertidas
+func (q *ConfigQuery) resultLocation() (*time.Location, bool) {
+	return q.loc, q.loc != nil
 }
 
 func (q *ConfigQuery) BuildQuery(params map[string]interface{}) (string, []interface{}) {
-	query := q.config.Query
+	// Resolver blocos condicionais ({{if param}} ... {{end}})
+	query := renderSQLTemplate(q.template, params)
+	return BindNamedParams(query, params)
+}
+
+// namedParamPattern reconhece as referências a parâmetros no SQL (@param)
+var namedParamPattern = regexp.MustCompile(`@(\w+)`)
+
+// listParamSeparator separa o nome do parâmetro do índice nos placeholders
+// gerados para listas (@ids__1, @ids__2, ...); nomes de parâmetros não podem
+// contê-lo
+const listParamSeparator = "__"
+
+// BindNamedParams associa os parâmetros referenciados no SQL (@param) a
+// argumentos sql.Named; a conversão para o placeholder do dialeto fica a
+// cargo do driver/provider. Listas são expandidas em um placeholder por
+// elemento (@p__1, @p__2, ...). Os argumentos seguem a ordem da primeira
+// referência no SQL.
+func BindNamedParams(query string, params map[string]interface{}) (string, []interface{}) {
 	var args []interface{}
+	bound := make(map[string]string)
 
-	// Substituir parâmetros nomeados (@param) por placeholders numerados
-	paramIndex := 1
-	for paramName, paramValue := range params {
-		placeholder := "@" + paramName
-		if strings.Contains(query, placeholder) {
-			//query = strings.ReplaceAll(query, placeholder, fmt.Sprintf("$%d", paramIndex))
+	query = namedParamPattern.ReplaceAllStringFunc(query, func(match string) string {
+		paramName := match[1:]
+		if placeholder, ok := bound[paramName]; ok {
+			return placeholder
+		}
+
+		paramValue, exists := params[paramName]
+		if !exists {
+			return match
+		}
+
+		placeholder := match
+		if list, ok := paramValue.([]interface{}); ok {
+			names := make([]string, len(list))
+			for i, item := range list {
+				name := fmt.Sprintf("%s%s%d", paramName, listParamSeparator, i+1)
+				names[i] = "@" + name
+				args = append(args, sql.Named(name, item))
+			}
+			placeholder = strings.Join(names, ", ")
+		} else {
 			args = append(args, sql.Named(paramName, paramValue))
-			paramIndex++
 		}
-	}
+
+		bound[paramName] = placeholder
+		return placeholder
+	})
 
 	return query, args
 }
@@ -343,15 +723,7 @@ func (q *ConfigQuery) TransformResults(columns []string, rows [][]interface{}) (
 		item := make(map[string]interface{})
 		for i, col := range columns {
 			if i < len(row) {
-				// Aplicar field mapping se definido
-				fieldName := col
-				if q.config.Output.FieldMapping != nil {
-					if mappedName, exists := q.config.Output.FieldMapping[col]; exists {
-						fieldName = mappedName
-					}
-				}
-
-				item[fieldName] = row[i]
+				item[q.fieldName(col)] = q.resultValue(row[i])
 			}
 		}
 		result = append(result, item)
@@ -360,6 +732,86 @@ func (q *ConfigQuery) TransformResults(columns []string, rows [][]interface{}) (
 	return result, nil
 }
 
+// TransformTable gera o formato compacto do resultado (colunas + linhas),
+// com os mesmos nomes e conversões de TransformResults
+func (q *ConfigQuery) TransformTable(columns []string, rows [][]interface{}) (*entities.TableData, error) {
+	table := &entities.TableData{
+		Columns: make([]string, len(columns)),
+		Rows:    make([][]interface{}, len(rows)),
+	}
+
+	for i, col := range columns {
+		table.Columns[i] = q.fieldName(col)
+	}
+
+	for i, row := range rows {
+		values := make([]interface{}, len(columns))
+		for j := range columns {
+			if j < len(row) {
+				values[j] = q.resultValue(row[j])
+			}
+		}
+		table.Rows[i] = values
+	}
+
+	return table, nil
+}
+
+// DescribeColumns monta o schema das colunas do resultado a partir dos tipos
+// informados pelo driver, seguido das colunas calculadas
+func (q *ConfigQuery) DescribeColumns(columnTypes []*sql.ColumnType) []entities.ColumnInfo {
+	columns := make([]entities.ColumnInfo, len(columnTypes), len(columnTypes)+len(q.computed))
+
+	for i, columnType := range columnTypes {
+		source := columnType.Name()
+		info := entities.ColumnInfo{
+			Name:   q.fieldName(source),
+			Source: source,
+			Type:   columnType.DatabaseTypeName(),
+			Label:  q.config.Output.Labels[source],
+		}
+		if info.Label == "" {
+			info.Label = info.Name
+		}
+		if nullable, ok := columnType.Nullable(); ok {
+			info.Nullable = &nullable
+		}
+		columns[i] = info
+	}
+
+	for _, computed := range q.computed {
+		info := entities.ColumnInfo{
+			Name:   q.fieldName(computed.name),
+			Source: computed.name,
+			Label:  q.config.Output.Labels[computed.name],
+		}
+		if info.Label == "" {
+			info.Label = info.Name
+		}
+		columns = append(columns, info)
+	}
+
+	return columns
+}
+
+// fieldName aplica o field mapping ao nome da coluna, se definido
+func (q *ConfigQuery) fieldName(col string) string {
+	if mappedName, exists := q.config.Output.FieldMapping[col]; exists {
+		return mappedName
+	}
+	return col
+}
+
+// resultValue converte datas do resultado para o fuso da requisição/relatório
+func (q *ConfigQuery) resultValue(value interface{}) interface{} {
+	if t, ok := value.(time.Time); ok {
+		if loc, convert := q.resultLocation(); convert {
+			return t.In(loc)
+		}
+	}
+	return value
+}
+
 func (q *ConfigQuery) OutputFormats() []string {
 	if len(q.config.Output.Formats) > 0 {
 		return q.config.Output.Formats
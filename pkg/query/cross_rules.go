@@ -0,0 +1,197 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"reports-system/internal/domain/entities"
+)
+
+var intervalPattern = regexp.MustCompile(`^(\d+)([hdwmy])$`)
+
+// validateCrossRules aplica as regras entre parâmetros sobre valores já
+// validados e convertidos. Regras de ordem e intervalo só são avaliadas
+// quando todos os parâmetros envolvidos têm valor, informado ou padrão;
+// mutually_exclusive e required_together consideram apenas os informados na
+// requisição (requested), para que um default não conte como presença.
+func (q *ConfigQuery) validateCrossRules(params map[string]interface{}, requested map[string]bool) []entities.FieldError {
+	var fieldErrors []entities.FieldError
+
+	for _, rule := range q.config.Rules {
+		if err := q.validateCrossRule(rule, params, requested); err != nil {
+			message := err.Error()
+			if rule.Message != "" {
+				message = rule.Message
+			}
+
+			values := make(map[string]interface{}, len(rule.Params))
+			for _, name := range rule.Params {
+				if value, ok := params[name]; ok {
+					values[name] = value
+				}
+			}
+
+			fieldErrors = append(fieldErrors, entities.FieldError{
+				Param:   strings.Join(rule.Params, ","),
+				Rule:    rule.Type,
+				Message: message,
+				Value:   values,
+			})
+		}
+	}
+
+	return fieldErrors
+}
+
+func (q *ConfigQuery) validateCrossRule(rule entities.CrossParamRule, params map[string]interface{}, requested map[string]bool) error {
+	var provided, informed []string
+	for _, name := range rule.Params {
+		if paramProvided(params, name) {
+			provided = append(provided, name)
+		}
+		if requested[name] {
+			informed = append(informed, name)
+		}
+	}
+
+	switch rule.Type {
+	case "order":
+		if len(provided) != len(rule.Params) {
+			return nil
+		}
+		for i := 1; i < len(rule.Params); i++ {
+			prev, next := rule.Params[i-1], rule.Params[i]
+			cmp, ok := compareTyped(params[prev], params[next])
+			if !ok {
+				return fmt.Errorf("parameters '%s' and '%s' cannot be compared", prev, next)
+			}
+			if cmp > 0 {
+				return fmt.Errorf("parameter '%s' must not be after '%s'", prev, next)
+			}
+		}
+	case "max_interval":
+		if len(provided) != len(rule.Params) {
+			return nil
+		}
+		start, okStart := params[rule.Params[0]].(time.Time)
+		end, okEnd := params[rule.Params[1]].(time.Time)
+		if !okStart || !okEnd {
+			return fmt.Errorf("parameters '%s' and '%s' must be dates", rule.Params[0], rule.Params[1])
+		}
+		limit, _ := addInterval(start, rule.Max)
+		if end.After(limit) {
+			return fmt.Errorf("interval between '%s' and '%s' must be at most %s", rule.Params[0], rule.Params[1], rule.Max)
+		}
+	case "mutually_exclusive":
+		if len(informed) > 1 {
+			return fmt.Errorf("parameters %s are mutually exclusive", strings.Join(quoted(informed), ", "))
+		}
+	case "required_together":
+		if len(informed) > 0 && len(informed) != len(rule.Params) {
+			return fmt.Errorf("parameters %s must be provided together", strings.Join(quoted(rule.Params), ", "))
+		}
+	}
+
+	return nil
+}
+
+// checkCrossRules valida as regras no carregamento da configuração
+func checkCrossRules(config *entities.QueryConfig) error {
+	declared := make(map[string]entities.ParamConfig)
+	for _, param := range config.Parameters {
+		declared[param.Name] = param
+	}
+
+	for i, rule := range config.Rules {
+		for _, name := range rule.Params {
+			if _, ok := declared[name]; !ok {
+				return fmt.Errorf("rule %d references undeclared parameter '%s'", i, name)
+			}
+		}
+
+		switch rule.Type {
+		case "order":
+			if len(rule.Params) < 2 {
+				return fmt.Errorf("rule %d: 'order' requires at least two params", i)
+			}
+		case "max_interval":
+			if len(rule.Params) != 2 {
+				return fmt.Errorf("rule %d: 'max_interval' requires exactly two params", i)
+			}
+			for _, name := range rule.Params {
+				if t := declared[name].Type; t != "date" && t != "datetime" {
+					return fmt.Errorf("rule %d: 'max_interval' requires date params, '%s' is %s", i, name, t)
+				}
+			}
+			if _, err := addInterval(time.Now(), rule.Max); err != nil {
+				return fmt.Errorf("rule %d: %w", i, err)
+			}
+		case "mutually_exclusive", "required_together":
+			if len(rule.Params) < 2 {
+				return fmt.Errorf("rule %d: '%s' requires at least two params", i, rule.Type)
+			}
+		default:
+			return fmt.Errorf("rule %d: unsupported rule type '%s'", i, rule.Type)
+		}
+	}
+
+	return nil
+}
+
+// addInterval soma um intervalo no formato <n><unidade> (h, d, w, m, y)
+func addInterval(t time.Time, interval string) (time.Time, error) {
+	matches := intervalPattern.FindStringSubmatch(interval)
+	if matches == nil {
+		return time.Time{}, fmt.Errorf("invalid interval '%s', expected e.g. 366d, 12m, 1y", interval)
+	}
+
+	amount, _ := strconv.Atoi(matches[1])
+	switch matches[2] {
+	case "h":
+		return t.Add(time.Duration(amount) * time.Hour), nil
+	case "d":
+		return t.AddDate(0, 0, amount), nil
+	case "w":
+		return t.AddDate(0, 0, amount*7), nil
+	case "m":
+		return t.AddDate(0, amount, 0), nil
+	default:
+		return t.AddDate(amount, 0, 0), nil
+	}
+}
+
+// compareTyped compara dois valores convertidos por coerceParam
+func compareTyped(a, b interface{}) (int, bool) {
+	if ta, ok := a.(time.Time); ok {
+		tb, ok := b.(time.Time)
+		if !ok {
+			return 0, false
+		}
+		return ta.Compare(tb), true
+	}
+
+	ra, okA := toRat(a)
+	rb, okB := toRat(b)
+	if okA && okB {
+		return ra.Cmp(rb), true
+	}
+
+	sa, okA := a.(string)
+	sb, okB := b.(string)
+	if okA && okB {
+		return strings.Compare(sa, sb), true
+	}
+
+	return 0, false
+}
+
+func quoted(names []string) []string {
+	result := make([]string, len(names))
+	for i, name := range names {
+		result[i] = "'" + name + "'"
+	}
+	return result
+}
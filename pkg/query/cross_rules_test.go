@@ -0,0 +1,66 @@
+package query
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"reports-system/internal/domain/entities"
+)
+
+func TestValidateCrossRules(t *testing.T) {
+	parameters := []entities.ParamConfig{
+		{Name: "start", Type: "date", Default: "2024-01-01"},
+		{Name: "end", Type: "date"},
+		{Name: "customer", Type: "string", Default: "all"},
+		{Name: "customer_group", Type: "string"},
+		{Name: "min", Type: "int"},
+		{Name: "max", Type: "int"},
+	}
+	rules := []entities.CrossParamRule{
+		{Type: "order", Params: []string{"start", "end"}},
+		{Type: "max_interval", Params: []string{"start", "end"}, Max: "90d"},
+		{Type: "mutually_exclusive", Params: []string{"customer", "customer_group"}},
+		{Type: "required_together", Params: []string{"start", "end"}},
+		{Type: "required_together", Params: []string{"min", "max"}},
+	}
+
+	tests := []struct {
+		name   string
+		params map[string]interface{}
+		errors []string
+	}{
+		{"nothing informed", map[string]interface{}{}, nil},
+		{"valid range", map[string]interface{}{"start": "2024-02-01", "end": "2024-03-01"}, nil},
+		{"range out of order", map[string]interface{}{"start": "2024-03-01", "end": "2024-02-01"}, []string{"order"}},
+		{"interval too long", map[string]interface{}{"start": "2024-01-01", "end": "2024-06-01"}, []string{"max_interval"}},
+		{"order checks defaulted start", map[string]interface{}{"end": "2023-12-01"}, []string{"order", "required_together"}},
+		{"defaulted param is not exclusive", map[string]interface{}{"customer_group": "vip"}, nil},
+		{"informed params are exclusive", map[string]interface{}{"customer": "a", "customer_group": "vip"}, []string{"mutually_exclusive"}},
+		{"defaulted param is not together", map[string]interface{}{"end": "2024-01-15"}, []string{"required_together"}},
+		{"empty value is not informed", map[string]interface{}{"customer": "", "customer_group": "vip"}, nil},
+		{"informed together", map[string]interface{}{"min": "1", "max": "10"}, nil},
+		{"informed alone", map[string]interface{}{"min": "1"}, []string{"required_together"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewConfigQuery(&entities.QueryConfig{Parameters: parameters, Rules: rules})
+			err := q.Validate(tt.params)
+
+			var rulesFailed []string
+			var validationErr *entities.ValidationError
+			if errors.As(err, &validationErr) {
+				for _, fieldErr := range validationErr.Errors {
+					rulesFailed = append(rulesFailed, fieldErr.Rule)
+				}
+			} else if err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+
+			if strings.Join(rulesFailed, ",") != strings.Join(tt.errors, ",") {
+				t.Errorf("failed rules = %v, want %v (err: %v)", rulesFailed, tt.errors, err)
+			}
+		})
+	}
+}
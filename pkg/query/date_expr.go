@@ -0,0 +1,199 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expressões de data relativas usadas em defaults e limites min/max:
+//
+//	now()                     instante atual
+//	now(-30d)                 deslocamentos: min, h, d, bd (dias úteis), w, m, y
+//	now(-1m+2d)               deslocamentos podem ser combinados
+//	today(-1d)                início do dia, com deslocamento opcional
+//	startOfMonth(now(-1m))    start/endOf + Day, Week, Month, Quarter, Year
+//
+// As expressões são avaliadas no fuso configurado no relatório.
+type dateExpr func(now time.Time) time.Time
+
+var (
+	dateExprPattern   = regexp.MustCompile(`^([A-Za-z]+)\((.*)\)$`)
+	dateOffsetPattern = regexp.MustCompile(`([+-])\s*(\d+)\s*(min|bd|h|d|w|m|y)`)
+)
+
+type dateOffset struct {
+	amount int
+	unit   string
+}
+
+// isDateExpression indica se o texto tem a forma de uma expressão (nome(...))
+func isDateExpression(str string) bool {
+	return dateExprPattern.MatchString(strings.TrimSpace(str))
+}
+
+func parseDateExpression(expr string) (dateExpr, error) {
+	matches := dateExprPattern.FindStringSubmatch(strings.TrimSpace(expr))
+	if matches == nil {
+		return nil, fmt.Errorf("invalid date expression '%s'", expr)
+	}
+
+	name, inner := matches[1], strings.TrimSpace(matches[2])
+
+	switch name {
+	case "now", "today":
+		offsets, err := parseDateOffsets(inner)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date expression '%s': %w", expr, err)
+		}
+		return func(now time.Time) time.Time {
+			result := applyDateOffsets(now, offsets)
+			if name == "today" {
+				result = startOfDay(result)
+			}
+			return result
+		}, nil
+	}
+
+	boundary, ok := dateBoundaries[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown date function '%s' in '%s'", name, expr)
+	}
+
+	base := func(now time.Time) time.Time { return now }
+	if inner != "" {
+		nested, err := parseDateExpression(inner)
+		if err != nil {
+			return nil, err
+		}
+		base = nested
+	}
+
+	return func(now time.Time) time.Time {
+		return boundary(base(now))
+	}, nil
+}
+
+func parseDateOffsets(inner string) ([]dateOffset, error) {
+	if inner == "" {
+		return nil, nil
+	}
+
+	original := inner
+
+	// Um deslocamento sem sinal é tratado como positivo (now(7d))
+	if inner[0] != '+' && inner[0] != '-' {
+		inner = "+" + inner
+	}
+
+	var offsets []dateOffset
+	consumed := 0
+	for _, match := range dateOffsetPattern.FindAllStringSubmatchIndex(inner, -1) {
+		if strings.TrimSpace(inner[consumed:match[0]]) != "" {
+			return nil, fmt.Errorf("invalid offset '%s'", original)
+		}
+
+		amount, _ := strconv.Atoi(inner[match[4]:match[5]])
+		if inner[match[2]:match[3]] == "-" {
+			amount = -amount
+		}
+		offsets = append(offsets, dateOffset{amount: amount, unit: inner[match[6]:match[7]]})
+		consumed = match[1]
+	}
+
+	if len(offsets) == 0 || strings.TrimSpace(inner[consumed:]) != "" {
+		return nil, fmt.Errorf("invalid offset '%s'", original)
+	}
+
+	return offsets, nil
+}
+
+func applyDateOffsets(t time.Time, offsets []dateOffset) time.Time {
+	for _, offset := range offsets {
+		switch offset.unit {
+		case "min":
+			t = t.Add(time.Duration(offset.amount) * time.Minute)
+		case "h":
+			t = t.Add(time.Duration(offset.amount) * time.Hour)
+		case "d":
+			t = t.AddDate(0, 0, offset.amount)
+		case "bd":
+			t = addBusinessDays(t, offset.amount)
+		case "w":
+			t = t.AddDate(0, 0, 7*offset.amount)
+		case "m":
+			t = addMonths(t, offset.amount)
+		case "y":
+			t = addMonths(t, 12*offset.amount)
+		}
+	}
+	return t
+}
+
+// addMonths desloca t em meses mantendo o dia dentro do mês de destino
+// (31/03 -1m = 29/02), ao contrário de AddDate, que avança para o mês seguinte
+func addMonths(t time.Time, amount int) time.Time {
+	first := time.Date(t.Year(), t.Month(), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	target := first.AddDate(0, amount, 0)
+	lastDay := target.AddDate(0, 1, -1).Day()
+	return target.AddDate(0, 0, min(t.Day(), lastDay)-1)
+}
+
+// addBusinessDays desloca t em dias úteis (segunda a sexta)
+func addBusinessDays(t time.Time, amount int) time.Time {
+	step := 1
+	if amount < 0 {
+		step, amount = -1, -amount
+	}
+
+	for amount > 0 {
+		t = t.AddDate(0, 0, step)
+		if t.Weekday() != time.Saturday && t.Weekday() != time.Sunday {
+			amount--
+		}
+	}
+	return t
+}
+
+var dateBoundaries = map[string]func(time.Time) time.Time{
+	"startOfDay":     startOfDay,
+	"endOfDay":       func(t time.Time) time.Time { return endOf(startOfDay(t).AddDate(0, 0, 1)) },
+	"startOfWeek":    startOfWeek,
+	"endOfWeek":      func(t time.Time) time.Time { return endOf(startOfWeek(t).AddDate(0, 0, 7)) },
+	"startOfMonth":   startOfMonth,
+	"endOfMonth":     func(t time.Time) time.Time { return endOf(startOfMonth(t).AddDate(0, 1, 0)) },
+	"startOfQuarter": startOfQuarter,
+	"endOfQuarter":   func(t time.Time) time.Time { return endOf(startOfQuarter(t).AddDate(0, 3, 0)) },
+	"startOfYear":    startOfYear,
+	"endOfYear":      func(t time.Time) time.Time { return endOf(startOfYear(t).AddDate(1, 0, 0)) },
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// startOfWeek considera a segunda-feira como primeiro dia da semana
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return startOfDay(t).AddDate(0, 0, -offset)
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+func startOfQuarter(t time.Time) time.Time {
+	month := time.Month((int(t.Month())-1)/3*3 + 1)
+	return time.Date(t.Year(), month, 1, 0, 0, 0, 0, t.Location())
+}
+
+func startOfYear(t time.Time) time.Time {
+	return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+}
+
+// endOf retorna o último segundo antes do início do próximo período
+func endOf(nextStart time.Time) time.Time {
+	return nextStart.Add(-time.Second)
+}
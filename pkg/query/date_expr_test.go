@@ -0,0 +1,173 @@
+package query
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDateExpression(t *testing.T) {
+	// Sexta-feira
+	now := time.Date(2024, time.March, 15, 10, 30, 0, 0, time.UTC)
+	at := func(year int, month time.Month, day, hour, min, sec int) time.Time {
+		return time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+	}
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"now()", now},
+		{"now(-30d)", at(2024, time.February, 14, 10, 30, 0)},
+		{"now(7d)", at(2024, time.March, 22, 10, 30, 0)},
+		{"now(+2h)", at(2024, time.March, 15, 12, 30, 0)},
+		{"now(-15min)", at(2024, time.March, 15, 10, 15, 0)},
+		{"now(1w)", at(2024, time.March, 22, 10, 30, 0)},
+		{"now(-1y)", at(2023, time.March, 15, 10, 30, 0)},
+		{"now(-1m+2d)", at(2024, time.February, 17, 10, 30, 0)},
+		{"now( - 1 d )", at(2024, time.March, 14, 10, 30, 0)},
+		{"now(1bd)", at(2024, time.March, 18, 10, 30, 0)},
+		{"now(-1bd)", at(2024, time.March, 14, 10, 30, 0)},
+		{"now(-5bd)", at(2024, time.March, 8, 10, 30, 0)},
+		{"today()", at(2024, time.March, 15, 0, 0, 0)},
+		{"today(-1d)", at(2024, time.March, 14, 0, 0, 0)},
+		{"startOfDay()", at(2024, time.March, 15, 0, 0, 0)},
+		{"endOfDay()", at(2024, time.March, 15, 23, 59, 59)},
+		{"startOfWeek()", at(2024, time.March, 11, 0, 0, 0)},
+		{"startOfWeek(now(2d))", at(2024, time.March, 11, 0, 0, 0)},
+		{"endOfWeek()", at(2024, time.March, 17, 23, 59, 59)},
+		{"startOfMonth()", at(2024, time.March, 1, 0, 0, 0)},
+		{"endOfMonth()", at(2024, time.March, 31, 23, 59, 59)},
+		{"startOfMonth(now(-1m))", at(2024, time.February, 1, 0, 0, 0)},
+		{"endOfMonth(now(-1m))", at(2024, time.February, 29, 23, 59, 59)},
+		{"startOfQuarter()", at(2024, time.January, 1, 0, 0, 0)},
+		{"endOfQuarter()", at(2024, time.March, 31, 23, 59, 59)},
+		{"startOfYear()", at(2024, time.January, 1, 0, 0, 0)},
+		{"endOfYear()", at(2024, time.December, 31, 23, 59, 59)},
+		{"startOfMonth(today(-1y))", at(2023, time.March, 1, 0, 0, 0)},
+		{"  today()  ", at(2024, time.March, 15, 0, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			evaluate, err := parseDateExpression(tt.expr)
+			if err != nil {
+				t.Fatalf("parseDateExpression: %v", err)
+			}
+			if got := evaluate(now); !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateExpressionMonthEnd(t *testing.T) {
+	at := func(year int, month time.Month, day, hour, min, sec int) time.Time {
+		return time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+	}
+	march31 := at(2026, time.March, 31, 10, 30, 0)
+	leapDay := at(2024, time.February, 29, 10, 30, 0)
+
+	tests := []struct {
+		name string
+		now  time.Time
+		expr string
+		want time.Time
+	}{
+		{"previous month", march31, "now(-1m)", at(2026, time.February, 28, 10, 30, 0)},
+		{"start of previous month", march31, "startOfMonth(now(-1m))", at(2026, time.February, 1, 0, 0, 0)},
+		{"end of previous month", march31, "endOfMonth(now(-1m))", at(2026, time.February, 28, 23, 59, 59)},
+		{"previous month with 30 days", at(2026, time.May, 31, 0, 0, 0), "today(-1m)", at(2026, time.April, 30, 0, 0, 0)},
+		{"eleven months back", march31, "now(-11m)", at(2025, time.April, 30, 10, 30, 0)},
+		{"next month", at(2026, time.January, 31, 0, 0, 0), "today(1m)", at(2026, time.February, 28, 0, 0, 0)},
+		{"non-leap february", at(2023, time.March, 31, 0, 0, 0), "today(-1m)", at(2023, time.February, 28, 0, 0, 0)},
+		{"leap day previous year", leapDay, "now(-1y)", at(2023, time.February, 28, 10, 30, 0)},
+		{"leap day next year", leapDay, "now(1y)", at(2025, time.February, 28, 10, 30, 0)},
+		{"leap day four years", leapDay, "now(4y)", at(2028, time.February, 29, 10, 30, 0)},
+		{"end of month previous year", leapDay, "endOfMonth(now(-1y))", at(2023, time.February, 28, 23, 59, 59)},
+		{"month then days", march31, "now(-1m+1d)", at(2026, time.March, 1, 10, 30, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluate, err := parseDateExpression(tt.expr)
+			if err != nil {
+				t.Fatalf("parseDateExpression: %v", err)
+			}
+			if got := evaluate(tt.now); !got.Equal(tt.want) {
+				t.Errorf("%s = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateExpressionErrors(t *testing.T) {
+	tests := []string{
+		"now",
+		"now(abc)",
+		"now(-1x)",
+		"now(1d 2d)",
+		"now(-)",
+		"now(-1d)x",
+		"yesterday()",
+		"startOfMonth(bogus)",
+		"startOfMonth(now(-1q))",
+	}
+
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := parseDateExpression(expr); err == nil {
+				t.Errorf("parseDateExpression(%q) succeeded", expr)
+			}
+		})
+	}
+}
+
+func TestDateExpressionLocation(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+
+	// 20h em UTC já é o dia seguinte em Tóquio
+	now := time.Date(2024, time.March, 15, 20, 0, 0, 0, time.UTC).In(tokyo)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"today()", time.Date(2024, time.March, 16, 0, 0, 0, 0, tokyo)},
+		{"startOfMonth()", time.Date(2024, time.March, 1, 0, 0, 0, 0, tokyo)},
+		{"endOfDay()", time.Date(2024, time.March, 16, 23, 59, 59, 0, tokyo)},
+	}
+
+	for _, tt := range tests {
+		evaluate, err := parseDateExpression(tt.expr)
+		if err != nil {
+			t.Fatalf("parseDateExpression(%q): %v", tt.expr, err)
+		}
+		got := evaluate(now)
+		if !got.Equal(tt.want) || got.Location() != tokyo {
+			t.Errorf("%s = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestIsDateExpression(t *testing.T) {
+	tests := []struct {
+		str  string
+		want bool
+	}{
+		{"now()", true},
+		{" today(-1d) ", true},
+		{"startOfMonth(now(-1m))", true},
+		{"2024-01-01", false},
+		{"now", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := isDateExpression(tt.str); got != tt.want {
+			t.Errorf("isDateExpression(%q) = %v, want %v", tt.str, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,856 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Linguagem de expressões das colunas calculadas (output.computed). É
+// propositalmente restrita: apenas literais, colunas da linha, parâmetros
+// (@nome), operadores e as funções de exprFunctions — sem laços, atribuições
+// ou acesso a arquivos e rede.
+//
+//	revenue - cost
+//	first_name + " " + last_name
+//	if(total > 1000, "alto", "baixo")
+//	round(margin / revenue * 100, 2)
+//	@status == "shipped" && qty >= 10
+//
+// Números são exatos (big.Rat). Operações com null resultam em null e divisão
+// por zero resulta em null.
+
+const (
+	maxExpressionLength = 1000
+	maxExpressionDepth  = 50
+)
+
+type exprNode interface {
+	eval(env *exprEnv) (interface{}, error)
+}
+
+// exprEnv fornece os valores da linha (por coluna) e dos parâmetros
+type exprEnv struct {
+	columns map[string]interface{}
+	params  map[string]interface{}
+}
+
+// Expression é uma expressão compilada
+type Expression struct {
+	source  string
+	root    exprNode
+	columns []string
+}
+
+// CompileExpression valida a sintaxe, as funções e os parâmetros
+// referenciados (declared) da expressão
+func CompileExpression(source string, declared map[string]bool) (*Expression, error) {
+	if len(source) > maxExpressionLength {
+		return nil, fmt.Errorf("expression exceeds %d characters", maxExpressionLength)
+	}
+
+	tokens, err := lexExpression(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{tokens: tokens, declared: declared}
+	root, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected '%s' at position %d", p.peek().text, p.peek().pos)
+	}
+
+	return &Expression{source: source, root: root, columns: p.columns}, nil
+}
+
+// Columns retorna as colunas referenciadas pela expressão, sem repetição
+func (e *Expression) Columns() []string {
+	return e.columns
+}
+
+// Eval avalia a expressão para uma linha
+func (e *Expression) Eval(columns, params map[string]interface{}) (interface{}, error) {
+	value, err := e.root.eval(&exprEnv{columns: columns, params: params})
+	if err != nil {
+		return nil, err
+	}
+	if r, ok := value.(*big.Rat); ok {
+		return ratNumber(r), nil
+	}
+	return value, nil
+}
+
+// ratNumber converte o resultado numérico para json.Number, com no máximo 10
+// casas decimais quando a fração não tem representação finita
+func ratNumber(r *big.Rat) json.Number {
+	if r.IsInt() {
+		return json.Number(r.FloatString(0))
+	}
+	if prec, exact := r.FloatPrec(); exact {
+		return json.Number(r.FloatString(prec))
+	}
+	text := strings.TrimRight(r.FloatString(10), "0")
+	return json.Number(strings.TrimSuffix(text, "."))
+}
+
+// Lexer
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenParam
+	tokenOperator
+)
+
+type exprToken struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+var exprOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "+", "-", "*", "/", "%", "<", ">", "!", "(", ")", ",", "?", ":"}
+
+func lexExpression(source string) ([]exprToken, error) {
+	var tokens []exprToken
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: tokenNumber, text: string(runes[start:i]), pos: start})
+		case r == '"' || r == '\'':
+			start := i
+			var text strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				text.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			tokens = append(tokens, exprToken{kind: tokenString, text: text.String(), pos: start})
+		case r == '@' || r == '_' || unicode.IsLetter(r):
+			start := i
+			kind := tokenIdent
+			if r == '@' {
+				kind = tokenParam
+				i++
+			}
+			nameStart := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			if i == nameStart {
+				return nil, fmt.Errorf("expected parameter name at position %d", start)
+			}
+			tokens = append(tokens, exprToken{kind: kind, text: string(runes[nameStart:i]), pos: start})
+		default:
+			matched := false
+			for _, op := range exprOperators {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					tokens = append(tokens, exprToken{kind: tokenOperator, text: op, pos: i})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character '%c' at position %d", r, i)
+			}
+		}
+	}
+
+	return append(tokens, exprToken{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+// Parser (descida recursiva, da menor para a maior precedência)
+
+type exprParser struct {
+	tokens   []exprToken
+	pos      int
+	depth    int
+	declared map[string]bool
+	columns  []string
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	token := p.tokens[p.pos]
+	if token.kind != tokenEOF {
+		p.pos++
+	}
+	return token
+}
+
+func (p *exprParser) accept(ops ...string) (string, bool) {
+	token := p.peek()
+	if token.kind != tokenOperator {
+		return "", false
+	}
+	for _, op := range ops {
+		if token.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		token := p.peek()
+		if token.kind == tokenEOF {
+			return fmt.Errorf("expected '%s' at end of expression", op)
+		}
+		return fmt.Errorf("expected '%s' at position %d", op, token.pos)
+	}
+	return nil
+}
+
+func (p *exprParser) parseTernary() (exprNode, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxExpressionDepth {
+		return nil, fmt.Errorf("expression is nested too deeply")
+	}
+
+	cond, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept("?"); !ok {
+		return cond, nil
+	}
+
+	then, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	return &conditionalNode{cond: cond, then: then, otherwise: otherwise}, nil
+}
+
+// Níveis de precedência dos operadores binários
+var binaryLevels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *exprParser) parseBinary(level int) (exprNode, error) {
+	if level == len(binaryLevels) {
+		return p.parseUnary()
+	}
+
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(binaryLevels[level]...)
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if op, ok := p.accept("!", "-"); ok {
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > maxExpressionDepth {
+			return nil, fmt.Errorf("expression is nested too deeply")
+		}
+
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	token := p.next()
+
+	switch token.kind {
+	case tokenNumber:
+		r, ok := new(big.Rat).SetString(token.text)
+		if !ok {
+			return nil, fmt.Errorf("invalid number '%s' at position %d", token.text, token.pos)
+		}
+		return &literalNode{value: r}, nil
+	case tokenString:
+		return &literalNode{value: token.text}, nil
+	case tokenParam:
+		if p.declared != nil && !p.declared[token.text] {
+			return nil, fmt.Errorf("undeclared parameter '@%s'", token.text)
+		}
+		return &paramNode{name: token.text}, nil
+	case tokenIdent:
+		switch token.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+		if _, ok := p.accept("("); ok {
+			return p.parseCall(token)
+		}
+		if !slices.Contains(p.columns, token.text) {
+			p.columns = append(p.columns, token.text)
+		}
+		return &columnNode{name: token.text}, nil
+	case tokenOperator:
+		if token.text == "(" {
+			inner, err := p.parseTernary()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		}
+		return nil, fmt.Errorf("unexpected '%s' at position %d", token.text, token.pos)
+	}
+
+	return nil, fmt.Errorf("unexpected end of expression")
+}
+
+func (p *exprParser) parseCall(name exprToken) (exprNode, error) {
+	fn, ok := exprFunctions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function '%s' at position %d", name.text, name.pos)
+	}
+
+	var args []exprNode
+	if _, ok := p.accept(")"); !ok {
+		for {
+			arg, err := p.parseTernary()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if _, ok := p.accept(","); ok {
+				continue
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return nil, fmt.Errorf("function '%s' called with %d arguments", name.text, len(args))
+	}
+
+	return &callNode{name: name.text, fn: fn, args: args}, nil
+}
+
+// Nós da árvore
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(*exprEnv) (interface{}, error) {
+	return n.value, nil
+}
+
+type columnNode struct {
+	name string
+}
+
+func (n *columnNode) eval(env *exprEnv) (interface{}, error) {
+	value, ok := env.columns[n.name]
+	if !ok {
+		return nil, fmt.Errorf("unknown column '%s'", n.name)
+	}
+	return exprValue(value), nil
+}
+
+type paramNode struct {
+	name string
+}
+
+func (n *paramNode) eval(env *exprEnv) (interface{}, error) {
+	return exprValue(env.params[n.name]), nil
+}
+
+type conditionalNode struct {
+	cond, then, otherwise exprNode
+}
+
+func (n *conditionalNode) eval(env *exprEnv) (interface{}, error) {
+	cond, err := n.cond.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if truthy(cond) {
+		return n.then.eval(env)
+	}
+	return n.otherwise.eval(env)
+}
+
+type unaryNode struct {
+	op      string
+	operand exprNode
+}
+
+func (n *unaryNode) eval(env *exprEnv) (interface{}, error) {
+	value, err := n.operand.eval(env)
+	if err != nil || value == nil {
+		return nil, err
+	}
+
+	if n.op == "!" {
+		return !truthy(value), nil
+	}
+	r, err := toNumber(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Rat).Neg(r), nil
+}
+
+type binaryNode struct {
+	op          string
+	left, right exprNode
+}
+
+func (n *binaryNode) eval(env *exprEnv) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	// && e || avaliam o lado direito apenas quando necessário
+	switch n.op {
+	case "&&":
+		if !truthy(left) {
+			return false, nil
+		}
+		right, err := n.right.eval(env)
+		return truthy(right), err
+	case "||":
+		if truthy(left) {
+			return true, nil
+		}
+		right, err := n.right.eval(env)
+		return truthy(right), err
+	}
+
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==", "!=":
+		equal := exprEqual(left, right)
+		return equal == (n.op == "=="), nil
+	case "<", "<=", ">", ">=":
+		if left == nil || right == nil {
+			return false, nil
+		}
+		cmp, ok := compareTyped(left, right)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare %s and %s", typeName(left), typeName(right))
+		}
+		switch n.op {
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		default:
+			return cmp >= 0, nil
+		}
+	}
+
+	if left == nil || right == nil {
+		return nil, nil
+	}
+
+	// + concatena quando um dos lados é texto
+	if n.op == "+" {
+		_, leftText := left.(string)
+		_, rightText := right.(string)
+		if leftText || rightText {
+			return exprText(left) + exprText(right), nil
+		}
+	}
+
+	a, err := toNumber(left)
+	if err != nil {
+		return nil, err
+	}
+	b, err := toNumber(right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "+":
+		return new(big.Rat).Add(a, b), nil
+	case "-":
+		return new(big.Rat).Sub(a, b), nil
+	case "*":
+		return new(big.Rat).Mul(a, b), nil
+	case "/":
+		if b.Sign() == 0 {
+			return nil, nil
+		}
+		return new(big.Rat).Quo(a, b), nil
+	default:
+		if b.Sign() == 0 {
+			return nil, nil
+		}
+		if !a.IsInt() || !b.IsInt() {
+			return nil, fmt.Errorf("'%%' requires integer operands")
+		}
+		return new(big.Rat).SetInt(new(big.Int).Rem(a.Num(), b.Num())), nil
+	}
+}
+
+type callNode struct {
+	name string
+	fn   exprFunction
+	args []exprNode
+}
+
+func (n *callNode) eval(env *exprEnv) (interface{}, error) {
+	// if() avalia apenas o ramo escolhido
+	if n.name == "if" {
+		return (&conditionalNode{cond: n.args[0], then: n.args[1], otherwise: n.args[2]}).eval(env)
+	}
+
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		value, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+
+	result, err := n.fn.call(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", n.name, err)
+	}
+	return result, nil
+}
+
+// Funções disponíveis nas expressões
+
+type exprFunction struct {
+	minArgs int
+	maxArgs int // -1: sem limite
+	call    func(args []interface{}) (interface{}, error)
+}
+
+var exprFunctions map[string]exprFunction
+
+func init() {
+	exprFunctions = map[string]exprFunction{
+		"if":       {minArgs: 3, maxArgs: 3},
+		"upper":    {1, 1, textFunction(strings.ToUpper)},
+		"lower":    {1, 1, textFunction(strings.ToLower)},
+		"trim":     {1, 1, textFunction(strings.TrimSpace)},
+		"length":   {1, 1, exprLength},
+		"concat":   {1, -1, exprConcat},
+		"coalesce": {1, -1, exprCoalesce},
+		"substr":   {2, 3, exprSubstr},
+		"round":    {1, 2, exprRound},
+		"abs":      {1, 1, exprAbs},
+		"min":      {1, -1, func(args []interface{}) (interface{}, error) { return exprExtreme(args, -1) }},
+		"max":      {1, -1, func(args []interface{}) (interface{}, error) { return exprExtreme(args, 1) }},
+		"year":     {1, 1, dateFunction(func(t time.Time) int { return t.Year() })},
+		"month":    {1, 1, dateFunction(func(t time.Time) int { return int(t.Month()) })},
+		"day":      {1, 1, dateFunction(func(t time.Time) int { return t.Day() })},
+	}
+}
+
+func textFunction(fn func(string) string) func([]interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		return fn(exprText(args[0])), nil
+	}
+}
+
+func dateFunction(fn func(time.Time) int) func([]interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		t, ok := resultTime(args[0])
+		if !ok {
+			return nil, fmt.Errorf("expected a date, got %s", typeName(args[0]))
+		}
+		return big.NewRat(int64(fn(t)), 1), nil
+	}
+}
+
+func exprLength(args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+	return big.NewRat(int64(len([]rune(exprText(args[0])))), 1), nil
+}
+
+func exprConcat(args []interface{}) (interface{}, error) {
+	var text strings.Builder
+	for _, arg := range args {
+		if arg != nil {
+			text.WriteString(exprText(arg))
+		}
+	}
+	return text.String(), nil
+}
+
+func exprCoalesce(args []interface{}) (interface{}, error) {
+	for _, arg := range args {
+		if arg != nil {
+			return arg, nil
+		}
+	}
+	return nil, nil
+}
+
+// exprSubstr segue o SQL: início a partir de 1 e tamanho opcional
+func exprSubstr(args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+	runes := []rune(exprText(args[0]))
+
+	start, err := exprInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	start = max(start-1, 0)
+	if start > len(runes) {
+		return "", nil
+	}
+
+	end := len(runes)
+	if len(args) == 3 {
+		length, err := exprInt(args[2])
+		if err != nil {
+			return nil, err
+		}
+		end = min(start+max(length, 0), len(runes))
+	}
+	return string(runes[start:end]), nil
+}
+
+func exprRound(args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+	r, err := toNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	places := 0
+	if len(args) == 2 {
+		if places, err = exprInt(args[1]); err != nil {
+			return nil, err
+		}
+	}
+	if places < 0 || places > 18 {
+		return nil, fmt.Errorf("places must be between 0 and 18")
+	}
+
+	rounded, _ := new(big.Rat).SetString(r.FloatString(places))
+	return rounded, nil
+}
+
+func exprAbs(args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+	r, err := toNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Rat).Abs(r), nil
+}
+
+// exprExtreme retorna o menor (sign -1) ou maior (sign 1) valor, ignorando nulos
+func exprExtreme(args []interface{}, sign int) (interface{}, error) {
+	var result interface{}
+	for _, arg := range args {
+		if arg == nil {
+			continue
+		}
+		if result == nil {
+			result = arg
+			continue
+		}
+		cmp, ok := compareTyped(arg, result)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare %s and %s", typeName(arg), typeName(result))
+		}
+		if cmp*sign > 0 {
+			result = arg
+		}
+	}
+	return result, nil
+}
+
+// Conversões
+
+// exprValue converte os valores da linha/parâmetros para os tipos da
+// linguagem: nil, bool, string, *big.Rat e time.Time
+func exprValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, bool, string, time.Time, *big.Rat:
+		return v
+	case []byte:
+		return string(v)
+	case int32:
+		return big.NewRat(int64(v), 1)
+	case float32:
+		return exprFloat(float64(v), 32)
+	case float64:
+		return exprFloat(v, 64)
+	case int, int64, json.Number:
+		if r, ok := toRat(v); ok {
+			return r
+		}
+	}
+	return exprText(value)
+}
+
+// exprFloat usa a representação decimal mais curta do float (0.1, e não a
+// fração binária exata)
+func exprFloat(value float64, bits int) interface{} {
+	if r, ok := new(big.Rat).SetString(strconv.FormatFloat(value, 'f', -1, bits)); ok {
+		return r
+	}
+	return nil
+}
+
+func toNumber(value interface{}) (*big.Rat, error) {
+	switch v := value.(type) {
+	case *big.Rat:
+		return v, nil
+	case string:
+		if r, ok := new(big.Rat).SetString(strings.TrimSpace(v)); ok {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("expected a number, got %s", typeName(value))
+}
+
+func exprInt(value interface{}) (int, error) {
+	r, err := toNumber(value)
+	if err != nil {
+		return 0, err
+	}
+	if !r.IsInt() || !r.Num().IsInt64() {
+		return 0, fmt.Errorf("expected an integer")
+	}
+	return int(r.Num().Int64()), nil
+}
+
+func exprText(value interface{}) string {
+	if r, ok := value.(*big.Rat); ok {
+		return string(ratNumber(r))
+	}
+	return FormatDefault(value)
+}
+
+func exprEqual(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if cmp, ok := compareTyped(a, b); ok {
+		return cmp == 0
+	}
+	if ba, ok := a.(bool); ok {
+		bb, ok := b.(bool)
+		return ok && ba == bb
+	}
+	return false
+}
+
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case *big.Rat:
+		return v.Sign() != 0
+	}
+	return true
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "text"
+	case *big.Rat:
+		return "number"
+	case time.Time:
+		return "date"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,290 @@
+package query
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLexExpression(t *testing.T) {
+	tokens, err := lexExpression(`a_1 >= .5 && @p != "x\"y" || !'z'`)
+	if err != nil {
+		t.Fatalf("lexExpression: %v", err)
+	}
+
+	want := []exprToken{
+		{kind: tokenIdent, text: "a_1", pos: 0},
+		{kind: tokenOperator, text: ">=", pos: 4},
+		{kind: tokenNumber, text: ".5", pos: 7},
+		{kind: tokenOperator, text: "&&", pos: 10},
+		{kind: tokenParam, text: "p", pos: 13},
+		{kind: tokenOperator, text: "!=", pos: 16},
+		{kind: tokenString, text: `x"y`, pos: 19},
+		{kind: tokenOperator, text: "||", pos: 26},
+		{kind: tokenOperator, text: "!", pos: 29},
+		{kind: tokenString, text: "z", pos: 30},
+		{kind: tokenEOF, pos: 33},
+	}
+	if !slices.Equal(tokens, want) {
+		t.Errorf("tokens:\n got %+v\nwant %+v", tokens, want)
+	}
+}
+
+func TestLexExpressionErrors(t *testing.T) {
+	tests := []string{`"abc`, `'abc\'`, `@`, `a # b`, `a = b`, `a & b`}
+
+	for _, source := range tests {
+		if _, err := lexExpression(source); err == nil {
+			t.Errorf("lexExpression(%q) succeeded", source)
+		}
+	}
+}
+
+func TestExpressionEval(t *testing.T) {
+	date := time.Date(2024, time.March, 15, 10, 30, 0, 0, time.UTC)
+	columns := map[string]interface{}{
+		"a":       int64(5),
+		"zero":    int64(0),
+		"x":       0.1,
+		"y":       0.2,
+		"big":     int64(9007199254740993),
+		"dec":     json.Number("1.0"),
+		"empty":   "",
+		"nothing": nil,
+		"first":   "Ana",
+		"last":    []byte("Silva"),
+		"created": date,
+		"qty":     int64(12),
+	}
+	params := map[string]interface{}{"status": "shipped", "limit": int64(10)}
+
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		// Precedência e associatividade
+		{"1 + 2 * 3", json.Number("7")},
+		{"(1 + 2) * 3", json.Number("9")},
+		{"10 - 4 - 3", json.Number("3")},
+		{"2 * 3 % 4", json.Number("2")},
+		{"-2 * 3", json.Number("-6")},
+		{"--2", json.Number("2")},
+		{"!true || true", true},
+		{"true || false && false", true},
+		{"1 < 2 == true", true},
+		{"a > 1 ? 'big' : 'small'", "big"},
+		{"a > 10 ? 'x' : a > 1 ? 'y' : 'z'", "y"},
+
+		// Números exatos
+		{"0.1 + 0.2", json.Number("0.3")},
+		{"x + y", json.Number("0.3")},
+		{"10 / 4", json.Number("2.5")},
+		{"1 / 3", json.Number("0.3333333333")},
+		{"2 / 3", json.Number("0.6666666667")},
+		{"big + 1", json.Number("9007199254740994")},
+		{"dec == 1", true},
+		{"'3' * 2", json.Number("6")},
+		{"'10' == 10", true},
+		{"-7 % 3", json.Number("-1")},
+
+		// Nulos e divisão por zero
+		{"1 / 0", nil},
+		{"a / zero", nil},
+		{"5 % 0", nil},
+		{"nothing + 1", nil},
+		{"-nothing", nil},
+		{"!nothing", nil},
+		{"nothing == null", true},
+		{"nothing != null", false},
+		{"a == null", false},
+		{"nothing > 1", false},
+		{"nothing < 1", false},
+		{"@missing", nil},
+
+		// Texto e booleanos
+		{"first + ' ' + last", "Ana Silva"},
+		{"'n' + 1", "n1"},
+		{"'a' < 'b'", true},
+		{"empty || 0", false},
+		{"first && a", true},
+		{"@status == 'shipped' && qty >= @limit", true},
+		{"false && unknown > 1", false},
+		{"true || unknown > 1", true},
+
+		// Funções
+		{"upper('abc')", "ABC"},
+		{"lower(first)", "ana"},
+		{"trim('  a  ')", "a"},
+		{"upper(nothing)", nil},
+		{"length('ação')", json.Number("4")},
+		{"length(nothing)", nil},
+		{"concat('a', nothing, 1, true)", "a1true"},
+		{"coalesce(nothing, null, 'x')", "x"},
+		{"coalesce(nothing)", nil},
+		{"substr('abcdef', 2, 3)", "bcd"},
+		{"substr('abcdef', 4)", "def"},
+		{"substr('abc', 0)", "abc"},
+		{"substr('abc', 10)", ""},
+		{"substr('abc', 2, -1)", ""},
+		{"substr('ação', 2, 2)", "çã"},
+		{"round(2.345, 2)", json.Number("2.35")},
+		{"round(2.5)", json.Number("3")},
+		{"round(-2.5)", json.Number("-3")},
+		{"round(1 / 3, 4)", json.Number("0.3333")},
+		{"round(nothing, 2)", nil},
+		{"abs(-3.5)", json.Number("3.5")},
+		{"min(3, nothing, 1, 2)", json.Number("1")},
+		{"max('a', 'c', 'b')", "c"},
+		{"max(nothing)", nil},
+		{"year(created)", json.Number("2024")},
+		{"month(created)", json.Number("3")},
+		{"day(created)", json.Number("15")},
+		{"year('2023-12-31')", json.Number("2023")},
+		{"day(nothing)", nil},
+		{"if(a > 1, 'x', 1 / 'y')", "x"},
+		{"if(false, unknown, 2)", json.Number("2")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := CompileExpression(tt.expr, nil)
+			if err != nil {
+				t.Fatalf("CompileExpression: %v", err)
+			}
+			got, err := expr.Eval(columns, params)
+			if err != nil {
+				t.Fatalf("Eval: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Eval = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpressionEvalErrors(t *testing.T) {
+	columns := map[string]interface{}{"a": int64(5), "s": "abc", "d": time.Now()}
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"'a' - 1", "expected a number"},
+		{"-s", "expected a number"},
+		{"s < 1", "cannot compare"},
+		{"d > 1", "cannot compare"},
+		{"5.5 % 2", "integer operands"},
+		{"round(1, 19)", "places must be between"},
+		{"round(1, 0.5)", "expected an integer"},
+		{"substr(s, 'x')", "expected a number"},
+		{"year(s)", "expected a date"},
+		{"max(1, 'a')", "cannot compare"},
+		{"missing + 1", "unknown column 'missing'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := CompileExpression(tt.expr, nil)
+			if err != nil {
+				t.Fatalf("CompileExpression: %v", err)
+			}
+			_, err = expr.Eval(columns, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Eval error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileExpressionErrors(t *testing.T) {
+	declared := map[string]bool{"status": true}
+
+	tests := []struct {
+		name string
+		expr string
+		want string
+	}{
+		{"empty", "", "unexpected end"},
+		{"missing operand", "1 +", "unexpected end"},
+		{"unclosed paren", "(1", "expected ')'"},
+		{"extra token", "1 2", "unexpected '2'"},
+		{"stray operator", ")", "unexpected ')'"},
+		{"incomplete ternary", "1 ? 2", "expected ':'"},
+		{"invalid number", "1..2", "invalid number"},
+		{"unknown function", "foo(1)", "unknown function 'foo'"},
+		{"too few args", "upper()", "called with 0 arguments"},
+		{"too many args", "upper(1, 2)", "called with 2 arguments"},
+		{"if arity", "if(1, 2)", "called with 2 arguments"},
+		{"undeclared param", "@other", "undeclared parameter '@other'"},
+		{"nested parens", strings.Repeat("(", maxExpressionDepth) + "1" + strings.Repeat(")", maxExpressionDepth), "nested too deeply"},
+		{"nested unary", strings.Repeat("-", maxExpressionDepth) + "1", "nested too deeply"},
+		{"nested calls", strings.Repeat("abs(", maxExpressionDepth) + "1" + strings.Repeat(")", maxExpressionDepth), "nested too deeply"},
+		{"too long", "1" + strings.Repeat(" ", maxExpressionLength), "exceeds"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CompileExpression(tt.expr, declared)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("CompileExpression error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileExpressionLimits(t *testing.T) {
+	depth := maxExpressionDepth - 1
+	tests := []string{
+		strings.Repeat("(", depth) + "1" + strings.Repeat(")", depth),
+		strings.Repeat("-", depth) + "1",
+		"1" + strings.Repeat(" ", maxExpressionLength-1),
+		// Operadores em sequência não aumentam a profundidade
+		"1" + strings.Repeat(" + 1", 200),
+	}
+
+	for _, source := range tests {
+		if _, err := CompileExpression(source, nil); err != nil {
+			t.Errorf("CompileExpression(%.20q...): %v", source, err)
+		}
+	}
+}
+
+func TestExpressionColumns(t *testing.T) {
+	expr, err := CompileExpression("a + b * a + @p + upper(c) + if(true, d, 'e')", map[string]bool{"p": true})
+	if err != nil {
+		t.Fatalf("CompileExpression: %v", err)
+	}
+
+	want := []string{"a", "b", "c", "d"}
+	if got := expr.Columns(); !slices.Equal(got, want) {
+		t.Errorf("Columns = %v, want %v", got, want)
+	}
+}
+
+func TestRatNumber(t *testing.T) {
+	tests := []struct {
+		expr string
+		want json.Number
+	}{
+		{"4 / 2", "2"},
+		{"1 / 8", "0.125"},
+		{"1 / 3", "0.3333333333"},
+		{"1 / 7", "0.1428571429"},
+		{"1 / 1024", "0.0009765625"},
+		{"1 / 30000000000", "0"},
+		{"-1 / 3", "-0.3333333333"},
+	}
+
+	for _, tt := range tests {
+		expr, err := CompileExpression(tt.expr, nil)
+		if err != nil {
+			t.Fatalf("CompileExpression(%q): %v", tt.expr, err)
+		}
+		got, _ := expr.Eval(nil, nil)
+		if got != tt.want {
+			t.Errorf("%s = %#v, want %#v", tt.expr, got, tt.want)
+		}
+	}
+}
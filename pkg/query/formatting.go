@@ -0,0 +1,305 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"reports-system/internal/domain/entities"
+)
+
+const defaultLocale = "en-US"
+
+// localeFormat define separadores e a posição do símbolo monetário
+type localeFormat struct {
+	group         string
+	decimal       string
+	symbolFirst   bool
+	symbolSpacing bool
+}
+
+var locales = map[string]localeFormat{
+	"en-US": {group: ",", decimal: ".", symbolFirst: true},
+	"en-GB": {group: ",", decimal: ".", symbolFirst: true},
+	"pt-BR": {group: ".", decimal: ",", symbolFirst: true, symbolSpacing: true},
+	"pt-PT": {group: " ", decimal: ",", symbolSpacing: true},
+	"es-ES": {group: ".", decimal: ",", symbolSpacing: true},
+	"de-DE": {group: ".", decimal: ",", symbolSpacing: true},
+	"fr-FR": {group: " ", decimal: ",", symbolSpacing: true},
+}
+
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"BRL": "R$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+}
+
+var formatTypes = map[string]bool{
+	"number":   true,
+	"currency": true,
+	"percent":  true,
+	"date":     true,
+	"datetime": true,
+	"bool":     true,
+	"text":     true,
+}
+
+// Layouts aceitos ao ler datas que chegam como texto (ex.: resposta em cache)
+var resultDateLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02", "15:04:05"}
+
+// Formatter gera o texto de exibição dos valores do resultado conforme
+// output.formatting. É usado pelos renderers (CSV, XLSX, PDF).
+type Formatter struct {
+	formats map[string]entities.ColumnFormat
+	locale  string
+}
+
+func NewFormatter(output entities.OutputConfig) *Formatter {
+	locale := output.Locale
+	if locale == "" {
+		locale = defaultLocale
+	}
+	return &Formatter{formats: output.Formatting, locale: locale}
+}
+
+// Format retorna o texto do valor do campo e se alguma regra de formatação
+// foi aplicada. Valores sem regra usam a representação padrão.
+func (f *Formatter) Format(field string, value interface{}) (string, bool) {
+	format, ok := f.formats[field]
+	if !ok {
+		return FormatDefault(value), false
+	}
+
+	if value == nil {
+		return format.Null, true
+	}
+
+	locale := format.Locale
+	if locale == "" {
+		locale = f.locale
+	}
+	lf := locales[locale]
+
+	switch format.Type {
+	case "number":
+		if r, ok := toRat(value); ok {
+			return formatNumber(r, value, format.Precision, -1, lf), true
+		}
+	case "currency":
+		if r, ok := toRat(value); ok {
+			return formatCurrency(r, value, format, lf), true
+		}
+	case "percent":
+		if r, ok := toRat(value); ok {
+			r = new(big.Rat).Mul(r, big.NewRat(100, 1))
+			return formatNumber(r, nil, format.Precision, 2, lf) + "%", true
+		}
+	case "date", "datetime":
+		if t, ok := resultTime(value); ok {
+			layout := dateLayouts[format.Type]
+			if format.Layout != "" {
+				layout = convertDateFormat(format.Layout)
+			}
+			return t.Format(layout), true
+		}
+	case "bool":
+		if b, ok := resultBool(value); ok {
+			if b && format.TrueLabel != "" {
+				return format.TrueLabel, true
+			}
+			if !b && format.FalseLabel != "" {
+				return format.FalseLabel, true
+			}
+			return strconv.FormatBool(b), true
+		}
+	}
+
+	// Tipo sem conversão possível (ou "text"): apenas a troca de nulos se aplica
+	return FormatDefault(value), format.Type != ""
+}
+
+// FormatDefault é a representação em texto de um valor sem formatação
+func FormatDefault(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(data)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func formatCurrency(r *big.Rat, value interface{}, format entities.ColumnFormat, lf localeFormat) string {
+	code := strings.ToUpper(format.Currency)
+	symbol, ok := currencySymbols[code]
+	if !ok {
+		symbol = code
+	}
+
+	defaultPrecision := 2
+	if code == "JPY" {
+		defaultPrecision = 0
+	}
+
+	negative := r.Sign() < 0
+	number := formatNumber(new(big.Rat).Abs(r), nil, format.Precision, defaultPrecision, lf)
+
+	separator := ""
+	if lf.symbolSpacing || !ok {
+		separator = " "
+	}
+
+	var text string
+	switch {
+	case symbol == "":
+		text = number
+	case lf.symbolFirst:
+		text = symbol + separator + number
+	default:
+		text = number + separator + symbol
+	}
+
+	if negative {
+		return "-" + text
+	}
+	return text
+}
+
+// formatNumber formata o número com os separadores do locale. Sem precisão
+// configurada (e com defaultPrecision < 0) os dígitos do valor original são
+// mantidos.
+func formatNumber(r *big.Rat, original interface{}, precision *int, defaultPrecision int, lf localeFormat) string {
+	var digits string
+	switch {
+	case precision != nil:
+		digits = r.FloatString(*precision)
+	case defaultPrecision >= 0:
+		digits = r.FloatString(defaultPrecision)
+	default:
+		digits = originalDigits(r, original)
+	}
+
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+
+	integer, fraction, hasFraction := strings.Cut(digits, ".")
+
+	var grouped strings.Builder
+	for i, ch := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			grouped.WriteString(lf.group)
+		}
+		grouped.WriteRune(ch)
+	}
+
+	if hasFraction {
+		return sign + grouped.String() + lf.decimal + fraction
+	}
+	return sign + grouped.String()
+}
+
+func originalDigits(r *big.Rat, original interface{}) string {
+	switch v := original.(type) {
+	case json.Number:
+		if decimalPattern.MatchString(string(v)) {
+			return strings.TrimPrefix(string(v), "+")
+		}
+	case string:
+		if v = strings.TrimSpace(v); decimalPattern.MatchString(v) {
+			return strings.TrimPrefix(v, "+")
+		}
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	if r.IsInt() {
+		return r.FloatString(0)
+	}
+	// Frações sem representação decimal finita (ex.: 1/3)
+	f, _ := r.Float64()
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func resultTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range resultDateLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func resultBool(value interface{}) (bool, bool) {
+	switch v := value.(type) {
+	case bool:
+		return v, true
+	case int64:
+		return v != 0, true
+	case float64:
+		return v != 0, true
+	case json.Number:
+		return string(v) != "0", true
+	case string:
+		b, err := strconv.ParseBool(v)
+		return b, err == nil
+	}
+	return false, false
+}
+
+// checkFormatting valida output.formatting no carregamento da configuração
+func checkFormatting(output entities.OutputConfig) error {
+	if output.Locale != "" {
+		if _, ok := locales[output.Locale]; !ok {
+			return fmt.Errorf("unsupported locale '%s'", output.Locale)
+		}
+	}
+
+	for field, format := range output.Formatting {
+		if format.Type != "" && !formatTypes[format.Type] {
+			return fmt.Errorf("field '%s': unsupported format type '%s'", field, format.Type)
+		}
+		if format.Locale != "" {
+			if _, ok := locales[format.Locale]; !ok {
+				return fmt.Errorf("field '%s': unsupported locale '%s'", field, format.Locale)
+			}
+		}
+		if format.Precision != nil && (*format.Precision < 0 || *format.Precision > 18) {
+			return fmt.Errorf("field '%s': precision must be between 0 and 18", field)
+		}
+		if format.Type == "currency" && len(format.Currency) != 3 {
+			return fmt.Errorf("field '%s': currency must be an ISO 4217 code (e.g. BRL)", field)
+		}
+		if format.Layout != "" && format.Type != "date" && format.Type != "datetime" {
+			return fmt.Errorf("field '%s': layout is only valid for date/datetime", field)
+		}
+		if (format.TrueLabel != "" || format.FalseLabel != "") && format.Type != "bool" {
+			return fmt.Errorf("field '%s': true_label/false_label are only valid for bool", field)
+		}
+	}
+
+	return nil
+}
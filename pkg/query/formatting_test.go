@@ -0,0 +1,119 @@
+package query
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"reports-system/internal/domain/entities"
+)
+
+func TestFormatterFormat(t *testing.T) {
+	precision := func(n int) *int { return &n }
+	when := time.Date(2024, time.March, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		locale    string
+		format    *entities.ColumnFormat
+		value     interface{}
+		want      string
+		formatted bool
+	}{
+		{name: "no rule", value: 1.5, want: "1.5"},
+		{name: "no rule null", value: nil, want: ""},
+		{name: "null label", format: &entities.ColumnFormat{Type: "number", Null: "-"}, value: nil, want: "-", formatted: true},
+		{name: "number grouping", format: &entities.ColumnFormat{Type: "number"}, value: int64(1234567), want: "1,234,567", formatted: true},
+		{name: "number keeps digits", format: &entities.ColumnFormat{Type: "number"}, value: json.Number("1234.500"), want: "1,234.500", formatted: true},
+		{name: "number negative", format: &entities.ColumnFormat{Type: "number"}, value: -1234.5, want: "-1,234.5", formatted: true},
+		{name: "number precision", locale: "pt-BR", format: &entities.ColumnFormat{Type: "number", Precision: precision(2)}, value: 1234.5, want: "1.234,50", formatted: true},
+		{name: "number from decimal string", format: &entities.ColumnFormat{Type: "number", Precision: precision(1)}, value: "0.25", want: "0.3", formatted: true},
+		{name: "field locale overrides", locale: "en-US", format: &entities.ColumnFormat{Type: "number", Locale: "fr-FR"}, value: 1234.5, want: "1 234,5", formatted: true},
+		{name: "currency pt-BR", locale: "pt-BR", format: &entities.ColumnFormat{Type: "currency", Currency: "BRL"}, value: 1234.5, want: "R$ 1.234,50", formatted: true},
+		{name: "currency negative", format: &entities.ColumnFormat{Type: "currency", Currency: "USD"}, value: int64(-5), want: "-$5.00", formatted: true},
+		{name: "currency symbol after", locale: "de-DE", format: &entities.ColumnFormat{Type: "currency", Currency: "EUR"}, value: 1234.5, want: "1.234,50 €", formatted: true},
+		{name: "currency without decimals", format: &entities.ColumnFormat{Type: "currency", Currency: "JPY"}, value: int64(1234), want: "¥1,234", formatted: true},
+		{name: "currency unknown code", format: &entities.ColumnFormat{Type: "currency", Currency: "xyz"}, value: int64(10), want: "XYZ 10.00", formatted: true},
+		{name: "percent", format: &entities.ColumnFormat{Type: "percent"}, value: 0.125, want: "12.50%", formatted: true},
+		{name: "percent rounding", format: &entities.ColumnFormat{Type: "percent", Precision: precision(0)}, value: 0.125, want: "13%", formatted: true},
+		{name: "date", format: &entities.ColumnFormat{Type: "date"}, value: when, want: "2024-03-15", formatted: true},
+		{name: "date layout", format: &entities.ColumnFormat{Type: "date", Layout: "DD/MM/YYYY"}, value: when, want: "15/03/2024", formatted: true},
+		{name: "datetime from cached text", format: &entities.ColumnFormat{Type: "datetime"}, value: "2024-03-15T10:30:00Z", want: "2024-03-15 10:30:00", formatted: true},
+		{name: "bool labels", format: &entities.ColumnFormat{Type: "bool", TrueLabel: "Sim", FalseLabel: "Não"}, value: true, want: "Sim", formatted: true},
+		{name: "bool from number", format: &entities.ColumnFormat{Type: "bool", TrueLabel: "Sim", FalseLabel: "Não"}, value: int64(0), want: "Não", formatted: true},
+		{name: "bool without labels", format: &entities.ColumnFormat{Type: "bool"}, value: "true", want: "true", formatted: true},
+		{name: "not convertible", format: &entities.ColumnFormat{Type: "number"}, value: "abc", want: "abc", formatted: true},
+		{name: "text", format: &entities.ColumnFormat{Type: "text"}, value: "x", want: "x", formatted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := entities.OutputConfig{Locale: tt.locale}
+			if tt.format != nil {
+				output.Formatting = map[string]entities.ColumnFormat{"field": *tt.format}
+			}
+
+			got, formatted := NewFormatter(output).Format("field", tt.value)
+			if got != tt.want || formatted != tt.formatted {
+				t.Errorf("Format = (%q, %v), want (%q, %v)", got, formatted, tt.want, tt.formatted)
+			}
+		})
+	}
+}
+
+func TestFormatDefault(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{nil, ""},
+		{"text", "text"},
+		{[]byte("bytes"), "bytes"},
+		{0.1, "0.1"},
+		{1e21, "1000000000000000000000"},
+		{int64(42), "42"},
+		{true, "true"},
+		{time.Date(2024, time.March, 15, 10, 30, 0, 0, time.UTC), "2024-03-15T10:30:00Z"},
+		{map[string]interface{}{"a": 1}, `{"a":1}`},
+		{[]interface{}{1, "b"}, `[1,"b"]`},
+	}
+
+	for _, tt := range tests {
+		if got := FormatDefault(tt.value); got != tt.want {
+			t.Errorf("FormatDefault(%#v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestCheckFormatting(t *testing.T) {
+	precision := func(n int) *int { return &n }
+
+	tests := []struct {
+		name   string
+		output entities.OutputConfig
+		valid  bool
+	}{
+		{"valid", entities.OutputConfig{Locale: "pt-BR", Formatting: map[string]entities.ColumnFormat{
+			"valor": {Type: "currency", Currency: "BRL", Precision: precision(2)},
+			"data":  {Type: "date", Layout: "DD/MM/YYYY"},
+			"ativo": {Type: "bool", TrueLabel: "Sim"},
+		}}, true},
+		{"unknown locale", entities.OutputConfig{Locale: "xx-XX"}, false},
+		{"unknown field locale", entities.OutputConfig{Formatting: map[string]entities.ColumnFormat{"a": {Type: "number", Locale: "xx"}}}, false},
+		{"unknown type", entities.OutputConfig{Formatting: map[string]entities.ColumnFormat{"a": {Type: "money"}}}, false},
+		{"precision too large", entities.OutputConfig{Formatting: map[string]entities.ColumnFormat{"a": {Type: "number", Precision: precision(19)}}}, false},
+		{"negative precision", entities.OutputConfig{Formatting: map[string]entities.ColumnFormat{"a": {Type: "number", Precision: precision(-1)}}}, false},
+		{"invalid currency", entities.OutputConfig{Formatting: map[string]entities.ColumnFormat{"a": {Type: "currency", Currency: "R$"}}}, false},
+		{"layout on number", entities.OutputConfig{Formatting: map[string]entities.ColumnFormat{"a": {Type: "number", Layout: "YYYY"}}}, false},
+		{"labels on text", entities.OutputConfig{Formatting: map[string]entities.ColumnFormat{"a": {Type: "text", TrueLabel: "Sim"}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkFormatting(tt.output)
+			if valid := err == nil; valid != tt.valid {
+				t.Errorf("checkFormatting valid = %v, want %v (err: %v)", valid, tt.valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"reports-system/internal/domain/entities"
+)
+
+var aggregateFunctions = map[string]bool{
+	"sum":   true,
+	"count": true,
+	"avg":   true,
+	"min":   true,
+	"max":   true,
+}
+
+// AggregateKey retorna a chave do agregado em totals
+func AggregateKey(aggregate entities.AggregateConfig) string {
+	if aggregate.Name != "" {
+		return aggregate.Name
+	}
+	return aggregate.Function + "_" + aggregate.Field
+}
+
+// GroupResults agrupa os registros conforme output.grouping e calcula os
+// agregados de cada grupo e do total geral, sempre sobre todas as linhas da
+// query (relatórios agrupados não são paginados). Sem agrupamento configurado
+// os registros são retornados sem alteração.
+func (q *ConfigQuery) GroupResults(records []map[string]interface{}) (interface{}, error) {
+	grouping := q.config.Output.Grouping
+	if grouping == nil {
+		return records, nil
+	}
+
+	groups, err := groupRecords(grouping, records, 0)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := aggregateRecords(grouping.Aggregates, records)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.GroupedData{Groups: groups, Totals: totals}, nil
+}
+
+func groupRecords(grouping *entities.GroupingConfig, records []map[string]interface{}, level int) ([]entities.Group, error) {
+	field := grouping.By[level]
+
+	// Grupos na ordem da primeira ocorrência
+	var keys []string
+	buckets := make(map[string]*entities.Group)
+	for _, record := range records {
+		value, ok := record[field]
+		if !ok {
+			return nil, fmt.Errorf("group field '%s' not found in result", field)
+		}
+
+		key := recordKey(value)
+		group, exists := buckets[key]
+		if !exists {
+			group = &entities.Group{Field: field, Value: value}
+			buckets[key] = group
+			keys = append(keys, key)
+		}
+		group.Rows = append(group.Rows, record)
+	}
+
+	groups := make([]entities.Group, len(keys))
+	for i, key := range keys {
+		group := buckets[key]
+		group.Count = len(group.Rows)
+
+		totals, err := aggregateRecords(grouping.Aggregates, group.Rows)
+		if err != nil {
+			return nil, err
+		}
+		group.Totals = totals
+
+		if level+1 < len(grouping.By) {
+			if group.Groups, err = groupRecords(grouping, group.Rows, level+1); err != nil {
+				return nil, err
+			}
+			group.Rows = nil
+		}
+		groups[i] = *group
+	}
+
+	return groups, nil
+}
+
+func aggregateRecords(aggregates []entities.AggregateConfig, records []map[string]interface{}) (map[string]interface{}, error) {
+	totals := make(map[string]interface{}, len(aggregates))
+
+	for _, aggregate := range aggregates {
+		acc := newAggregator(aggregate.Function)
+		for _, record := range records {
+			value, ok := record[aggregate.Field]
+			if !ok {
+				return nil, fmt.Errorf("aggregate field '%s' not found in result", aggregate.Field)
+			}
+			if err := acc.add(value); err != nil {
+				return nil, fmt.Errorf("field '%s': %w", aggregate.Field, err)
+			}
+		}
+		totals[AggregateKey(aggregate)] = acc.result()
+	}
+
+	return totals, nil
+}
+
+// recordKey gera a chave de agrupamento de um conjunto de valores, usada por
+// grouping, pivot e nest. Números são comparados pelo valor, qualquer que seja
+// o tipo (int64 do banco, json.Number do cache, float64), e datas pelo
+// instante.
+func recordKey(values ...interface{}) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		switch value.(type) {
+		case []byte, int, int32, int64, float32, float64, json.Number:
+			value = exprValue(value)
+		}
+
+		switch v := value.(type) {
+		case nil:
+			parts[i] = "null"
+		case *big.Rat:
+			parts[i] = "n:" + v.RatString()
+		case time.Time:
+			parts[i] = "t:" + v.UTC().Format(time.RFC3339Nano)
+		case string:
+			parts[i] = "s:" + v
+		default:
+			parts[i] = fmt.Sprintf("%T:%v", v, v)
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// aggregator acumula os valores não nulos de uma função de agregação
+type aggregator struct {
+	function string
+	count    int64
+	sum      *big.Rat
+	extreme  interface{}
+}
+
+func newAggregator(function string) *aggregator {
+	return &aggregator{function: function, sum: new(big.Rat)}
+}
+
+func (a *aggregator) add(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	a.count++
+
+	switch a.function {
+	case "sum", "avg":
+		r, ok := toRat(value)
+		if !ok {
+			return fmt.Errorf("%s of non-numeric value", a.function)
+		}
+		a.sum.Add(a.sum, r)
+	case "min", "max":
+		if a.extreme == nil {
+			a.extreme = value
+			return nil
+		}
+		cmp, ok := compareTyped(value, a.extreme)
+		if !ok {
+			return fmt.Errorf("%s of incomparable values", a.function)
+		}
+		if (a.function == "min" && cmp < 0) || (a.function == "max" && cmp > 0) {
+			a.extreme = value
+		}
+	}
+	return nil
+}
+
+// result segue o SQL: sum/avg/min/max de nenhum valor resultam em null
+func (a *aggregator) result() interface{} {
+	switch a.function {
+	case "count":
+		return a.count
+	case "sum":
+		if a.count > 0 {
+			return ratNumber(a.sum)
+		}
+	case "avg":
+		if a.count > 0 {
+			return ratNumber(new(big.Rat).Quo(a.sum, big.NewRat(a.count, 1)))
+		}
+	case "min", "max":
+		return a.extreme
+	}
+	return nil
+}
+
+// GroupingFields retorna os campos usados pelo agrupamento (grupos e
+// agregados), que precisam estar presentes no resultado
+func GroupingFields(grouping *entities.GroupingConfig) []string {
+	fields := slices.Clone(grouping.By)
+	for _, aggregate := range grouping.Aggregates {
+		if !slices.Contains(fields, aggregate.Field) {
+			fields = append(fields, aggregate.Field)
+		}
+	}
+	return fields
+}
+
+// checkGrouping valida output.grouping no carregamento
+func checkGrouping(grouping *entities.GroupingConfig) error {
+	if len(grouping.By) == 0 {
+		return fmt.Errorf("at least one group field is required")
+	}
+
+	seen := make(map[string]bool)
+	for _, field := range grouping.By {
+		if field == "" {
+			return fmt.Errorf("group field name is required")
+		}
+		if seen[field] {
+			return fmt.Errorf("duplicate group field '%s'", field)
+		}
+		seen[field] = true
+	}
+
+	keys := make(map[string]bool)
+	for _, aggregate := range grouping.Aggregates {
+		if !aggregateFunctions[aggregate.Function] {
+			return fmt.Errorf("unsupported aggregate function '%s'", aggregate.Function)
+		}
+		if aggregate.Field == "" {
+			return fmt.Errorf("aggregate '%s' requires a field", aggregate.Function)
+		}
+
+		key := AggregateKey(aggregate)
+		if keys[key] {
+			return fmt.Errorf("duplicate aggregate '%s'", key)
+		}
+		keys[key] = true
+	}
+
+	return nil
+}
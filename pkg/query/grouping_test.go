@@ -0,0 +1,244 @@
+package query
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"reports-system/internal/domain/entities"
+)
+
+func groupingQuery(grouping *entities.GroupingConfig) *ConfigQuery {
+	return NewConfigQuery(&entities.QueryConfig{Output: entities.OutputConfig{Grouping: grouping}}).(*ConfigQuery)
+}
+
+func TestGroupResults(t *testing.T) {
+	// Valores do banco (int64) e do cache (json.Number) misturados
+	records := []map[string]interface{}{
+		{"cliente": "A", "uf": "SP", "valor": int64(10)},
+		{"cliente": "B", "uf": "RJ", "valor": json.Number("5")},
+		{"cliente": "A", "uf": "SP", "valor": json.Number("20.5")},
+		{"cliente": "A", "uf": "RJ", "valor": nil},
+	}
+	aggregates := []entities.AggregateConfig{
+		{Function: "sum", Field: "valor"},
+		{Function: "count", Field: "valor", Name: "n"},
+		{Function: "avg", Field: "valor"},
+		{Function: "min", Field: "valor"},
+		{Function: "max", Field: "valor"},
+	}
+	totals := func(sum, n, avg, min, max interface{}) map[string]interface{} {
+		return map[string]interface{}{"sum_valor": sum, "n": n, "avg_valor": avg, "min_valor": min, "max_valor": max}
+	}
+
+	tests := []struct {
+		name     string
+		grouping *entities.GroupingConfig
+		want     interface{}
+	}{
+		{
+			name: "without grouping",
+			want: records,
+		},
+		{
+			name:     "one level",
+			grouping: &entities.GroupingConfig{By: []string{"cliente"}, Aggregates: aggregates},
+			want: &entities.GroupedData{
+				Groups: []entities.Group{
+					{
+						Field: "cliente", Value: "A", Count: 3,
+						Totals: totals(json.Number("30.5"), int64(2), json.Number("15.25"), int64(10), json.Number("20.5")),
+						Rows:   []map[string]interface{}{records[0], records[2], records[3]},
+					},
+					{
+						Field: "cliente", Value: "B", Count: 1,
+						Totals: totals(json.Number("5"), int64(1), json.Number("5"), json.Number("5"), json.Number("5")),
+						Rows:   []map[string]interface{}{records[1]},
+					},
+				},
+				Totals: totals(json.Number("35.5"), int64(3), json.Number("11.8333333333"), json.Number("5"), json.Number("20.5")),
+			},
+		},
+		{
+			name: "two levels",
+			grouping: &entities.GroupingConfig{By: []string{"cliente", "uf"}, Aggregates: []entities.AggregateConfig{
+				{Function: "count", Field: "cliente"},
+			}},
+			want: &entities.GroupedData{
+				Groups: []entities.Group{
+					{
+						Field: "cliente", Value: "A", Count: 3,
+						Totals: map[string]interface{}{"count_cliente": int64(3)},
+						Groups: []entities.Group{
+							{Field: "uf", Value: "SP", Count: 2, Totals: map[string]interface{}{"count_cliente": int64(2)}, Rows: []map[string]interface{}{records[0], records[2]}},
+							{Field: "uf", Value: "RJ", Count: 1, Totals: map[string]interface{}{"count_cliente": int64(1)}, Rows: []map[string]interface{}{records[3]}},
+						},
+					},
+					{
+						Field: "cliente", Value: "B", Count: 1,
+						Totals: map[string]interface{}{"count_cliente": int64(1)},
+						Groups: []entities.Group{
+							{Field: "uf", Value: "RJ", Count: 1, Totals: map[string]interface{}{"count_cliente": int64(1)}, Rows: []map[string]interface{}{records[1]}},
+						},
+					},
+				},
+				Totals: map[string]interface{}{"count_cliente": int64(4)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := groupingQuery(tt.grouping).GroupResults(records)
+			if err != nil {
+				t.Fatalf("GroupResults: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.MarshalIndent(got, "", "  ")
+				wantJSON, _ := json.MarshalIndent(tt.want, "", "  ")
+				t.Errorf("GroupResults =\n%s\nwant\n%s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestGroupResultsNullAggregates(t *testing.T) {
+	records := []map[string]interface{}{{"g": "a", "v": nil}, {"g": "a", "v": nil}}
+	grouping := &entities.GroupingConfig{By: []string{"g"}, Aggregates: []entities.AggregateConfig{
+		{Function: "sum", Field: "v"},
+		{Function: "avg", Field: "v"},
+		{Function: "min", Field: "v"},
+		{Function: "count", Field: "v"},
+	}}
+
+	got, err := groupingQuery(grouping).GroupResults(records)
+	if err != nil {
+		t.Fatalf("GroupResults: %v", err)
+	}
+
+	want := map[string]interface{}{"sum_v": nil, "avg_v": nil, "min_v": nil, "count_v": int64(0)}
+	if totals := got.(*entities.GroupedData).Totals; !reflect.DeepEqual(totals, want) {
+		t.Errorf("totals = %v, want %v", totals, want)
+	}
+}
+
+func TestGroupResultsNormalizedKeys(t *testing.T) {
+	records := []map[string]interface{}{
+		{"id": int64(1)},
+		{"id": json.Number("1")},
+		{"id": 1.0},
+		{"id": json.Number("1.0")},
+		{"id": "1"},
+	}
+	grouping := &entities.GroupingConfig{By: []string{"id"}}
+
+	got, err := groupingQuery(grouping).GroupResults(records)
+	if err != nil {
+		t.Fatalf("GroupResults: %v", err)
+	}
+
+	groups := got.(*entities.GroupedData).Groups
+	if len(groups) != 2 || groups[0].Count != 4 || groups[0].Value != int64(1) || groups[1].Value != "1" {
+		t.Errorf("groups = %+v", groups)
+	}
+}
+
+func TestGroupResultsErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		records   []map[string]interface{}
+		aggregate entities.AggregateConfig
+		want      string
+	}{
+		{"missing group field", []map[string]interface{}{{"v": 1}}, entities.AggregateConfig{Function: "sum", Field: "v"}, "group field 'g' not found"},
+		{"missing aggregate field", []map[string]interface{}{{"g": "a"}}, entities.AggregateConfig{Function: "sum", Field: "v"}, "aggregate field 'v' not found"},
+		{"sum of text", []map[string]interface{}{{"g": "a", "v": "x"}}, entities.AggregateConfig{Function: "sum", Field: "v"}, "sum of non-numeric value"},
+		{"min of mixed types", []map[string]interface{}{{"g": "a", "v": "x"}, {"g": "a", "v": time.Now()}}, entities.AggregateConfig{Function: "min", Field: "v"}, "min of incomparable values"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grouping := &entities.GroupingConfig{By: []string{"g"}, Aggregates: []entities.AggregateConfig{tt.aggregate}}
+			_, err := groupingQuery(grouping).GroupResults(tt.records)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("GroupResults error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordKey(t *testing.T) {
+	when := time.Date(2024, time.March, 15, 10, 0, 0, 0, time.UTC)
+	tokyo := time.FixedZone("JST", 9*3600)
+
+	tests := []struct {
+		name  string
+		a, b  []interface{}
+		equal bool
+	}{
+		{"int64 and json.Number", []interface{}{int64(1)}, []interface{}{json.Number("1")}, true},
+		{"int and float", []interface{}{1}, []interface{}{1.0}, true},
+		{"trailing zeros", []interface{}{json.Number("1.50")}, []interface{}{1.5}, true},
+		{"float32", []interface{}{float32(0.1)}, []interface{}{0.1}, true},
+		{"bytes and string", []interface{}{[]byte("a")}, []interface{}{"a"}, true},
+		{"same instant", []interface{}{when}, []interface{}{when.In(tokyo)}, true},
+		{"several values", []interface{}{"a", int64(2)}, []interface{}{"a", json.Number("2")}, true},
+		{"number and text", []interface{}{int64(1)}, []interface{}{"1"}, false},
+		{"null and text", []interface{}{nil}, []interface{}{"null"}, false},
+		{"bool and text", []interface{}{true}, []interface{}{"true"}, false},
+		{"different instants", []interface{}{when}, []interface{}{when.Add(time.Second)}, false},
+		{"value order", []interface{}{"a", "b"}, []interface{}{"b", "a"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if equal := recordKey(tt.a...) == recordKey(tt.b...); equal != tt.equal {
+				t.Errorf("recordKey(%v) == recordKey(%v) is %v, want %v", tt.a, tt.b, equal, tt.equal)
+			}
+		})
+	}
+}
+
+func TestGroupingFields(t *testing.T) {
+	grouping := &entities.GroupingConfig{
+		By: []string{"a", "b"},
+		Aggregates: []entities.AggregateConfig{
+			{Function: "sum", Field: "v"},
+			{Function: "count", Field: "a"},
+			{Function: "avg", Field: "v"},
+		},
+	}
+
+	want := []string{"a", "b", "v"}
+	if got := GroupingFields(grouping); !slices.Equal(got, want) {
+		t.Errorf("GroupingFields = %v, want %v", got, want)
+	}
+}
+
+func TestCheckGrouping(t *testing.T) {
+	tests := []struct {
+		name     string
+		grouping entities.GroupingConfig
+		valid    bool
+	}{
+		{"valid", entities.GroupingConfig{By: []string{"a"}, Aggregates: []entities.AggregateConfig{{Function: "sum", Field: "v"}, {Function: "sum", Field: "v", Name: "total"}}}, true},
+		{"no group field", entities.GroupingConfig{}, false},
+		{"empty group field", entities.GroupingConfig{By: []string{""}}, false},
+		{"duplicate group field", entities.GroupingConfig{By: []string{"a", "a"}}, false},
+		{"unknown function", entities.GroupingConfig{By: []string{"a"}, Aggregates: []entities.AggregateConfig{{Function: "median", Field: "v"}}}, false},
+		{"aggregate without field", entities.GroupingConfig{By: []string{"a"}, Aggregates: []entities.AggregateConfig{{Function: "count"}}}, false},
+		{"duplicate aggregate", entities.GroupingConfig{By: []string{"a"}, Aggregates: []entities.AggregateConfig{{Function: "sum", Field: "v"}, {Function: "sum", Field: "v"}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkGrouping(&tt.grouping)
+			if valid := err == nil; valid != tt.valid {
+				t.Errorf("checkGrouping valid = %v, want %v (err: %v)", valid, tt.valid, err)
+			}
+		})
+	}
+}
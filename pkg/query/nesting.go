@@ -0,0 +1,228 @@
+package query
+
+import (
+	"fmt"
+	"slices"
+
+	"reports-system/internal/domain/entities"
+)
+
+// NestResults agrupa os registros planos (ex.: resultado de joins) em objetos
+// aninhados conforme output.nest. Os objetos seguem a ordem da primeira
+// ocorrência; filhos com a chave toda nula (ex.: LEFT JOIN sem
+// correspondência) resultam em coleções vazias. Sem aninhamento configurado
+// os registros são retornados sem alteração.
+func (q *ConfigQuery) NestResults(records []map[string]interface{}) (interface{}, error) {
+	nest := q.config.Output.Nest
+	if nest == nil {
+		return records, nil
+	}
+	return nestRecords(*nest, records, map[string]bool{})
+}
+
+func nestRecords(level entities.NestConfig, records []map[string]interface{}, claimed map[string]bool) ([]map[string]interface{}, error) {
+	// Campos deste nível e dos níveis acima, indisponíveis para os filhos
+	own := append(slices.Clone(level.Key), level.Fields...)
+	below := make(map[string]bool, len(claimed)+len(own))
+	for field := range claimed {
+		below[field] = true
+	}
+	for _, field := range own {
+		below[field] = true
+	}
+
+	var order []string
+	objects := make(map[string]map[string]interface{})
+	buckets := make(map[string][]map[string]interface{})
+
+	for _, record := range records {
+		values := make([]interface{}, len(level.Key))
+		empty := true
+		for i, field := range level.Key {
+			value, ok := record[field]
+			if !ok {
+				return nil, fmt.Errorf("nest key field '%s' not found in result", field)
+			}
+			if value != nil {
+				empty = false
+			}
+			values[i] = value
+		}
+		if empty {
+			continue
+		}
+		key := recordKey(values...)
+
+		if _, exists := objects[key]; !exists {
+			object, err := nestObject(level, record, claimed)
+			if err != nil {
+				return nil, err
+			}
+			objects[key] = object
+			order = append(order, key)
+		}
+		buckets[key] = append(buckets[key], record)
+	}
+
+	result := make([]map[string]interface{}, len(order))
+	for i, key := range order {
+		object := objects[key]
+		for _, child := range level.Children {
+			items, err := nestRecords(child, buckets[key], below)
+			if err != nil {
+				return nil, err
+			}
+			object[child.Name] = items
+		}
+		result[i] = object
+	}
+
+	return result, nil
+}
+
+// nestObject copia os campos do nível a partir da primeira linha do objeto
+func nestObject(level entities.NestConfig, record map[string]interface{}, claimed map[string]bool) (map[string]interface{}, error) {
+	object := make(map[string]interface{})
+
+	fields := append(slices.Clone(level.Key), level.Fields...)
+	if len(level.Fields) == 0 && len(level.Children) == 0 {
+		for field := range record {
+			if !claimed[field] && !slices.Contains(fields, field) {
+				fields = append(fields, field)
+			}
+		}
+	}
+
+	for _, field := range fields {
+		value, ok := record[field]
+		if !ok {
+			return nil, fmt.Errorf("nest field '%s' not found in result", field)
+		}
+		object[field] = value
+	}
+	return object, nil
+}
+
+// FlattenNested desfaz o aninhamento de output.nest para a exportação
+// tabular (CSV, XLSX, PDF): uma linha por objeto do último nível, com os
+// campos dos objetos acima. Aceita também os dados lidos do cache (JSON).
+func FlattenNested(nest entities.NestConfig, data interface{}) ([]map[string]interface{}, error) {
+	records, err := nestedItems(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]interface{}
+	for _, record := range records {
+		flat, err := flattenObject(nest, record, nil)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, flat...)
+	}
+	return rows, nil
+}
+
+func flattenObject(level entities.NestConfig, object map[string]interface{}, inherited map[string]interface{}) ([]map[string]interface{}, error) {
+	row := make(map[string]interface{}, len(inherited)+len(object))
+	for field, value := range inherited {
+		row[field] = value
+	}
+	for field, value := range object {
+		if !slices.ContainsFunc(level.Children, func(child entities.NestConfig) bool { return child.Name == field }) {
+			row[field] = value
+		}
+	}
+
+	var rows []map[string]interface{}
+	for _, child := range level.Children {
+		items, err := nestedItems(object[child.Name])
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			flat, err := flattenObject(child, item, row)
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, flat...)
+		}
+	}
+
+	// Objeto sem filhos ainda gera uma linha com os próprios campos
+	if len(rows) == 0 {
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func nestedItems(data interface{}) ([]map[string]interface{}, error) {
+	switch v := data.(type) {
+	case nil:
+		return nil, nil
+	case []map[string]interface{}:
+		return v, nil
+	case []interface{}:
+		items := make([]map[string]interface{}, len(v))
+		for i, item := range v {
+			record, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("unsupported nested item type %T", item)
+			}
+			items[i] = record
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unsupported nested data type %T", data)
+}
+
+// NestFields retorna os campos declarados em output.nest (chaves e campos de
+// todos os níveis), que precisam estar presentes no resultado
+func NestFields(nest entities.NestConfig) []string {
+	fields := append(slices.Clone(nest.Key), nest.Fields...)
+	for _, child := range nest.Children {
+		fields = append(fields, NestFields(child)...)
+	}
+	return fields
+}
+
+// checkNesting valida output.nest no carregamento: cada campo aparece em um
+// único nível e as coleções irmãs têm nomes distintos
+func checkNesting(output entities.OutputConfig) error {
+	if output.Grouping != nil {
+		return fmt.Errorf("nest cannot be combined with grouping")
+	}
+	return checkNestLevel(*output.Nest, true, make(map[string]bool))
+}
+
+func checkNestLevel(level entities.NestConfig, root bool, used map[string]bool) error {
+	if !root && level.Name == "" {
+		return fmt.Errorf("child collection name is required")
+	}
+	if len(level.Key) == 0 {
+		return fmt.Errorf("level '%s': at least one key field is required", level.Name)
+	}
+
+	for _, field := range append(slices.Clone(level.Key), level.Fields...) {
+		if used[field] {
+			return fmt.Errorf("field '%s' is used in more than one level", field)
+		}
+		used[field] = true
+	}
+
+	names := make(map[string]bool)
+	for _, child := range level.Children {
+		if names[child.Name] {
+			return fmt.Errorf("duplicate child collection '%s'", child.Name)
+		}
+		names[child.Name] = true
+		if used[child.Name] {
+			return fmt.Errorf("child collection '%s' conflicts with a field", child.Name)
+		}
+
+		if err := checkNestLevel(child, false, used); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package query
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"reports-system/internal/domain/entities"
+)
+
+func nestQuery(nest *entities.NestConfig) *ConfigQuery {
+	return NewConfigQuery(&entities.QueryConfig{Output: entities.OutputConfig{Nest: nest}}).(*ConfigQuery)
+}
+
+// Pedidos com itens (LEFT JOIN): o pedido 2 não tem itens
+var nestRecordsFixture = []map[string]interface{}{
+	{"pedido_id": int64(1), "cliente": "A", "item_id": int64(10), "produto": "x"},
+	{"pedido_id": int64(1), "cliente": "A", "item_id": int64(11), "produto": "y"},
+	{"pedido_id": int64(2), "cliente": "B", "item_id": nil, "produto": nil},
+}
+
+func TestNestResults(t *testing.T) {
+	tests := []struct {
+		name string
+		nest *entities.NestConfig
+		want interface{}
+	}{
+		{
+			name: "without nest",
+			want: nestRecordsFixture,
+		},
+		{
+			name: "declared fields",
+			nest: &entities.NestConfig{
+				Key:      []string{"pedido_id"},
+				Fields:   []string{"cliente"},
+				Children: []entities.NestConfig{{Name: "itens", Key: []string{"item_id"}, Fields: []string{"produto"}}},
+			},
+			want: []map[string]interface{}{
+				{"pedido_id": int64(1), "cliente": "A", "itens": []map[string]interface{}{
+					{"item_id": int64(10), "produto": "x"},
+					{"item_id": int64(11), "produto": "y"},
+				}},
+				{"pedido_id": int64(2), "cliente": "B", "itens": []map[string]interface{}{}},
+			},
+		},
+		{
+			name: "leaf takes remaining fields",
+			nest: &entities.NestConfig{
+				Key:      []string{"pedido_id"},
+				Fields:   []string{"cliente"},
+				Children: []entities.NestConfig{{Name: "itens", Key: []string{"item_id"}}},
+			},
+			want: []map[string]interface{}{
+				{"pedido_id": int64(1), "cliente": "A", "itens": []map[string]interface{}{
+					{"item_id": int64(10), "produto": "x"},
+					{"item_id": int64(11), "produto": "y"},
+				}},
+				{"pedido_id": int64(2), "cliente": "B", "itens": []map[string]interface{}{}},
+			},
+		},
+		{
+			name: "single level",
+			nest: &entities.NestConfig{Key: []string{"cliente"}, Fields: []string{"pedido_id"}},
+			want: []map[string]interface{}{
+				{"cliente": "A", "pedido_id": int64(1)},
+				{"cliente": "B", "pedido_id": int64(2)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nestQuery(tt.nest).NestResults(nestRecordsFixture)
+			if err != nil {
+				t.Fatalf("NestResults: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(tt.want)
+				t.Errorf("NestResults =\n%s\nwant\n%s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestNestResultsEmptyChildren(t *testing.T) {
+	nest := &entities.NestConfig{
+		Key:      []string{"pedido_id"},
+		Children: []entities.NestConfig{{Name: "itens", Key: []string{"item_id"}}},
+	}
+	got, err := nestQuery(nest).NestResults(nestRecordsFixture[2:])
+	if err != nil {
+		t.Fatalf("NestResults: %v", err)
+	}
+
+	// Coleção vazia é serializada como [] e não como null
+	data, _ := json.Marshal(got)
+	if string(data) != `[{"itens":[],"pedido_id":2}]` {
+		t.Errorf("NestResults = %s", data)
+	}
+}
+
+func TestNestResultsNormalizedKeys(t *testing.T) {
+	records := []map[string]interface{}{
+		{"id": int64(1), "item": "a"},
+		{"id": json.Number("1"), "item": "b"},
+		{"id": 1.0, "item": "c"},
+	}
+	nest := &entities.NestConfig{
+		Key:      []string{"id"},
+		Children: []entities.NestConfig{{Name: "itens", Key: []string{"item"}}},
+	}
+
+	got, err := nestQuery(nest).NestResults(records)
+	if err != nil {
+		t.Fatalf("NestResults: %v", err)
+	}
+
+	objects := got.([]map[string]interface{})
+	if len(objects) != 1 || len(objects[0]["itens"].([]map[string]interface{})) != 3 {
+		t.Errorf("NestResults = %v", objects)
+	}
+}
+
+func TestNestResultsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		nest *entities.NestConfig
+		want string
+	}{
+		{"missing key", &entities.NestConfig{Key: []string{"x"}}, "nest key field 'x' not found"},
+		{"missing field", &entities.NestConfig{Key: []string{"pedido_id"}, Fields: []string{"x"}}, "nest field 'x' not found"},
+		{"missing child key", &entities.NestConfig{Key: []string{"pedido_id"}, Children: []entities.NestConfig{{Name: "c", Key: []string{"x"}}}}, "nest key field 'x' not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := nestQuery(tt.nest).NestResults(nestRecordsFixture)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("NestResults error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlattenNested(t *testing.T) {
+	nest := entities.NestConfig{
+		Key:      []string{"pedido_id"},
+		Fields:   []string{"cliente"},
+		Children: []entities.NestConfig{{Name: "itens", Key: []string{"item_id"}, Fields: []string{"produto"}}},
+	}
+	nested, err := nestQuery(&nest).NestResults(nestRecordsFixture)
+	if err != nil {
+		t.Fatalf("NestResults: %v", err)
+	}
+
+	// O mesmo resultado lido do cache (JSON)
+	encoded, _ := json.Marshal(nested)
+	var cached interface{}
+	json.Unmarshal(encoded, &cached)
+
+	tests := []struct {
+		name string
+		data interface{}
+		want []map[string]interface{}
+	}{
+		{
+			name: "nested",
+			data: nested,
+			want: []map[string]interface{}{
+				{"pedido_id": int64(1), "cliente": "A", "item_id": int64(10), "produto": "x"},
+				{"pedido_id": int64(1), "cliente": "A", "item_id": int64(11), "produto": "y"},
+				{"pedido_id": int64(2), "cliente": "B"},
+			},
+		},
+		{
+			name: "cached",
+			data: cached,
+			want: []map[string]interface{}{
+				{"pedido_id": 1.0, "cliente": "A", "item_id": 10.0, "produto": "x"},
+				{"pedido_id": 1.0, "cliente": "A", "item_id": 11.0, "produto": "y"},
+				{"pedido_id": 2.0, "cliente": "B"},
+			},
+		},
+		{
+			name: "empty",
+			data: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FlattenNested(nest, tt.data)
+			if err != nil {
+				t.Fatalf("FlattenNested: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FlattenNested = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlattenNestedUnsupported(t *testing.T) {
+	nest := entities.NestConfig{Key: []string{"id"}}
+	for _, data := range []interface{}{"x", []interface{}{"x"}, map[string]interface{}{}} {
+		if _, err := FlattenNested(nest, data); err == nil {
+			t.Errorf("FlattenNested(%#v) succeeded", data)
+		}
+	}
+}
+
+func TestNestFields(t *testing.T) {
+	nest := entities.NestConfig{
+		Key:    []string{"loja_id"},
+		Fields: []string{"loja"},
+		Children: []entities.NestConfig{
+			{Name: "pedidos", Key: []string{"pedido_id"}, Children: []entities.NestConfig{
+				{Name: "itens", Key: []string{"item_id"}, Fields: []string{"produto"}},
+			}},
+			{Name: "gerentes", Key: []string{"gerente_id"}},
+		},
+	}
+
+	want := []string{"loja_id", "loja", "pedido_id", "item_id", "produto", "gerente_id"}
+	if got := NestFields(nest); !slices.Equal(got, want) {
+		t.Errorf("NestFields = %v, want %v", got, want)
+	}
+}
+
+func TestCheckNesting(t *testing.T) {
+	child := func(name string, key ...string) entities.NestConfig {
+		return entities.NestConfig{Name: name, Key: key}
+	}
+
+	tests := []struct {
+		name     string
+		nest     entities.NestConfig
+		grouping *entities.GroupingConfig
+		valid    bool
+	}{
+		{"valid", entities.NestConfig{Key: []string{"a"}, Fields: []string{"b"}, Children: []entities.NestConfig{child("c", "d"), child("e", "f")}}, nil, true},
+		{"with grouping", entities.NestConfig{Key: []string{"a"}}, &entities.GroupingConfig{By: []string{"a"}}, false},
+		{"no key", entities.NestConfig{}, nil, false},
+		{"child without name", entities.NestConfig{Key: []string{"a"}, Children: []entities.NestConfig{child("", "b")}}, nil, false},
+		{"child without key", entities.NestConfig{Key: []string{"a"}, Children: []entities.NestConfig{child("c")}}, nil, false},
+		{"field in two levels", entities.NestConfig{Key: []string{"a"}, Children: []entities.NestConfig{child("c", "a")}}, nil, false},
+		{"field repeated in level", entities.NestConfig{Key: []string{"a"}, Fields: []string{"a"}}, nil, false},
+		{"duplicate child", entities.NestConfig{Key: []string{"a"}, Children: []entities.NestConfig{child("c", "d"), child("c", "e")}}, nil, false},
+		{"child name is a field", entities.NestConfig{Key: []string{"a"}, Fields: []string{"c"}, Children: []entities.NestConfig{child("c", "d")}}, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkNesting(entities.OutputConfig{Nest: &tt.nest, Grouping: tt.grouping})
+			if valid := err == nil; valid != tt.valid {
+				t.Errorf("checkNesting valid = %v, want %v (err: %v)", valid, tt.valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package query
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"reports-system/internal/domain/entities"
+)
+
+// ResolveSort converte a ordenação pedida pelo cliente (campos mapeados, com
+// "-" para ordem decrescente) nas colunas originais. Apenas campos listados
+// em output.sortable são aceitos.
+func ResolveSort(config *entities.QueryConfig, sort []string) ([]entities.SortField, error) {
+	var fields []entities.SortField
+	var fieldErrors []entities.FieldError
+	seen := make(map[string]bool)
+
+	for _, item := range sort {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, desc := strings.TrimPrefix(strings.TrimPrefix(item, "-"), "+"), strings.HasPrefix(item, "-")
+		if !slices.Contains(config.Output.Sortable, name) {
+			fieldErrors = append(fieldErrors, entities.FieldError{
+				Param:   "sort",
+				Rule:    "sortable",
+				Message: fmt.Sprintf("field '%s' is not sortable", name),
+				Value:   item,
+			})
+			continue
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		fields = append(fields, entities.SortField{Column: SourceColumn(config, name), Desc: desc})
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, &entities.ValidationError{Errors: fieldErrors}
+	}
+
+	return fields, nil
+}
+
+// ResolveFields converte os campos mapeados pedidos pelo cliente nas colunas
+// originais, na ordem pedida. Apenas campos de output.selectable são aceitos.
+func ResolveFields(config *entities.QueryConfig, fields []string) ([]string, error) {
+	var columns []string
+	var fieldErrors []entities.FieldError
+	seen := make(map[string]bool)
+
+	for _, name := range fields {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		if !slices.Contains(config.Output.Selectable, name) {
+			fieldErrors = append(fieldErrors, entities.FieldError{
+				Param:   "fields",
+				Rule:    "selectable",
+				Message: fmt.Sprintf("field '%s' is not selectable", name),
+				Value:   name,
+			})
+			continue
+		}
+
+		columns = append(columns, SourceColumn(config, name))
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, &entities.ValidationError{Errors: fieldErrors}
+	}
+
+	return columns, nil
+}
+
+// SourceColumn retorna a coluna original de um campo mapeado em
+// field_mapping; campos sem mapeamento usam o próprio nome
+func SourceColumn(config *entities.QueryConfig, field string) string {
+	for column, mapped := range config.Output.FieldMapping {
+		if mapped == field {
+			return column
+		}
+	}
+	return field
+}
+
+// SortQuery envolve o SQL do relatório com a ordenação pedida. As colunas
+// vêm de ResolveSort (whitelist da configuração) e são sempre delimitadas.
+func SortQuery(dialect string, query string, sort []entities.SortField) string {
+	if len(sort) == 0 {
+		return query
+	}
+
+	order := make([]string, len(sort))
+	for i, field := range sort {
+		order[i] = "report_sorted." + quoteIdentifier(dialect, field.Column)
+		if field.Desc {
+			order[i] += " DESC"
+		}
+	}
+
+	return "SELECT * FROM (" + subquery(dialect, trimStatement(query)) + ") AS report_sorted ORDER BY " + strings.Join(order, ", ")
+}
+
+// checkOutputFields valida decimals, formatting, sortable e selectable no
+// carregamento: os campos devem ser únicos e corresponder a colunas com nomes
+// simples
+func checkOutputFields(config *entities.QueryConfig) error {
+	lists := []struct {
+		key    string
+		fields []string
+	}{
+		{"sortable", config.Output.Sortable},
+		{"selectable", config.Output.Selectable},
+	}
+
+	switch config.Output.Decimals {
+	case "", DecimalsAsNumber, DecimalsAsString:
+	default:
+		return fmt.Errorf("decimals must be '%s' or '%s'", DecimalsAsNumber, DecimalsAsString)
+	}
+
+	if err := checkFormatting(config.Output); err != nil {
+		return err
+	}
+
+	for _, list := range lists {
+		key, fields := list.key, list.fields
+		seen := make(map[string]bool)
+		for _, field := range fields {
+			if seen[field] {
+				return fmt.Errorf("duplicate %s field '%s'", key, field)
+			}
+			seen[field] = true
+
+			if column := SourceColumn(config, field); !identifierPattern.MatchString(column) {
+				return fmt.Errorf("%s field '%s' maps to invalid column '%s'", key, field, column)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package query
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"reports-system/internal/domain/entities"
+)
+
+func outputFieldsConfig() *entities.QueryConfig {
+	return &entities.QueryConfig{Output: entities.OutputConfig{
+		FieldMapping: map[string]string{"total": "total_sales", "region_name": "region"},
+		Sortable:     []string{"total_sales", "region"},
+		Selectable:   []string{"total_sales", "region", "orders"},
+	}}
+}
+
+// failedValues retorna os valores rejeitados de um ValidationError
+func failedValues(t *testing.T, err error, rule string) []interface{} {
+	t.Helper()
+
+	var validationErr *entities.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("error = %v, want validation error", err)
+	}
+	var values []interface{}
+	for _, fieldErr := range validationErr.Errors {
+		if fieldErr.Rule != rule {
+			t.Errorf("rule = %s, want %s", fieldErr.Rule, rule)
+		}
+		values = append(values, fieldErr.Value)
+	}
+	return values
+}
+
+func TestResolveSort(t *testing.T) {
+	tests := []struct {
+		name     string
+		sort     []string
+		want     []entities.SortField
+		rejected []interface{}
+	}{
+		{"empty", nil, nil, nil},
+		{"mapped fields", []string{"-total_sales", "region"}, []entities.SortField{{Column: "total", Desc: true}, {Column: "region_name"}}, nil},
+		{"explicit ascending", []string{"+region", " "}, []entities.SortField{{Column: "region_name"}}, nil},
+		{"first direction wins", []string{"region", "-region"}, []entities.SortField{{Column: "region_name"}}, nil},
+		{"source column is not a field", []string{"total"}, nil, []interface{}{"total"}},
+		{"not sortable", []string{"orders"}, nil, []interface{}{"orders"}},
+		{"injection", []string{"region; DROP TABLE sales", "-total_sales"}, nil, []interface{}{"region; DROP TABLE sales"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveSort(outputFieldsConfig(), tt.sort)
+			if tt.rejected != nil {
+				if values := failedValues(t, err, "sortable"); !reflect.DeepEqual(values, tt.rejected) {
+					t.Errorf("rejected = %v, want %v", values, tt.rejected)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveSort: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveSort = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   []string
+		want     []string
+		rejected []interface{}
+	}{
+		{"empty", nil, nil, nil},
+		{"request order", []string{"region", "total_sales"}, []string{"region_name", "total"}, nil},
+		{"duplicates and blanks", []string{"orders", " orders ", ""}, []string{"orders"}, nil},
+		{"not selectable", []string{"region", "cost", "margin"}, nil, []interface{}{"cost", "margin"}},
+		{"source column is not a field", []string{"region_name"}, nil, []interface{}{"region_name"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveFields(outputFieldsConfig(), tt.fields)
+			if tt.rejected != nil {
+				if values := failedValues(t, err, "selectable"); !reflect.DeepEqual(values, tt.rejected) {
+					t.Errorf("rejected = %v, want %v", values, tt.rejected)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveFields: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveFields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortQuery(t *testing.T) {
+	sort := []entities.SortField{{Column: "total", Desc: true}, {Column: "region_name"}}
+
+	tests := []struct {
+		name    string
+		dialect string
+		query   string
+		sort    []entities.SortField
+		want    string
+	}{
+		{"no sort", DialectPostgres, "SELECT * FROM sales;", nil, "SELECT * FROM sales;"},
+		{"postgres", DialectPostgres, "SELECT * FROM sales ORDER BY region_name;", sort,
+			`SELECT * FROM (SELECT * FROM sales ORDER BY region_name) AS report_sorted ORDER BY report_sorted."total" DESC, report_sorted."region_name"`},
+		{"sqlserver drops inner order", DialectSQLServer, "SELECT * FROM sales ORDER BY region_name", sort,
+			`SELECT * FROM (SELECT * FROM sales) AS report_sorted ORDER BY report_sorted.[total] DESC, report_sorted.[region_name]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SortQuery(tt.dialect, tt.query, tt.sort); got != tt.want {
+				t.Errorf("SortQuery =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckOutputFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		output entities.OutputConfig
+		valid  bool
+	}{
+		{"valid", outputFieldsConfig().Output, true},
+		{"duplicate sortable", entities.OutputConfig{Sortable: []string{"a", "a"}}, false},
+		{"duplicate selectable", entities.OutputConfig{Selectable: []string{"a", "a"}}, false},
+		{"invalid column", entities.OutputConfig{Sortable: []string{"a b"}}, false},
+		{"mapped to invalid column", entities.OutputConfig{FieldMapping: map[string]string{"count(*)": "total"}, Selectable: []string{"total"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkOutputFields(&entities.QueryConfig{Output: tt.output})
+			if (err == nil) != tt.valid {
+				t.Errorf("checkOutputFields error = %v, want valid = %v", err, tt.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,331 @@
+package query
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"reports-system/internal/domain/entities"
+)
+
+const (
+	DialectPostgres  = "postgres"
+	DialectSQLServer = "sqlserver"
+
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+var (
+	identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	orderByPattern    = regexp.MustCompile(`(?i)^ORDER\s+BY\b`)
+)
+
+// ResolvePage valida a página pedida e aplica o tamanho padrão da
+// configuração. A paginação só é aceita nos relatórios que a configuram, e
+// cursores apenas quando ela define uma Key.
+func ResolvePage(config *entities.QueryConfig, page entities.PageRequest) (entities.PageRequest, error) {
+	if config == nil || config.Pagination == nil {
+		return page, paginationUnsupported(page)
+	}
+	pagination := config.Pagination
+
+	defaultSize, maxSize := pageSizeLimits(pagination)
+	var fieldErrors []entities.FieldError
+
+	if page.PageSize == 0 {
+		page.PageSize = defaultSize
+	} else if page.PageSize < 1 || page.PageSize > maxSize {
+		fieldErrors = append(fieldErrors, entities.FieldError{
+			Param:   "page_size",
+			Rule:    "range",
+			Message: fmt.Sprintf("page_size must be between 1 and %d", maxSize),
+			Value:   page.PageSize,
+		})
+	}
+
+	if page.Cursor != "" {
+		if page.Page != 0 {
+			fieldErrors = append(fieldErrors, entities.FieldError{
+				Param:   "page,cursor",
+				Rule:    "mutually_exclusive",
+				Message: "parameters 'page' and 'cursor' are mutually exclusive",
+			})
+		}
+		if pagination.Key == "" {
+			fieldErrors = append(fieldErrors, entities.FieldError{
+				Param:   "cursor",
+				Rule:    "unsupported",
+				Message: "report does not support cursor pagination",
+				Value:   page.Cursor,
+			})
+		} else if _, err := decodeCursor(page.Cursor); err != nil {
+			fieldErrors = append(fieldErrors, entities.FieldError{
+				Param:   "cursor",
+				Rule:    "format",
+				Message: "invalid cursor",
+				Value:   page.Cursor,
+			})
+		}
+	} else if page.Page == 0 {
+		page.Page = 1
+	} else if page.Page < 1 {
+		fieldErrors = append(fieldErrors, entities.FieldError{
+			Param:   "page",
+			Rule:    "min",
+			Message: "page must be at least 1",
+			Value:   page.Page,
+		})
+	}
+
+	if len(fieldErrors) > 0 {
+		return page, &entities.ValidationError{Errors: fieldErrors}
+	}
+
+	return page, nil
+}
+
+// paginationUnsupported aponta os argumentos de paginação informados para um
+// relatório sem paginação configurada
+func paginationUnsupported(page entities.PageRequest) error {
+	var fieldErrors []entities.FieldError
+	unsupported := func(param string, value interface{}) {
+		fieldErrors = append(fieldErrors, entities.FieldError{
+			Param:   param,
+			Rule:    "unsupported",
+			Message: "report does not support pagination",
+			Value:   value,
+		})
+	}
+
+	if page.Page != 0 || (page.PageSize == 0 && page.Cursor == "") {
+		unsupported("page", page.Page)
+	}
+	if page.PageSize != 0 {
+		unsupported("page_size", page.PageSize)
+	}
+	if page.Cursor != "" {
+		unsupported("cursor", page.Cursor)
+	}
+
+	return &entities.ValidationError{Errors: fieldErrors}
+}
+
+func pageSizeLimits(pagination *entities.PaginationConfig) (int, int) {
+	maxSize := pagination.MaxPageSize
+	if maxSize == 0 {
+		maxSize = maxPageSize
+	}
+
+	defaultSize := pagination.DefaultPageSize
+	if defaultSize == 0 {
+		defaultSize = defaultPageSize
+	}
+	if defaultSize > maxSize {
+		defaultSize = maxSize
+	}
+
+	return defaultSize, maxSize
+}
+
+// PaginateQuery envolve o SQL do relatório para retornar uma página já
+// resolvida por ResolvePage. É buscada uma linha além do tamanho da página
+// para indicar se há uma próxima.
+//
+// Com Key, as linhas são ordenadas pela coluna e o cursor filtra as linhas
+// posteriores (keyset). Sem Key, o limite é aplicado à própria query, cujo
+// ORDER BY (exigido no carregamento) define a ordem das páginas.
+func PaginateQuery(dialect string, pagination *entities.PaginationConfig, query string, args []interface{}, page entities.PageRequest) (string, []interface{}, error) {
+	if dialect != DialectPostgres && dialect != DialectSQLServer {
+		return "", nil, fmt.Errorf("pagination is not supported for dialect '%s'", dialect)
+	}
+
+	query = trimStatement(query)
+	offset := 0
+	if page.Cursor == "" {
+		offset = (page.Page - 1) * page.PageSize
+	}
+
+	paged := append([]interface{}{}, args...)
+	paged = append(paged, sql.Named("page_limit", page.PageSize+1), sql.Named("page_offset", offset))
+
+	var out strings.Builder
+	if pagination != nil && pagination.Key != "" {
+		key := "report_page." + quoteIdentifier(dialect, pagination.Key)
+
+		out.WriteString("SELECT * FROM (")
+		out.WriteString(subquery(dialect, query))
+		out.WriteString(") AS report_page")
+		if page.Cursor != "" {
+			cursor, err := decodeCursor(page.Cursor)
+			if err != nil {
+				return "", nil, err
+			}
+			out.WriteString(" WHERE " + key + " > @page_cursor")
+			paged = append(paged, sql.Named("page_cursor", cursor))
+		}
+		out.WriteString(" ORDER BY " + key)
+	} else {
+		// A ordem de uma subquery não é garantida na query externa, então o
+		// ORDER BY do relatório permanece no nível mais externo
+		out.WriteString(query)
+		if _, orderBy := splitOrderBy(query); orderBy == "" && dialect == DialectSQLServer {
+			// OFFSET/FETCH exige ORDER BY
+			out.WriteString(" ORDER BY (SELECT NULL)")
+		}
+	}
+
+	if dialect == DialectPostgres {
+		out.WriteString(" LIMIT @page_limit OFFSET @page_offset")
+	} else {
+		out.WriteString(" OFFSET @page_offset ROWS FETCH NEXT @page_limit ROWS ONLY")
+	}
+
+	return out.String(), paged, nil
+}
+
+// CountQuery retorna o SQL que conta as linhas do relatório; os argumentos
+// são os mesmos da query original
+func CountQuery(dialect string, query string) string {
+	return "SELECT COUNT(*) FROM (" + subquery(dialect, trimStatement(query)) + ") AS report_count"
+}
+
+// subquery adapta o SQL para uso como tabela derivada. O SQL Server não
+// aceita ORDER BY em tabelas derivadas sem TOP/OFFSET, então a ordenação
+// final é removida.
+func subquery(dialect string, query string) string {
+	if dialect != DialectSQLServer {
+		return query
+	}
+	body, orderBy := splitOrderBy(query)
+	if orderBy == "" || strings.Contains(strings.ToUpper(orderBy), "OFFSET") {
+		return query
+	}
+	return body
+}
+
+// splitOrderBy separa a cláusula ORDER BY final do SQL. Apenas o nível mais
+// externo é considerado (ORDER BY em subqueries e OVER(...) é ignorado).
+func splitOrderBy(query string) (string, string) {
+	position := -1
+	depth := 0
+
+	for i := 0; i < len(query); i++ {
+		switch ch := query[i]; {
+		case ch == '\'' || ch == '"' || ch == '[':
+			closing := ch
+			if ch == '[' {
+				closing = ']'
+			}
+			end := strings.IndexByte(query[i+1:], closing)
+			if end < 0 {
+				return query, ""
+			}
+			i += end + 1
+		case ch == '-' && i+1 < len(query) && query[i+1] == '-':
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			i += end - 1
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		case depth == 0 && (ch == 'o' || ch == 'O') && (i == 0 || !isIdentByte(query[i-1])):
+			if orderByPattern.MatchString(query[i:]) {
+				position = i
+			}
+		}
+	}
+
+	if position < 0 {
+		return query, ""
+	}
+	return strings.TrimSpace(query[:position]), query[position:]
+}
+
+func isIdentByte(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+}
+
+func trimStatement(query string) string {
+	return strings.TrimRight(strings.TrimSpace(query), "; \t\r\n")
+}
+
+func quoteIdentifier(dialect, name string) string {
+	if dialect == DialectSQLServer {
+		return "[" + name + "]"
+	}
+	return `"` + name + `"`
+}
+
+// EncodeCursor gera o cursor opaco da próxima página a partir do valor da
+// coluna Key da última linha retornada
+func EncodeCursor(value interface{}) string {
+	switch v := value.(type) {
+	case []byte:
+		value = string(v)
+	case time.Time:
+		value = v.Format(time.RFC3339Nano)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(cursor string) (interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	// UseNumber preserva chaves inteiras grandes (ids bigint)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, nil
+		}
+		return v.Float64()
+	case string:
+		return v, nil
+	}
+	return nil, fmt.Errorf("invalid cursor")
+}
+
+// checkPagination valida a configuração de paginação no carregamento. Sem
+// Key, a query precisa de um ORDER BY final para que as páginas sejam
+// estáveis.
+func checkPagination(pagination *entities.PaginationConfig, query string) error {
+	if pagination.DefaultPageSize < 0 || pagination.MaxPageSize < 0 {
+		return fmt.Errorf("page sizes must not be negative")
+	}
+	if pagination.MaxPageSize > 0 && pagination.DefaultPageSize > pagination.MaxPageSize {
+		return fmt.Errorf("default_page_size must not exceed max_page_size")
+	}
+	if pagination.Key != "" && !identifierPattern.MatchString(pagination.Key) {
+		return fmt.Errorf("invalid key column '%s'", pagination.Key)
+	}
+	if pagination.Key == "" {
+		if _, orderBy := splitOrderBy(trimStatement(query)); orderBy == "" {
+			return fmt.Errorf("a key column or a final ORDER BY in the query is required")
+		}
+	}
+	return nil
+}
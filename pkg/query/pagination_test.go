@@ -0,0 +1,294 @@
+package query
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"reports-system/internal/domain/entities"
+)
+
+func TestPaginateQuery(t *testing.T) {
+	const ordered = "SELECT id, name FROM t ORDER BY name;"
+
+	tests := []struct {
+		name       string
+		dialect    string
+		key        string
+		query      string
+		page       entities.PageRequest
+		want       string
+		wantOffset int
+		wantCursor interface{}
+	}{
+		{
+			name:    "postgres keyed",
+			dialect: DialectPostgres,
+			key:     "id",
+			query:   ordered,
+			page:    entities.PageRequest{Page: 1, PageSize: 10},
+			want:    `SELECT * FROM (SELECT id, name FROM t ORDER BY name) AS report_page ORDER BY report_page."id" LIMIT @page_limit OFFSET @page_offset`,
+		},
+		{
+			name:       "postgres keyed with cursor",
+			dialect:    DialectPostgres,
+			key:        "id",
+			query:      ordered,
+			page:       entities.PageRequest{Cursor: EncodeCursor(int64(42)), PageSize: 10},
+			want:       `SELECT * FROM (SELECT id, name FROM t ORDER BY name) AS report_page WHERE report_page."id" > @page_cursor ORDER BY report_page."id" LIMIT @page_limit OFFSET @page_offset`,
+			wantCursor: int64(42),
+		},
+		{
+			name:       "sqlserver keyed drops inner order by",
+			dialect:    DialectSQLServer,
+			key:        "id",
+			query:      ordered,
+			page:       entities.PageRequest{Cursor: EncodeCursor("abc"), PageSize: 10},
+			want:       `SELECT * FROM (SELECT id, name FROM t) AS report_page WHERE report_page.[id] > @page_cursor ORDER BY report_page.[id] OFFSET @page_offset ROWS FETCH NEXT @page_limit ROWS ONLY`,
+			wantCursor: "abc",
+		},
+		{
+			name:       "postgres unkeyed keeps outer order by",
+			dialect:    DialectPostgres,
+			query:      ordered,
+			page:       entities.PageRequest{Page: 3, PageSize: 10},
+			want:       `SELECT id, name FROM t ORDER BY name LIMIT @page_limit OFFSET @page_offset`,
+			wantOffset: 20,
+		},
+		{
+			name:       "sqlserver unkeyed",
+			dialect:    DialectSQLServer,
+			query:      ordered,
+			page:       entities.PageRequest{Page: 2, PageSize: 5},
+			want:       `SELECT id, name FROM t ORDER BY name OFFSET @page_offset ROWS FETCH NEXT @page_limit ROWS ONLY`,
+			wantOffset: 5,
+		},
+		{
+			name:    "sqlserver unkeyed without order by",
+			dialect: DialectSQLServer,
+			query:   "SELECT id FROM t",
+			page:    entities.PageRequest{Page: 1, PageSize: 5},
+			want:    `SELECT id FROM t ORDER BY (SELECT NULL) OFFSET @page_offset ROWS FETCH NEXT @page_limit ROWS ONLY`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pagination *entities.PaginationConfig
+			if tt.key != "" {
+				pagination = &entities.PaginationConfig{Key: tt.key}
+			}
+
+			args := []interface{}{sql.Named("status", "ok")}
+			got, paged, err := PaginateQuery(tt.dialect, pagination, tt.query, args, tt.page)
+			if err != nil {
+				t.Fatalf("PaginateQuery: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("query:\n got %s\nwant %s", got, tt.want)
+			}
+
+			named := make(map[string]interface{})
+			for _, arg := range paged {
+				if n, ok := arg.(sql.NamedArg); ok {
+					named[n.Name] = n.Value
+				}
+			}
+			if named["status"] != "ok" {
+				t.Errorf("original args were not kept: %v", paged)
+			}
+			if named["page_limit"] != tt.page.PageSize+1 {
+				t.Errorf("page_limit = %v, want %d", named["page_limit"], tt.page.PageSize+1)
+			}
+			if named["page_offset"] != tt.wantOffset {
+				t.Errorf("page_offset = %v, want %d", named["page_offset"], tt.wantOffset)
+			}
+			if named["page_cursor"] != tt.wantCursor {
+				t.Errorf("page_cursor = %v, want %v", named["page_cursor"], tt.wantCursor)
+			}
+		})
+	}
+}
+
+func TestPaginateQueryUnsupportedDialect(t *testing.T) {
+	page := entities.PageRequest{Page: 1, PageSize: 10}
+	if _, _, err := PaginateQuery("mysql", nil, "SELECT 1 ORDER BY 1", nil, page); err == nil {
+		t.Error("PaginateQuery accepted an unsupported dialect")
+	}
+}
+
+func TestCountQuery(t *testing.T) {
+	tests := []struct {
+		dialect string
+		query   string
+		want    string
+	}{
+		{DialectPostgres, "SELECT * FROM t ORDER BY a;", "SELECT COUNT(*) FROM (SELECT * FROM t ORDER BY a) AS report_count"},
+		{DialectSQLServer, "SELECT * FROM t ORDER BY a", "SELECT COUNT(*) FROM (SELECT * FROM t) AS report_count"},
+		{DialectSQLServer, "SELECT * FROM t ORDER BY a OFFSET 0 ROWS", "SELECT COUNT(*) FROM (SELECT * FROM t ORDER BY a OFFSET 0 ROWS) AS report_count"},
+		{DialectSQLServer, "SELECT * FROM t", "SELECT COUNT(*) FROM (SELECT * FROM t) AS report_count"},
+	}
+
+	for _, tt := range tests {
+		if got := CountQuery(tt.dialect, tt.query); got != tt.want {
+			t.Errorf("CountQuery(%s, %q) = %q, want %q", tt.dialect, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSplitOrderBy(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		body    string
+		orderBy string
+	}{
+		{"final order by", "SELECT * FROM t ORDER BY a", "SELECT * FROM t", "ORDER BY a"},
+		{"lowercase and spacing", "select * from t\norder   by a desc", "select * from t", "order   by a desc"},
+		{"no order by", "SELECT * FROM t", "SELECT * FROM t", ""},
+		{"window function", "SELECT ROW_NUMBER() OVER (ORDER BY a) FROM t", "SELECT ROW_NUMBER() OVER (ORDER BY a) FROM t", ""},
+		{"subquery", "SELECT * FROM (SELECT * FROM t ORDER BY a) x", "SELECT * FROM (SELECT * FROM t ORDER BY a) x", ""},
+		{"outer after subquery", "SELECT * FROM (SELECT * FROM t ORDER BY a) x ORDER BY b", "SELECT * FROM (SELECT * FROM t ORDER BY a) x", "ORDER BY b"},
+		{"string literal", "SELECT 'ORDER BY a' FROM t", "SELECT 'ORDER BY a' FROM t", ""},
+		{"quoted identifier", `SELECT "order by" FROM t`, `SELECT "order by" FROM t`, ""},
+		{"bracket identifier", "SELECT [order by] FROM t", "SELECT [order by] FROM t", ""},
+		{"line comment", "SELECT * FROM t -- ORDER BY a\nWHERE 1=1", "SELECT * FROM t -- ORDER BY a\nWHERE 1=1", ""},
+		{"identifier suffix", "SELECT reorder BY FROM t", "SELECT reorder BY FROM t", ""},
+		{"unterminated string", "SELECT 'x ORDER BY a", "SELECT 'x ORDER BY a", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, orderBy := splitOrderBy(tt.query)
+			if body != tt.body || orderBy != tt.orderBy {
+				t.Errorf("splitOrderBy = (%q, %q), want (%q, %q)", body, orderBy, tt.body, tt.orderBy)
+			}
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	when := time.Date(2024, time.March, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  interface{}
+	}{
+		{"int", int64(42), int64(42)},
+		{"bigint beyond float precision", int64(9007199254740993), int64(9007199254740993)},
+		{"float", 1.5, 1.5},
+		{"string", "abc", "abc"},
+		{"bytes", []byte("abc"), "abc"},
+		{"time", when, when.Format(time.RFC3339Nano)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(EncodeCursor(tt.value))
+			if err != nil {
+				t.Fatalf("decodeCursor: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	for _, cursor := range []string{"!!!", encode("{"), encode("[1]"), encode("true"), encode("null")} {
+		if _, err := decodeCursor(cursor); err == nil {
+			t.Errorf("decodeCursor(%q) succeeded", cursor)
+		}
+	}
+}
+
+func TestResolvePage(t *testing.T) {
+	keyed := &entities.QueryConfig{Pagination: &entities.PaginationConfig{Key: "id", DefaultPageSize: 20, MaxPageSize: 50}}
+	unkeyed := &entities.QueryConfig{Pagination: &entities.PaginationConfig{}}
+	disabled := &entities.QueryConfig{}
+
+	tests := []struct {
+		name   string
+		config *entities.QueryConfig
+		page   entities.PageRequest
+		want   entities.PageRequest
+		errors []string
+	}{
+		{name: "defaults", config: unkeyed, want: entities.PageRequest{Page: 1, PageSize: defaultPageSize}},
+		{name: "configured default", config: keyed, want: entities.PageRequest{Page: 1, PageSize: 20}},
+		{name: "explicit page", config: keyed, page: entities.PageRequest{Page: 3, PageSize: 50}, want: entities.PageRequest{Page: 3, PageSize: 50}},
+		{name: "cursor", config: keyed, page: entities.PageRequest{Cursor: EncodeCursor(1)}, want: entities.PageRequest{Cursor: EncodeCursor(1), PageSize: 20}},
+		{name: "page size above max", config: keyed, page: entities.PageRequest{PageSize: 51}, errors: []string{"page_size"}},
+		{name: "negative page size", config: unkeyed, page: entities.PageRequest{PageSize: -1}, errors: []string{"page_size"}},
+		{name: "negative page", config: unkeyed, page: entities.PageRequest{Page: -1}, errors: []string{"page"}},
+		{name: "cursor without key", config: unkeyed, page: entities.PageRequest{Cursor: EncodeCursor(1)}, errors: []string{"cursor"}},
+		{name: "invalid cursor", config: keyed, page: entities.PageRequest{Cursor: "!!!"}, errors: []string{"cursor"}},
+		{name: "page and cursor", config: keyed, page: entities.PageRequest{Page: 2, Cursor: EncodeCursor(1)}, errors: []string{"page,cursor"}},
+		{name: "page without pagination", config: disabled, page: entities.PageRequest{Page: 2}, errors: []string{"page"}},
+		{name: "page size without pagination", config: disabled, page: entities.PageRequest{PageSize: 10}, errors: []string{"page_size"}},
+		{name: "cursor without pagination", config: disabled, page: entities.PageRequest{Cursor: EncodeCursor(1)}, errors: []string{"cursor"}},
+		{name: "page and size without pagination", config: disabled, page: entities.PageRequest{Page: 1, PageSize: 10}, errors: []string{"page", "page_size"}},
+		{name: "nil config", page: entities.PageRequest{Page: 1}, errors: []string{"page"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolvePage(tt.config, tt.page)
+
+			if len(tt.errors) == 0 {
+				if err != nil {
+					t.Fatalf("ResolvePage: %v", err)
+				}
+				if got != tt.want {
+					t.Errorf("ResolvePage = %+v, want %+v", got, tt.want)
+				}
+				return
+			}
+
+			var validationErr *entities.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("ResolvePage error = %v, want validation error", err)
+			}
+			var params []string
+			for _, fieldErr := range validationErr.Errors {
+				params = append(params, fieldErr.Param)
+			}
+			if !slices.Equal(params, tt.errors) {
+				t.Errorf("errors on %v, want %v", params, tt.errors)
+			}
+		})
+	}
+}
+
+func TestCheckPagination(t *testing.T) {
+	tests := []struct {
+		name       string
+		pagination entities.PaginationConfig
+		query      string
+		valid      bool
+	}{
+		{"key without order by", entities.PaginationConfig{Key: "id"}, "SELECT * FROM t", true},
+		{"order by without key", entities.PaginationConfig{}, "SELECT * FROM t ORDER BY id;", true},
+		{"no key nor order by", entities.PaginationConfig{}, "SELECT * FROM t", false},
+		{"order by only in window", entities.PaginationConfig{}, "SELECT ROW_NUMBER() OVER (ORDER BY id) FROM t", false},
+		{"invalid key", entities.PaginationConfig{Key: "id; DROP"}, "SELECT * FROM t", false},
+		{"negative size", entities.PaginationConfig{Key: "id", DefaultPageSize: -1}, "SELECT * FROM t", false},
+		{"default above max", entities.PaginationConfig{Key: "id", DefaultPageSize: 60, MaxPageSize: 50}, "SELECT * FROM t", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPagination(&tt.pagination, tt.query)
+			if valid := err == nil; valid != tt.valid {
+				t.Errorf("checkPagination valid = %v, want %v (err: %v)", valid, tt.valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"reports-system/internal/domain/entities"
+)
+
+var decimalPattern = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+
+// coerceParam converte um valor já validado para o tipo Go canônico do
+// ParamConfig: bool, int64, float64, time.Time (no fuso loc) ou string
+// decimal.
+func coerceParam(config entities.ParamConfig, value interface{}, loc *time.Location) interface{} {
+	switch config.Type {
+	case "bool":
+		switch v := value.(type) {
+		case float64:
+			return v != 0
+		case string:
+			b, _ := strconv.ParseBool(v)
+			return b
+		}
+	case "int":
+		switch v := value.(type) {
+		case int:
+			return int64(v)
+		case float64:
+			return int64(v)
+		case string:
+			i, _ := strconv.ParseInt(v, 10, 64)
+			return i
+		}
+	case "float":
+		switch v := value.(type) {
+		case int:
+			return float64(v)
+		case int64:
+			return float64(v)
+		case string:
+			f, _ := strconv.ParseFloat(v, 64)
+			return f
+		}
+	case "decimal":
+		// Mantido como texto para não perder precisão no binding
+		switch v := value.(type) {
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		case int:
+			return strconv.Itoa(v)
+		case int64:
+			return strconv.FormatInt(v, 10)
+		case string:
+			return strings.TrimPrefix(strings.TrimSpace(v), "+")
+		}
+	case "date":
+		if str, ok := value.(string); ok {
+			if t, err := time.ParseInLocation(paramLayout(config, "2006-01-02"), str, loc); err == nil {
+				return t
+			}
+		}
+	case "datetime":
+		if str, ok := value.(string); ok {
+			if t, err := time.ParseInLocation(paramLayout(config, "2006-01-02 15:04:05"), str, loc); err == nil {
+				return t
+			}
+		}
+	case "string", "enum":
+		if f, ok := value.(float64); ok {
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+	}
+
+	return value
+}
+
+// CanonicalParams converte os parâmetros para uma representação textual
+// estável, baseada no tipo declarado em cada ParamConfig. É usada para gerar
+// chaves de cache que não dependem da forma como o valor chegou (query string,
+// JSON, valor padrão).
+func CanonicalParams(config *entities.QueryConfig, params map[string]interface{}) map[string]string {
+	result := make(map[string]string, len(params))
+
+	declared := make(map[string]entities.ParamConfig)
+	if config != nil {
+		for _, paramConfig := range config.Parameters {
+			declared[paramConfig.Name] = paramConfig
+		}
+	}
+
+	for name, value := range params {
+		if paramConfig, ok := declared[name]; ok {
+			result[name] = canonicalValue(paramConfig, value)
+			continue
+		}
+		result[name] = fmt.Sprintf("%v", value)
+	}
+
+	return result
+}
+
+func canonicalValue(config entities.ParamConfig, value interface{}) string {
+	if elemType, ok := listElementType(config.Type); ok {
+		if list, ok := value.([]interface{}); ok {
+			elemConfig := config
+			elemConfig.Type = elemType
+			items := make([]string, len(list))
+			for i, item := range list {
+				items[i] = canonicalValue(elemConfig, item)
+			}
+			// Listas expandem em IN (...): a ordem dos itens não altera o
+			// resultado
+			slices.Sort(items)
+			encoded, _ := json.Marshal(items)
+			return string(encoded)
+		}
+	}
+
+	if t, ok := value.(time.Time); ok {
+		if config.Type == "date" {
+			return t.Format("2006-01-02")
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	switch config.Type {
+	case "bool":
+		switch v := value.(type) {
+		case bool:
+			return strconv.FormatBool(v)
+		case float64:
+			return strconv.FormatBool(v != 0)
+		case int:
+			return strconv.FormatBool(v != 0)
+		case string:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return strconv.FormatBool(b)
+			}
+		}
+	case "int":
+		switch v := value.(type) {
+		case int:
+			return strconv.FormatInt(int64(v), 10)
+		case int64:
+			return strconv.FormatInt(v, 10)
+		case float64:
+			return strconv.FormatInt(int64(v), 10)
+		case string:
+			if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return strconv.FormatInt(i, 10)
+			}
+		}
+	case "float":
+		switch v := value.(type) {
+		case float64:
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		case int:
+			return strconv.FormatFloat(float64(v), 'g', -1, 64)
+		case string:
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return strconv.FormatFloat(f, 'g', -1, 64)
+			}
+		}
+	case "date":
+		if str, ok := value.(string); ok {
+			if t, err := time.Parse(paramLayout(config, "2006-01-02"), str); err == nil {
+				return t.Format("2006-01-02")
+			}
+		}
+	case "datetime":
+		if str, ok := value.(string); ok {
+			if t, err := time.Parse(paramLayout(config, "2006-01-02 15:04:05"), str); err == nil {
+				return t.Format(time.RFC3339)
+			}
+		}
+	case "string", "enum":
+		if str, ok := value.(string); ok {
+			return str
+		}
+		if f, ok := value.(float64); ok {
+			return strconv.FormatFloat(f, 'g', -1, 64)
+		}
+	}
+
+	return fmt.Sprintf("%v", value)
+}
+
+// Layouts padrão dos tipos de data quando validation.format não é informado
+var dateLayouts = map[string]string{
+	"date":     "2006-01-02",
+	"datetime": "2006-01-02 15:04:05",
+}
+
+// paramBaseType retorna o tipo escalar do parâmetro (o tipo do elemento, em
+// listas)
+func paramBaseType(config entities.ParamConfig) string {
+	if elemType, ok := listElementType(config.Type); ok {
+		return elemType
+	}
+	return config.Type
+}
+
+// paramLayout retorna o layout Go configurado em validation.format ou o
+// layout padrão informado.
+func paramLayout(config entities.ParamConfig, fallback string) string {
+	if val, ok := config.Validation["format"]; ok {
+		if formatStr, ok := val.(string); ok && strings.TrimSpace(formatStr) != "" {
+			return convertDateFormat(formatStr)
+		}
+	}
+	return fallback
+}
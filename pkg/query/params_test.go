@@ -0,0 +1,127 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"reports-system/internal/domain/entities"
+)
+
+func TestCanonicalParams(t *testing.T) {
+	config := &entities.QueryConfig{Parameters: []entities.ParamConfig{
+		{Name: "ctr", Type: "bool"},
+		{Name: "limit", Type: "int"},
+		{Name: "ratio", Type: "float"},
+		{Name: "day", Type: "date"},
+		{Name: "status", Type: "string"},
+		{Name: "ids", Type: "list<int>"},
+		{Name: "tags", Type: "array"},
+	}}
+
+	tests := []struct {
+		name string
+		a, b map[string]interface{}
+		same bool
+	}{
+		{"bool from query string and JSON", map[string]interface{}{"ctr": "1"}, map[string]interface{}{"ctr": true}, true},
+		{"int from text and number", map[string]interface{}{"limit": "1"}, map[string]interface{}{"limit": float64(1)}, true},
+		{"coerced int", map[string]interface{}{"limit": int64(1)}, map[string]interface{}{"limit": "1"}, true},
+		{"float", map[string]interface{}{"ratio": "0.50"}, map[string]interface{}{"ratio": 0.5}, true},
+		{"date as text and time", map[string]interface{}{"day": "2024-03-01"}, map[string]interface{}{"day": time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}, true},
+		{"list order", map[string]interface{}{"ids": []interface{}{"3", "1", "2"}}, map[string]interface{}{"ids": []interface{}{float64(1), float64(2), float64(3)}}, true},
+		{"string list order", map[string]interface{}{"tags": []interface{}{"b", "a"}}, map[string]interface{}{"tags": []interface{}{"a", "b"}}, true},
+		{"different list", map[string]interface{}{"ids": []interface{}{"1", "2"}}, map[string]interface{}{"ids": []interface{}{"1", "2", "3"}}, false},
+		{"different value", map[string]interface{}{"limit": "1"}, map[string]interface{}{"limit": "2"}, false},
+		{"string from number", map[string]interface{}{"status": "1"}, map[string]interface{}{"status": float64(1)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := CanonicalParams(config, tt.a), CanonicalParams(config, tt.b)
+			equal := len(a) == len(b)
+			for name, value := range a {
+				if b[name] != value {
+					equal = false
+				}
+			}
+			if equal != tt.same {
+				t.Errorf("CanonicalParams(%v) = %v, CanonicalParams(%v) = %v, same = %v, want %v", tt.a, a, tt.b, b, equal, tt.same)
+			}
+		})
+	}
+}
+
+func TestCoerceParam(t *testing.T) {
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Skip("timezone data unavailable")
+	}
+
+	tests := []struct {
+		name   string
+		config entities.ParamConfig
+		value  interface{}
+		want   interface{}
+	}{
+		{"bool from text", entities.ParamConfig{Type: "bool"}, "true", true},
+		{"bool from number", entities.ParamConfig{Type: "bool"}, float64(0), false},
+		{"bool kept", entities.ParamConfig{Type: "bool"}, true, true},
+		{"int from text", entities.ParamConfig{Type: "int"}, "42", int64(42)},
+		{"int from JSON number", entities.ParamConfig{Type: "int"}, float64(42), int64(42)},
+		{"int from int", entities.ParamConfig{Type: "int"}, 42, int64(42)},
+		{"float from text", entities.ParamConfig{Type: "float"}, "1.5", 1.5},
+		{"float from int", entities.ParamConfig{Type: "float"}, 2, float64(2)},
+		{"decimal from text", entities.ParamConfig{Type: "decimal"}, " +10.50 ", "10.50"},
+		{"decimal from number", entities.ParamConfig{Type: "decimal"}, 10.5, "10.5"},
+		{"decimal from int", entities.ParamConfig{Type: "decimal"}, int64(7), "7"},
+		{"date", entities.ParamConfig{Type: "date"}, "2024-03-01", time.Date(2024, 3, 1, 0, 0, 0, 0, saoPaulo)},
+		{"date with format", entities.ParamConfig{Type: "date", Validation: map[string]interface{}{"format": "DD/MM/YYYY"}}, "01/03/2024", time.Date(2024, 3, 1, 0, 0, 0, 0, saoPaulo)},
+		{"datetime", entities.ParamConfig{Type: "datetime"}, "2024-03-01 10:30:00", time.Date(2024, 3, 1, 10, 30, 0, 0, saoPaulo)},
+		{"invalid date kept", entities.ParamConfig{Type: "date"}, "01/03/2024", "01/03/2024"},
+		{"string from number", entities.ParamConfig{Type: "string"}, float64(123), "123"},
+		{"enum kept", entities.ParamConfig{Type: "enum"}, "paid", "paid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := coerceParam(tt.config, tt.value, saoPaulo)
+			if want, ok := tt.want.(time.Time); ok {
+				if gotTime, ok := got.(time.Time); !ok || !gotTime.Equal(want) || gotTime.Location() != saoPaulo {
+					t.Errorf("coerceParam(%v) = %v, want %v", tt.value, got, want)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("coerceParam(%v) = %#v, want %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCoercesParams(t *testing.T) {
+	q := NewConfigQuery(&entities.QueryConfig{Parameters: []entities.ParamConfig{
+		{Name: "active", Type: "bool"},
+		{Name: "limit", Type: "int", Default: "10"},
+		{Name: "day", Type: "date"},
+		{Name: "ids", Type: "list<int>"},
+	}})
+
+	params := map[string]interface{}{"active": "1", "day": "2024-03-01", "ids": "1,2"}
+	if err := q.Validate(params); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	if params["active"] != true {
+		t.Errorf("active = %#v, want true", params["active"])
+	}
+	if params["limit"] != int64(10) {
+		t.Errorf("limit = %#v, want int64(10)", params["limit"])
+	}
+	if day, ok := params["day"].(time.Time); !ok || !day.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("day = %#v, want 2024-03-01 UTC", params["day"])
+	}
+	ids, ok := params["ids"].([]interface{})
+	if !ok || len(ids) != 2 || ids[0] != int64(1) || ids[1] != int64(2) {
+		t.Errorf("ids = %#v, want [1 2] as int64", params["ids"])
+	}
+}
@@ -0,0 +1,232 @@
+package query
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"reports-system/internal/domain/entities"
+)
+
+// PivotResults aplica output.pivot ao resultado (colunas originais), antes
+// da transformação. Há uma coluna por valor distinto do campo Column, em
+// ordem crescente, descrita com o tipo da coluna de valores. Sem pivot o
+// resultado é retornado sem alteração.
+func (q *ConfigQuery) PivotResults(columns []string, info []entities.ColumnInfo, rows [][]interface{}) ([]string, []entities.ColumnInfo, [][]interface{}, error) {
+	pivot := q.config.Output.Pivot
+	if pivot == nil {
+		return columns, info, rows, nil
+	}
+
+	index := func(field string) (int, error) {
+		source := SourceColumn(q.config, field)
+		i := slices.Index(columns, source)
+		if i < 0 {
+			return -1, fmt.Errorf("pivot field '%s' not found in result", field)
+		}
+		return i, nil
+	}
+
+	keyIndexes := make([]int, len(pivot.Rows))
+	for i, field := range pivot.Rows {
+		var err error
+		if keyIndexes[i], err = index(field); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	columnIndex, err := index(pivot.Column)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	valueIndex, err := index(pivot.Value)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	function := pivot.Aggregate
+	if function == "" {
+		function = "sum"
+	}
+
+	// Linhas na ordem da primeira ocorrência; colunas pelo texto do valor
+	type pivotRow struct {
+		keys  []interface{}
+		cells map[string]*aggregator
+	}
+	var order []*pivotRow
+	byKey := make(map[string]*pivotRow)
+	values := make(map[string]interface{})
+
+	for _, row := range rows {
+		keys := make([]interface{}, len(keyIndexes))
+		for i, k := range keyIndexes {
+			keys[i] = row[k]
+		}
+		key := recordKey(keys...)
+
+		target, exists := byKey[key]
+		if !exists {
+			target = &pivotRow{keys: keys, cells: make(map[string]*aggregator)}
+			byKey[key] = target
+			order = append(order, target)
+		}
+
+		name := pivotColumnName(row[columnIndex])
+		if _, seen := values[name]; !seen {
+			values[name] = row[columnIndex]
+		}
+
+		acc, ok := target.cells[name]
+		if !ok {
+			acc = newAggregator(function)
+			target.cells[name] = acc
+		}
+		if err := acc.add(row[valueIndex]); err != nil {
+			return nil, nil, nil, fmt.Errorf("pivot value '%s': %w", pivot.Value, err)
+		}
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sortPivotColumns(names, values)
+
+	pivoted := make([]string, 0, len(keyIndexes)+len(names))
+	for _, k := range keyIndexes {
+		pivoted = append(pivoted, columns[k])
+	}
+	for _, name := range names {
+		if slices.Contains(pivoted, name) {
+			return nil, nil, nil, fmt.Errorf("pivot column '%s' conflicts with a row field", name)
+		}
+		pivoted = append(pivoted, name)
+	}
+
+	result := make([][]interface{}, len(order))
+	for i, row := range order {
+		values := make([]interface{}, 0, len(pivoted))
+		values = append(values, row.keys...)
+		for _, name := range names {
+			if acc, ok := row.cells[name]; ok {
+				values = append(values, acc.result())
+			} else {
+				values = append(values, pivot.Fill)
+			}
+		}
+		result[i] = values
+	}
+
+	return pivoted, q.pivotInfo(info, pivoted[:len(keyIndexes)], names, columns[valueIndex], function), result, nil
+}
+
+// pivotInfo descreve as colunas do pivot: as de Rows mantêm o schema
+// original e as geradas usam o da coluna de valores (exceto em count)
+func (q *ConfigQuery) pivotInfo(info []entities.ColumnInfo, keys, names []string, valueSource, function string) []entities.ColumnInfo {
+	if len(info) == 0 {
+		return info
+	}
+
+	var value entities.ColumnInfo
+	result := make([]entities.ColumnInfo, 0, len(keys)+len(names))
+	for _, item := range info {
+		if item.Source == valueSource {
+			value = item
+		}
+	}
+	for _, key := range keys {
+		for _, item := range info {
+			if item.Source == key {
+				result = append(result, item)
+				break
+			}
+		}
+	}
+
+	for _, name := range names {
+		column := entities.ColumnInfo{Name: q.fieldName(name), Source: valueSource, Label: name}
+		if function != "count" {
+			column.Type = value.Type
+		}
+		result = append(result, column)
+	}
+	return result
+}
+
+func pivotColumnName(value interface{}) string {
+	if value == nil {
+		return "null"
+	}
+	return FormatDefault(value)
+}
+
+// sortPivotColumns ordena as colunas pelo valor original (números, datas ou
+// texto), com null por último
+func sortPivotColumns(names []string, values map[string]interface{}) {
+	sort.SliceStable(names, func(i, j int) bool {
+		a, b := values[names[i]], values[names[j]]
+		if a == nil || b == nil {
+			return a != nil
+		}
+		if cmp, ok := compareTyped(a, b); ok && cmp != 0 {
+			return cmp < 0
+		}
+		return names[i] < names[j]
+	})
+}
+
+// PivotFormatter aplica às colunas geradas pelo pivot a formatação do campo
+// de valores; columns são os nomes das colunas da resposta
+func PivotFormatter(output entities.OutputConfig, columns []string) *Formatter {
+	pivot := output.Pivot
+	format, ok := output.Formatting[pivot.Value]
+	if !ok || pivot.Aggregate == "count" {
+		return NewFormatter(output)
+	}
+
+	formatting := make(map[string]entities.ColumnFormat, len(output.Formatting)+len(columns))
+	for field, columnFormat := range output.Formatting {
+		formatting[field] = columnFormat
+	}
+	for _, column := range columns {
+		if _, exists := formatting[column]; !exists && !slices.Contains(pivot.Rows, column) {
+			formatting[column] = format
+		}
+	}
+
+	output.Formatting = formatting
+	return NewFormatter(output)
+}
+
+// checkPivot valida output.pivot no carregamento
+func checkPivot(output entities.OutputConfig) error {
+	pivot := output.Pivot
+	if len(pivot.Rows) == 0 {
+		return fmt.Errorf("at least one row field is required")
+	}
+	if pivot.Column == "" || pivot.Value == "" {
+		return fmt.Errorf("column and value are required")
+	}
+	if pivot.Aggregate != "" && !aggregateFunctions[pivot.Aggregate] {
+		return fmt.Errorf("unsupported aggregate function '%s'", pivot.Aggregate)
+	}
+
+	seen := make(map[string]bool)
+	for _, field := range append(slices.Clone(pivot.Rows), pivot.Column, pivot.Value) {
+		if seen[field] {
+			return fmt.Errorf("field '%s' is used more than once", field)
+		}
+		seen[field] = true
+	}
+
+	// Após o pivot restam apenas os campos de Rows e as colunas geradas
+	if output.Grouping != nil {
+		for _, field := range output.Grouping.By {
+			if !slices.Contains(pivot.Rows, field) {
+				return fmt.Errorf("group field '%s' must be a pivot row field", field)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,227 @@
+package query
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"reports-system/internal/domain/entities"
+)
+
+func pivotQuery(pivot *entities.PivotConfig) *ConfigQuery {
+	return NewConfigQuery(&entities.QueryConfig{Output: entities.OutputConfig{Pivot: pivot}}).(*ConfigQuery)
+}
+
+func TestPivotResults(t *testing.T) {
+	columns := []string{"regiao", "mes", "valor"}
+	// Valores do banco (int64) e do cache (json.Number) misturados
+	rows := [][]interface{}{
+		{"Sul", int64(10), int64(10)},
+		{"Sul", int64(9), int64(5)},
+		{"Norte", int64(9), int64(7)},
+		{"Sul", json.Number("10"), json.Number("1.5")},
+		{"Sul", nil, int64(3)},
+	}
+
+	tests := []struct {
+		name        string
+		pivot       *entities.PivotConfig
+		wantColumns []string
+		wantRows    [][]interface{}
+	}{
+		{
+			name:        "sum with numeric column order and null last",
+			pivot:       &entities.PivotConfig{Rows: []string{"regiao"}, Column: "mes", Value: "valor"},
+			wantColumns: []string{"regiao", "9", "10", "null"},
+			wantRows: [][]interface{}{
+				{"Sul", json.Number("5"), json.Number("11.5"), json.Number("3")},
+				{"Norte", json.Number("7"), nil, nil},
+			},
+		},
+		{
+			name:        "count with fill",
+			pivot:       &entities.PivotConfig{Rows: []string{"regiao"}, Column: "mes", Value: "valor", Aggregate: "count", Fill: int64(0)},
+			wantColumns: []string{"regiao", "9", "10", "null"},
+			wantRows: [][]interface{}{
+				{"Sul", int64(1), int64(2), int64(1)},
+				{"Norte", int64(1), int64(0), int64(0)},
+			},
+		},
+		{
+			name:        "max",
+			pivot:       &entities.PivotConfig{Rows: []string{"mes"}, Column: "regiao", Value: "valor", Aggregate: "max"},
+			wantColumns: []string{"mes", "Norte", "Sul"},
+			wantRows: [][]interface{}{
+				{int64(10), nil, int64(10)},
+				{int64(9), int64(7), int64(5)},
+				{nil, nil, int64(3)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotColumns, _, gotRows, err := pivotQuery(tt.pivot).PivotResults(columns, nil, rows)
+			if err != nil {
+				t.Fatalf("PivotResults: %v", err)
+			}
+			if !slices.Equal(gotColumns, tt.wantColumns) {
+				t.Errorf("columns = %v, want %v", gotColumns, tt.wantColumns)
+			}
+			if !reflect.DeepEqual(gotRows, tt.wantRows) {
+				t.Errorf("rows = %#v, want %#v", gotRows, tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestPivotResultsWithoutPivot(t *testing.T) {
+	columns := []string{"a"}
+	rows := [][]interface{}{{int64(1)}}
+
+	gotColumns, _, gotRows, err := pivotQuery(nil).PivotResults(columns, nil, rows)
+	if err != nil || !slices.Equal(gotColumns, columns) || !reflect.DeepEqual(gotRows, rows) {
+		t.Errorf("PivotResults = %v, %v, %v", gotColumns, gotRows, err)
+	}
+}
+
+func TestPivotResultsNormalizedRowKeys(t *testing.T) {
+	rows := [][]interface{}{
+		{int64(1), "a", int64(1)},
+		{json.Number("1"), "b", int64(2)},
+		{1.0, "a", int64(3)},
+	}
+	pivot := &entities.PivotConfig{Rows: []string{"id"}, Column: "c", Value: "v"}
+
+	_, _, got, err := pivotQuery(pivot).PivotResults([]string{"id", "c", "v"}, nil, rows)
+	if err != nil {
+		t.Fatalf("PivotResults: %v", err)
+	}
+
+	want := [][]interface{}{{int64(1), json.Number("4"), json.Number("2")}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %#v, want %#v", got, want)
+	}
+}
+
+func TestPivotResultsInfo(t *testing.T) {
+	info := []entities.ColumnInfo{
+		{Name: "Região", Source: "regiao", Type: "text", Label: "Região"},
+		{Name: "mes", Source: "mes", Type: "number", Label: "mes"},
+		{Name: "Valor", Source: "valor", Type: "decimal", Label: "Valor"},
+	}
+	rows := [][]interface{}{{"Sul", int64(1), int64(10)}, {"Sul", int64(2), int64(5)}}
+
+	tests := []struct {
+		aggregate string
+		wantType  string
+	}{
+		{"", "decimal"},
+		{"avg", "decimal"},
+		{"count", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.aggregate, func(t *testing.T) {
+			q := NewConfigQuery(&entities.QueryConfig{Output: entities.OutputConfig{
+				FieldMapping: map[string]string{"regiao": "Região", "valor": "Valor"},
+				Pivot:        &entities.PivotConfig{Rows: []string{"Região"}, Column: "mes", Value: "Valor", Aggregate: tt.aggregate},
+			}}).(*ConfigQuery)
+
+			_, got, _, err := q.PivotResults([]string{"regiao", "mes", "valor"}, info, rows)
+			if err != nil {
+				t.Fatalf("PivotResults: %v", err)
+			}
+
+			want := []entities.ColumnInfo{
+				info[0],
+				{Name: "1", Source: "valor", Type: tt.wantType, Label: "1"},
+				{Name: "2", Source: "valor", Type: tt.wantType, Label: "2"},
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("info = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestPivotResultsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		pivot *entities.PivotConfig
+		rows  [][]interface{}
+		want  string
+	}{
+		{"missing row field", &entities.PivotConfig{Rows: []string{"x"}, Column: "c", Value: "v"}, nil, "pivot field 'x' not found"},
+		{"missing value field", &entities.PivotConfig{Rows: []string{"r"}, Column: "c", Value: "x"}, nil, "pivot field 'x' not found"},
+		{"non-numeric sum", &entities.PivotConfig{Rows: []string{"r"}, Column: "c", Value: "v"}, [][]interface{}{{"a", "b", "x"}}, "pivot value 'v'"},
+		{"column conflicts with row field", &entities.PivotConfig{Rows: []string{"r"}, Column: "c", Value: "v"}, [][]interface{}{{"a", "r", int64(1)}}, "conflicts with a row field"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := pivotQuery(tt.pivot).PivotResults([]string{"r", "c", "v"}, nil, tt.rows)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("PivotResults error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPivotFormatter(t *testing.T) {
+	currency := entities.ColumnFormat{Type: "currency", Currency: "USD"}
+	columns := []string{"regiao", "9", "10"}
+
+	tests := []struct {
+		name      string
+		aggregate string
+		field     string
+		value     interface{}
+		want      string
+	}{
+		{"generated column", "sum", "10", int64(5), "$5.00"},
+		{"row field", "sum", "regiao", "Sul", "Sul"},
+		{"count is not formatted", "count", "10", int64(5), "5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := entities.OutputConfig{
+				Formatting: map[string]entities.ColumnFormat{"valor": currency},
+				Pivot:      &entities.PivotConfig{Rows: []string{"regiao"}, Column: "mes", Value: "valor", Aggregate: tt.aggregate},
+			}
+			if got, _ := PivotFormatter(output, columns).Format(tt.field, tt.value); got != tt.want {
+				t.Errorf("Format(%s) = %q, want %q", tt.field, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPivot(t *testing.T) {
+	tests := []struct {
+		name     string
+		pivot    entities.PivotConfig
+		grouping *entities.GroupingConfig
+		valid    bool
+	}{
+		{"valid", entities.PivotConfig{Rows: []string{"r"}, Column: "c", Value: "v", Aggregate: "avg"}, nil, true},
+		{"grouped by row field", entities.PivotConfig{Rows: []string{"r"}, Column: "c", Value: "v"}, &entities.GroupingConfig{By: []string{"r"}}, true},
+		{"no rows", entities.PivotConfig{Column: "c", Value: "v"}, nil, false},
+		{"no column", entities.PivotConfig{Rows: []string{"r"}, Value: "v"}, nil, false},
+		{"no value", entities.PivotConfig{Rows: []string{"r"}, Column: "c"}, nil, false},
+		{"unknown aggregate", entities.PivotConfig{Rows: []string{"r"}, Column: "c", Value: "v", Aggregate: "median"}, nil, false},
+		{"repeated field", entities.PivotConfig{Rows: []string{"r"}, Column: "r", Value: "v"}, nil, false},
+		{"grouped by generated column", entities.PivotConfig{Rows: []string{"r"}, Column: "c", Value: "v"}, &entities.GroupingConfig{By: []string{"c"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPivot(entities.OutputConfig{Pivot: &tt.pivot, Grouping: tt.grouping})
+			if valid := err == nil; valid != tt.valid {
+				t.Errorf("checkPivot valid = %v, want %v (err: %v)", valid, tt.valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// NextScheduledRun calcula a próxima execução de um agendamento de warmup a
+// partir de from. Formatos aceitos:
+//   - "@every 30m": intervalo fixo
+//   - "06:00" ou "06:00,18:30": horários diários no fuso de from
+func NextScheduledRun(schedule string, from time.Time) (time.Time, error) {
+	schedule = strings.TrimSpace(schedule)
+	if schedule == "" {
+		return time.Time{}, fmt.Errorf("schedule is empty")
+	}
+
+	if strings.HasPrefix(schedule, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(schedule, "@every ")))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid schedule interval: %w", err)
+		}
+		if interval <= 0 {
+			return time.Time{}, fmt.Errorf("schedule interval must be positive")
+		}
+		return from.Add(interval), nil
+	}
+
+	var next time.Time
+	for _, part := range strings.Split(schedule, ",") {
+		clock, err := time.Parse("15:04", strings.TrimSpace(part))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid schedule time '%s', expected HH:MM", part)
+		}
+
+		candidate := time.Date(from.Year(), from.Month(), from.Day(), clock.Hour(), clock.Minute(), 0, 0, from.Location())
+		if !candidate.After(from) {
+			candidate = candidate.AddDate(0, 0, 1)
+		}
+
+		if next.IsZero() || candidate.Before(next) {
+			next = candidate
+		}
+	}
+
+	return next, nil
+}
@@ -0,0 +1,50 @@
+package query
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextScheduledRun(t *testing.T) {
+	from := time.Date(2024, time.March, 15, 10, 30, 0, 0, time.UTC)
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2024, time.March, day, hour, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		schedule string
+		want     time.Time
+	}{
+		{"06:00", at(16, 6, 0)},
+		{"12:00", at(15, 12, 0)},
+		{"10:30", at(16, 10, 30)},
+		{"06:00, 12:30", at(15, 12, 30)},
+		{"12:30,06:00", at(15, 12, 30)},
+		{"23:59,00:00", at(15, 23, 59)},
+		{"@every 1h", at(15, 11, 30)},
+		{"@every 90m", at(15, 12, 0)},
+		{" @every 15m ", at(15, 10, 45)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.schedule, func(t *testing.T) {
+			got, err := NextScheduledRun(tt.schedule, from)
+			if err != nil {
+				t.Fatalf("NextScheduledRun: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("NextScheduledRun = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextScheduledRunErrors(t *testing.T) {
+	tests := []string{"", "6h", "25:00", "06:00,x", "@every", "@every x", "@every 0s", "@every -1h"}
+
+	for _, schedule := range tests {
+		if _, err := NextScheduledRun(schedule, time.Now()); err == nil {
+			t.Errorf("NextScheduledRun(%q) succeeded", schedule)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Blocos condicionais no SQL da configuração:
+//
+//	WHERE data >= @data_inicial
+//	{{if cliente_id}} AND cliente_id = @cliente_id {{end}}
+//	{{if not status}} AND status <> 'cancelled' {{else}} AND status = @status {{end}}
+//
+// As condições apenas testam se o parâmetro foi informado; valores nunca são
+// interpolados no texto SQL e continuam sendo enviados como parâmetros.
+type sqlNode struct {
+	text      string
+	param     string
+	negate    bool
+	then      []sqlNode
+	otherwise []sqlNode
+}
+
+func parseSQLTemplate(src string) ([]sqlNode, error) {
+	nodes, rest, tag, err := parseSQLNodes(src)
+	if err != nil {
+		return nil, err
+	}
+	if tag != "" {
+		return nil, fmt.Errorf("unexpected {{%s}}", tag)
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("unexpected trailing template content")
+	}
+	return nodes, nil
+}
+
+// parseSQLNodes lê nós até o fim do texto ou até um {{else}}/{{end}}, que é
+// retornado em tag para o chamador tratar.
+func parseSQLNodes(src string) ([]sqlNode, string, string, error) {
+	var nodes []sqlNode
+
+	for {
+		start := strings.Index(src, "{{")
+		if start < 0 {
+			if src != "" {
+				nodes = append(nodes, sqlNode{text: src})
+			}
+			return nodes, "", "", nil
+		}
+
+		if start > 0 {
+			nodes = append(nodes, sqlNode{text: src[:start]})
+		}
+
+		end := strings.Index(src[start:], "}}")
+		if end < 0 {
+			return nil, "", "", fmt.Errorf("unclosed template tag")
+		}
+
+		tag := strings.TrimSpace(src[start+2 : start+end])
+		src = src[start+end+2:]
+
+		fields := strings.Fields(tag)
+		switch {
+		case tag == "else" || tag == "end":
+			return nodes, src, tag, nil
+		case len(fields) >= 2 && fields[0] == "if":
+			node := sqlNode{param: fields[1]}
+			if fields[1] == "not" && len(fields) == 3 {
+				node.negate = true
+				node.param = fields[2]
+			} else if len(fields) != 2 {
+				return nil, "", "", fmt.Errorf("invalid template tag {{%s}}", tag)
+			}
+			node.param = strings.TrimPrefix(node.param, "@")
+
+			then, rest, closing, err := parseSQLNodes(src)
+			if err != nil {
+				return nil, "", "", err
+			}
+			node.then = then
+
+			if closing == "else" {
+				otherwise, after, closingElse, err := parseSQLNodes(rest)
+				if err != nil {
+					return nil, "", "", err
+				}
+				if closingElse != "end" {
+					return nil, "", "", fmt.Errorf("missing {{end}} for {{%s}}", tag)
+				}
+				node.otherwise = otherwise
+				rest = after
+			} else if closing != "end" {
+				return nil, "", "", fmt.Errorf("missing {{end}} for {{%s}}", tag)
+			}
+
+			nodes = append(nodes, node)
+			src = rest
+		default:
+			return nil, "", "", fmt.Errorf("invalid template tag {{%s}}", tag)
+		}
+	}
+}
+
+func renderSQLTemplate(nodes []sqlNode, params map[string]interface{}) string {
+	var out strings.Builder
+	writeSQLNodes(&out, nodes, params)
+	return out.String()
+}
+
+func writeSQLNodes(out *strings.Builder, nodes []sqlNode, params map[string]interface{}) {
+	for _, node := range nodes {
+		if node.param == "" {
+			out.WriteString(node.text)
+			continue
+		}
+
+		if paramProvided(params, node.param) != node.negate {
+			writeSQLNodes(out, node.then, params)
+		} else {
+			writeSQLNodes(out, node.otherwise, params)
+		}
+	}
+}
+
+// templateParams lista os parâmetros usados nas condições do template
+func templateParams(nodes []sqlNode) []string {
+	var names []string
+	for _, node := range nodes {
+		if node.param == "" {
+			continue
+		}
+		names = append(names, node.param)
+		names = append(names, templateParams(node.then)...)
+		names = append(names, templateParams(node.otherwise)...)
+	}
+	return names
+}
+
+func paramProvided(params map[string]interface{}, name string) bool {
+	value, exists := params[name]
+	if !exists || value == nil {
+		return false
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	}
+
+	return true
+}
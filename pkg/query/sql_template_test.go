@@ -0,0 +1,137 @@
+package query
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRenderSQLTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		params   map[string]interface{}
+		want     string
+	}{
+		{
+			name:     "plain sql",
+			template: "SELECT * FROM t WHERE a = @a",
+			params:   map[string]interface{}{"a": 1},
+			want:     "SELECT * FROM t WHERE a = @a",
+		},
+		{
+			name:     "if provided",
+			template: "WHERE 1=1{{if cliente_id}} AND cliente_id = @cliente_id{{end}}",
+			params:   map[string]interface{}{"cliente_id": 7},
+			want:     "WHERE 1=1 AND cliente_id = @cliente_id",
+		},
+		{
+			name:     "if missing",
+			template: "WHERE 1=1{{if cliente_id}} AND cliente_id = @cliente_id{{end}}",
+			params:   map[string]interface{}{},
+			want:     "WHERE 1=1",
+		},
+		{
+			name:     "at prefix",
+			template: "{{if @a}}A{{end}}",
+			params:   map[string]interface{}{"a": true},
+			want:     "A",
+		},
+		{
+			name:     "nil is missing",
+			template: "{{if a}}A{{end}}",
+			params:   map[string]interface{}{"a": nil},
+			want:     "",
+		},
+		{
+			name:     "empty string is missing",
+			template: "{{if a}}A{{end}}",
+			params:   map[string]interface{}{"a": ""},
+			want:     "",
+		},
+		{
+			name:     "empty list is missing",
+			template: "{{if a}}A{{end}}",
+			params:   map[string]interface{}{"a": []interface{}{}},
+			want:     "",
+		},
+		{
+			name:     "false is provided",
+			template: "{{if a}}A{{end}}",
+			params:   map[string]interface{}{"a": false},
+			want:     "A",
+		},
+		{
+			name:     "else branch",
+			template: "{{if status}}= @status{{else}}<> 'x'{{end}}",
+			params:   map[string]interface{}{},
+			want:     "<> 'x'",
+		},
+		{
+			name:     "if not",
+			template: "{{if not status}}<> 'x'{{else}}= @status{{end}}",
+			params:   map[string]interface{}{"status": "ok"},
+			want:     "= @status",
+		},
+		{
+			name:     "nested",
+			template: "{{if a}}A{{if b}}B{{else}}-{{end}}{{end}}.",
+			params:   map[string]interface{}{"a": 1},
+			want:     "A-.",
+		},
+		{
+			name:     "tag spacing",
+			template: "{{ if a }}A{{ end }}",
+			params:   map[string]interface{}{"a": 1},
+			want:     "A",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, err := parseSQLTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("parseSQLTemplate: %v", err)
+			}
+			if got := renderSQLTemplate(nodes, tt.params); got != tt.want {
+				t.Errorf("render = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSQLTemplateErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+	}{
+		{name: "unclosed tag", template: "{{if a}}A{{end"},
+		{name: "missing end", template: "{{if a}}A"},
+		{name: "missing end after else", template: "{{if a}}A{{else}}B"},
+		{name: "stray end", template: "A{{end}}"},
+		{name: "stray else", template: "A{{else}}B"},
+		{name: "if without param", template: "{{if}}A{{end}}"},
+		{name: "too many fields", template: "{{if a b}}A{{end}}"},
+		{name: "unknown tag", template: "{{range a}}A{{end}}"},
+		{name: "double else", template: "{{if a}}A{{else}}B{{else}}C{{end}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseSQLTemplate(tt.template); err == nil {
+				t.Errorf("parseSQLTemplate(%q) succeeded", tt.template)
+			}
+		})
+	}
+}
+
+func TestTemplateParams(t *testing.T) {
+	nodes, err := parseSQLTemplate("{{if a}}{{if not b}}x{{end}}{{else}}{{if c}}y{{end}}{{end}}{{if @d}}z{{end}}")
+	if err != nil {
+		t.Fatalf("parseSQLTemplate: %v", err)
+	}
+
+	want := []string{"a", "b", "c", "d"}
+	if got := templateParams(nodes); !slices.Equal(got, want) {
+		t.Errorf("templateParams = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,75 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"reports-system/internal/domain/entities"
+)
+
+func TestWithTimezone(t *testing.T) {
+	// 12:00 UTC é 09:00 em São Paulo e 21:00 em Tóquio
+	stored := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		report   string
+		request  string
+		timezone string
+		// fuso do parâmetro date e hora local esperada no resultado
+		paramZone  string
+		resultHour int
+		invalid    bool
+	}{
+		{"no timezone", "", "", "", "UTC", 12, false},
+		{"report timezone", "America/Sao_Paulo", "", "America/Sao_Paulo", "America/Sao_Paulo", 9, false},
+		{"request timezone", "", "Asia/Tokyo", "Asia/Tokyo", "Asia/Tokyo", 21, false},
+		{"request overrides report", "America/Sao_Paulo", "Asia/Tokyo", "Asia/Tokyo", "Asia/Tokyo", 21, false},
+		{"invalid request timezone", "", "Mars/Olympus", "", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := time.LoadLocation("America/Sao_Paulo"); err != nil {
+				t.Skip("timezone data unavailable")
+			}
+
+			q, err := NewConfigQuery(&entities.QueryConfig{
+				Timezone:   tt.report,
+				Parameters: []entities.ParamConfig{{Name: "day", Type: "date"}},
+			}).(*ConfigQuery).WithTimezone(tt.request)
+			if tt.invalid {
+				if err == nil {
+					t.Error("WithTimezone succeeded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("WithTimezone: %v", err)
+			}
+			zoned := q.(*ConfigQuery)
+
+			if got := zoned.Timezone(); got != tt.timezone {
+				t.Errorf("Timezone() = %q, want %q", got, tt.timezone)
+			}
+
+			params := map[string]interface{}{"day": "2024-03-01"}
+			if err := zoned.Validate(params); err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			day := params["day"].(time.Time)
+			if day.Location().String() != tt.paramZone || day.Hour() != 0 || day.Day() != 1 {
+				t.Errorf("day = %v, want midnight in %s", day, tt.paramZone)
+			}
+
+			records, _ := zoned.TransformResults([]string{"created_at"}, [][]interface{}{{stored}})
+			converted := records.([]map[string]interface{})[0]["created_at"].(time.Time)
+			table, _ := zoned.TransformTable([]string{"created_at"}, [][]interface{}{{stored}})
+			for _, value := range []time.Time{converted, table.Rows[0][0].(time.Time)} {
+				if !value.Equal(stored) || value.Hour() != tt.resultHour {
+					t.Errorf("result = %v, want %v at hour %d", value, stored, tt.resultHour)
+				}
+			}
+		})
+	}
+}
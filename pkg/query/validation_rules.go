@@ -0,0 +1,312 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"reports-system/internal/domain/entities"
+)
+
+// Chaves aceitas em ParamConfig.Validation. Qualquer outra chave é rejeitada
+// no carregamento da configuração.
+var validationKeys = map[string]bool{
+	"format":     true,
+	"min":        true,
+	"max":        true,
+	"min_length": true,
+	"max_length": true,
+	"regex":      true,
+	"values":     true,
+	"not_empty":  true,
+	"min_items":  true,
+	"max_items":  true,
+}
+
+var scalarParamTypes = map[string]bool{
+	"date":     true,
+	"datetime": true,
+	"string":   true,
+	"int":      true,
+	"float":    true,
+	"decimal":  true,
+	"bool":     true,
+	"enum":     true,
+}
+
+// validateRules aplica as regras de Validation a um valor que já passou pela
+// verificação de tipo. Regras textuais (regex, *_length, not_empty) usam a
+// representação em texto do valor; min/max usam a ordem natural do tipo.
+func (q *ConfigQuery) validateRules(config entities.ParamConfig, value interface{}) error {
+	if len(config.Validation) == 0 {
+		return nil
+	}
+
+	typed := coerceParam(config, value, q.location())
+	text, ok := value.(string)
+	if !ok {
+		text = canonicalValue(config, typed)
+	}
+
+	if notEmpty, ok := config.Validation["not_empty"].(bool); ok && notEmpty && strings.TrimSpace(text) == "" {
+		return paramError(config, "not_empty", value, "parameter '%s' must not be empty", config.Name)
+	}
+
+	length := utf8.RuneCountInString(text)
+	if min, ok := config.Validation["min_length"].(float64); ok && length < int(min) {
+		return paramError(config, "min_length", value, "parameter '%s' must have at least %v characters", config.Name, min)
+	}
+	if max, ok := config.Validation["max_length"].(float64); ok && length > int(max) {
+		return paramError(config, "max_length", value, "parameter '%s' must have at most %v characters", config.Name, max)
+	}
+
+	if regexStr, ok := config.Validation["regex"].(string); ok {
+		regex, err := regexp.Compile(regexStr)
+		if err != nil {
+			return fmt.Errorf("invalid regex for parameter '%s'", config.Name)
+		}
+		if !regex.MatchString(text) {
+			return paramError(config, "regex", value, "parameter '%s' does not match required pattern", config.Name)
+		}
+	}
+
+	if values, ok := config.Validation["values"].([]interface{}); ok {
+		if !containsValue(config, values, typed, q.location()) {
+			return paramError(config, "values", value, "parameter '%s' must be one of: %v", config.Name, values)
+		}
+	}
+
+	if min, ok := config.Validation["min"]; ok {
+		cmp, err := q.compareParam(config, typed, min)
+		if err != nil {
+			return err
+		}
+		if cmp < 0 {
+			return paramError(config, "min", value, "parameter '%s' must be at least %v", config.Name, min)
+		}
+	}
+
+	if max, ok := config.Validation["max"]; ok {
+		cmp, err := q.compareParam(config, typed, max)
+		if err != nil {
+			return err
+		}
+		if cmp > 0 {
+			return paramError(config, "max", value, "parameter '%s' must be at most %v", config.Name, max)
+		}
+	}
+
+	return nil
+}
+
+// paramError cria o erro estruturado de uma regra violada
+func paramError(config entities.ParamConfig, rule string, value interface{}, format string, args ...interface{}) error {
+	return &entities.FieldError{
+		Param:   config.Name,
+		Rule:    rule,
+		Message: fmt.Sprintf(format, args...),
+		Value:   value,
+	}
+}
+
+func containsValue(config entities.ParamConfig, values []interface{}, typed interface{}, loc *time.Location) bool {
+	expected := canonicalValue(config, typed)
+	for _, allowed := range values {
+		if canonicalValue(config, coerceParam(config, allowed, loc)) == expected {
+			return true
+		}
+	}
+	return false
+}
+
+// compareParam compara o valor convertido com um limite da configuração,
+// retornando -1, 0 ou 1.
+func (q *ConfigQuery) compareParam(config entities.ParamConfig, typed interface{}, bound interface{}) (int, error) {
+	switch config.Type {
+	case "int", "float", "decimal":
+		value, ok := toRat(typed)
+		limit, okLimit := toRat(bound)
+		if !ok || !okLimit {
+			return 0, fmt.Errorf("invalid numeric bound for parameter '%s'", config.Name)
+		}
+		return value.Cmp(limit), nil
+	case "date", "datetime":
+		value, ok := typed.(time.Time)
+		limit, err := q.boundTime(config, bound)
+		if !ok || err != nil {
+			return 0, fmt.Errorf("invalid date bound for parameter '%s'", config.Name)
+		}
+		return value.Compare(limit), nil
+	case "string", "enum":
+		return strings.Compare(fmt.Sprintf("%v", typed), fmt.Sprintf("%v", bound)), nil
+	default:
+		return 0, fmt.Errorf("min/max are not supported for %s parameter '%s'", config.Type, config.Name)
+	}
+}
+
+// boundTime interpreta um limite de data no layout do parâmetro ou como
+// expressão relativa (ex.: "now(-1y)")
+func (q *ConfigQuery) boundTime(config entities.ParamConfig, bound interface{}) (time.Time, error) {
+	str, ok := bound.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("date bound must be a string")
+	}
+
+	layout := paramLayout(config, dateLayouts[config.Type])
+	if isDateExpression(str) {
+		resolved, err := q.evaluateDateExpression(config, str)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.ParseInLocation(layout, resolved, q.location())
+	}
+
+	if t, err := time.ParseInLocation(layout, str, q.location()); err == nil {
+		return t, nil
+	}
+	// Limites de datetime também podem ser informados só com a data
+	return time.ParseInLocation("2006-01-02", str, q.location())
+}
+
+func toRat(value interface{}) (*big.Rat, bool) {
+	switch v := value.(type) {
+	case int:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case int64:
+		return new(big.Rat).SetInt64(v), true
+	case float64:
+		r := new(big.Rat)
+		if r.SetFloat64(v) == nil {
+			return nil, false
+		}
+		return r, true
+	case string:
+		return new(big.Rat).SetString(strings.TrimSpace(v))
+	case json.Number:
+		return new(big.Rat).SetString(string(v))
+	case *big.Rat:
+		return v, true
+	}
+	return nil, false
+}
+
+// checkParamConfig valida a declaração de um parâmetro no carregamento:
+// tipo suportado, chaves de validação conhecidas e valores coerentes.
+func (q *ConfigQuery) checkParamConfig(config entities.ParamConfig) error {
+	if config.Name == "" {
+		return fmt.Errorf("parameter name is required")
+	}
+
+	// Reservado aos placeholders das listas (ver BindNamedParams)
+	if strings.Contains(config.Name, listParamSeparator) {
+		return fmt.Errorf("parameter '%s': name cannot contain '%s'", config.Name, listParamSeparator)
+	}
+
+	paramType := config.Type
+	elemType, isList := listElementType(config.Type)
+	if isList {
+		paramType = elemType
+	}
+	if !scalarParamTypes[paramType] {
+		return fmt.Errorf("parameter '%s': unsupported type '%s'", config.Name, config.Type)
+	}
+
+	elemConfig := config
+	elemConfig.Type = paramType
+
+	for key, rule := range config.Validation {
+		if !validationKeys[key] {
+			return fmt.Errorf("parameter '%s': unknown validation rule '%s'", config.Name, key)
+		}
+
+		var err error
+		switch key {
+		case "format":
+			if _, ok := rule.(string); !ok || (paramType != "date" && paramType != "datetime") {
+				err = fmt.Errorf("'format' must be a string and is only valid for date/datetime")
+			}
+		case "min", "max":
+			if _, cmpErr := q.compareParam(elemConfig, q.sampleValue(elemConfig, rule), rule); cmpErr != nil {
+				err = cmpErr
+			}
+		case "min_length", "max_length", "min_items", "max_items":
+			if n, ok := rule.(float64); !ok || n < 0 {
+				err = fmt.Errorf("'%s' must be a non-negative number", key)
+			} else if (key == "min_items" || key == "max_items") && !isList {
+				err = fmt.Errorf("'%s' is only valid for list parameters", key)
+			}
+		case "regex":
+			regexStr, ok := rule.(string)
+			if !ok {
+				err = fmt.Errorf("'regex' must be a string")
+			} else if _, compileErr := regexp.Compile(regexStr); compileErr != nil {
+				err = fmt.Errorf("invalid regex: %w", compileErr)
+			}
+		case "values":
+			values, ok := rule.([]interface{})
+			if !ok || len(values) == 0 {
+				err = fmt.Errorf("'values' must be a non-empty array")
+				break
+			}
+			typeOnly := elemConfig
+			typeOnly.Validation = map[string]interface{}{"values": values, "format": config.Validation["format"]}
+			for _, value := range values {
+				if err = q.validateParam(typeOnly, value); err != nil {
+					break
+				}
+			}
+		case "not_empty":
+			if _, ok := rule.(bool); !ok {
+				err = fmt.Errorf("'not_empty' must be a boolean")
+			}
+		}
+
+		if err != nil {
+			return fmt.Errorf("parameter '%s': %w", config.Name, err)
+		}
+	}
+
+	if str, ok := config.Default.(string); ok && isDateExpression(str) {
+		if _, isDate := dateLayouts[paramType]; isDate {
+			if _, err := parseDateExpression(str); err != nil {
+				return fmt.Errorf("parameter '%s': invalid default: %w", config.Name, err)
+			}
+		}
+	}
+
+	if config.Options != nil {
+		if paramType != "enum" {
+			return fmt.Errorf("parameter '%s': options are only supported for enum parameters", config.Name)
+		}
+		if config.Options.Query == "" {
+			return fmt.Errorf("parameter '%s': options query is required", config.Name)
+		}
+		if config.Options.CacheTTL != "" {
+			if _, err := time.ParseDuration(config.Options.CacheTTL); err != nil {
+				return fmt.Errorf("parameter '%s': invalid options cache_ttl: %w", config.Name, err)
+			}
+		}
+	} else if paramType == "enum" {
+		if _, ok := config.Validation["values"]; !ok {
+			return fmt.Errorf("enum parameter '%s' must have 'values' validation", config.Name)
+		}
+	}
+
+	return nil
+}
+
+// sampleValue converte um limite da configuração para o tipo do parâmetro,
+// permitindo reutilizar compareParam na checagem de carregamento
+func (q *ConfigQuery) sampleValue(config entities.ParamConfig, bound interface{}) interface{} {
+	if config.Type == "date" || config.Type == "datetime" {
+		if t, err := q.boundTime(config, bound); err == nil {
+			return t
+		}
+		return nil
+	}
+	return coerceParam(config, bound, q.location())
+}
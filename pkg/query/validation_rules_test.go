@@ -0,0 +1,102 @@
+package query
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"reports-system/internal/domain/entities"
+)
+
+func TestValidateRules(t *testing.T) {
+	rules := func(paramType string, validation map[string]interface{}) entities.ParamConfig {
+		return entities.ParamConfig{Name: "p", Type: paramType, Validation: validation}
+	}
+
+	tests := []struct {
+		name   string
+		config entities.ParamConfig
+		value  interface{}
+		// regra violada; vazio quando o valor é aceito
+		rule string
+	}{
+		{"int min", rules("int", map[string]interface{}{"min": float64(1)}), "0", "min"},
+		{"int max", rules("int", map[string]interface{}{"max": float64(10)}), float64(11), "max"},
+		{"int in range", rules("int", map[string]interface{}{"min": float64(1), "max": float64(10)}), "10", ""},
+		{"float max", rules("float", map[string]interface{}{"max": 1.5}), "1.6", "max"},
+		{"float min", rules("float", map[string]interface{}{"min": 0.5}), 0.4, "min"},
+		{"decimal max keeps precision", rules("decimal", map[string]interface{}{"max": "10.00"}), "10.001", "max"},
+		{"decimal at max", rules("decimal", map[string]interface{}{"max": "10.00"}), "10", ""},
+		{"date max", rules("date", map[string]interface{}{"max": "2024-12-31"}), "2025-01-01", "max"},
+		{"date min", rules("date", map[string]interface{}{"min": "2024-01-01"}), "2023-12-31", "min"},
+		{"date min with format", rules("date", map[string]interface{}{"format": "DD/MM/YYYY", "min": "01/01/2024"}), "31/12/2023", "min"},
+		{"datetime max", rules("datetime", map[string]interface{}{"max": "2024-01-01 12:00:00"}), "2024-01-01 12:00:01", "max"},
+		{"datetime min as date", rules("datetime", map[string]interface{}{"min": "2024-01-01"}), "2024-01-01 00:00:00", ""},
+		{"date relative max", rules("date", map[string]interface{}{"max": "now(+1y)"}), "2999-01-01", "max"},
+		{"string min_length", rules("string", map[string]interface{}{"min_length": float64(3)}), "ab", "min_length"},
+		{"string max_length counts runes", rules("string", map[string]interface{}{"max_length": float64(4)}), "ação", ""},
+		{"string max_length", rules("string", map[string]interface{}{"max_length": float64(2)}), "abc", "max_length"},
+		{"string regex", rules("string", map[string]interface{}{"regex": "^[A-Z]{2}$"}), "sp", "regex"},
+		{"int regex on text", rules("int", map[string]interface{}{"regex": "^[0-9]{4}$"}), float64(2024), ""},
+		{"not_empty", rules("string", map[string]interface{}{"not_empty": true}), "  ", "not_empty"},
+		{"string values", rules("string", map[string]interface{}{"values": []interface{}{"a", "b"}}), "c", "values"},
+		{"int values from text", rules("int", map[string]interface{}{"values": []interface{}{float64(1), float64(2)}}), "2", ""},
+		{"string min", rules("string", map[string]interface{}{"min": "b"}), "a", "min"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewConfigQuery(&entities.QueryConfig{}).(*ConfigQuery)
+			err := q.validateRules(tt.config, tt.value)
+
+			var fieldErr *entities.FieldError
+			switch {
+			case tt.rule == "" && err != nil:
+				t.Errorf("validateRules(%v): %v", tt.value, err)
+			case tt.rule != "" && !errors.As(err, &fieldErr):
+				t.Errorf("validateRules(%v) = %v, want %s error", tt.value, err, tt.rule)
+			case tt.rule != "" && fieldErr.Rule != tt.rule:
+				t.Errorf("rule = %s, want %s", fieldErr.Rule, tt.rule)
+			}
+		})
+	}
+}
+
+func TestCheckParamConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config entities.ParamConfig
+		// trecho esperado na mensagem; vazio quando a configuração é válida
+		err string
+	}{
+		{"valid", entities.ParamConfig{Name: "p", Type: "int", Validation: map[string]interface{}{"min": float64(1), "max": float64(5)}}, ""},
+		{"unknown rule", entities.ParamConfig{Name: "p", Type: "int", Validation: map[string]interface{}{"minimum": float64(1)}}, "unknown validation rule 'minimum'"},
+		{"unsupported type", entities.ParamConfig{Name: "p", Type: "number"}, "unsupported type"},
+		{"format on string", entities.ParamConfig{Name: "p", Type: "string", Validation: map[string]interface{}{"format": "YYYY"}}, "'format'"},
+		{"negative length", entities.ParamConfig{Name: "p", Type: "string", Validation: map[string]interface{}{"max_length": float64(-1)}}, "non-negative"},
+		{"items on scalar", entities.ParamConfig{Name: "p", Type: "string", Validation: map[string]interface{}{"max_items": float64(3)}}, "only valid for list"},
+		{"items on list", entities.ParamConfig{Name: "p", Type: "list<string>", Validation: map[string]interface{}{"max_items": float64(3)}}, ""},
+		{"invalid regex", entities.ParamConfig{Name: "p", Type: "string", Validation: map[string]interface{}{"regex": "("}}, "invalid regex"},
+		{"invalid numeric bound", entities.ParamConfig{Name: "p", Type: "int", Validation: map[string]interface{}{"max": "ten"}}, "invalid numeric bound"},
+		{"invalid date bound", entities.ParamConfig{Name: "p", Type: "date", Validation: map[string]interface{}{"min": "yesterday"}}, "invalid date bound"},
+		{"min on bool", entities.ParamConfig{Name: "p", Type: "bool", Validation: map[string]interface{}{"min": true}}, "not supported"},
+		{"values of other type", entities.ParamConfig{Name: "p", Type: "int", Validation: map[string]interface{}{"values": []interface{}{"a"}}}, "must be an integer"},
+		{"enum without values", entities.ParamConfig{Name: "p", Type: "enum"}, "must have 'values'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewConfigQuery(&entities.QueryConfig{}).(*ConfigQuery)
+			err := q.checkParamConfig(tt.config)
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("checkParamConfig: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("checkParamConfig error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package query
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Tipos do banco (DatabaseTypeName) agrupados pela conversão aplicada ao
+// valor lido. Nomes de Postgres (lib/pq) e SQL Server (go-mssqldb).
+var (
+	decimalColumnTypes = map[string]bool{
+		"NUMERIC": true, "DECIMAL": true, "MONEY": true, "SMALLMONEY": true,
+	}
+	binaryColumnTypes = map[string]bool{
+		"BYTEA": true, "BINARY": true, "VARBINARY": true, "IMAGE": true, "TIMESTAMP": true, "ROWVERSION": true,
+	}
+	jsonColumnTypes = map[string]bool{
+		"JSON": true, "JSONB": true,
+	}
+
+	jsonNumberPattern = regexp.MustCompile(`^-?(0|[1-9]\d*)(\.\d+)?$`)
+)
+
+const (
+	DecimalsAsNumber = "number"
+	DecimalsAsString = "string"
+)
+
+// ValueMapper normaliza os valores lidos do banco a partir do tipo de cada
+// coluna, para que o resultado seja igual entre os providers:
+//   - decimais viram números exatos (json.Number) ou texto
+//   - UUIDs viram texto canônico (xxxxxxxx-xxxx-...)
+//   - bytes de colunas textuais viram string; colunas binárias são mantidas
+//   - colunas JSON/JSONB viram objetos
+//   - DATE vira "2006-01-02" e TIME "15:04:05", sem fuso
+type ValueMapper struct {
+	types    []string
+	decimals string
+}
+
+func NewValueMapper(columnTypes []*sql.ColumnType, decimals string) *ValueMapper {
+	types := make([]string, len(columnTypes))
+	for i, columnType := range columnTypes {
+		types[i] = strings.ToUpper(columnType.DatabaseTypeName())
+	}
+	if decimals == "" {
+		decimals = DecimalsAsNumber
+	}
+	return &ValueMapper{types: types, decimals: decimals}
+}
+
+// MapRow normaliza os valores da linha no lugar
+func (m *ValueMapper) MapRow(row []interface{}) {
+	for i := range row {
+		if i < len(m.types) {
+			row[i] = m.MapValue(m.types[i], row[i])
+		}
+	}
+}
+
+func (m *ValueMapper) MapValue(dbType string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return m.mapBytes(dbType, v)
+	case string:
+		if decimalColumnTypes[dbType] {
+			return m.mapDecimal(v)
+		}
+		if jsonColumnTypes[dbType] {
+			return mapJSON([]byte(v))
+		}
+		return v
+	case time.Time:
+		switch dbType {
+		case "DATE":
+			return v.Format("2006-01-02")
+		case "TIME":
+			return v.Format("15:04:05.999999999")
+		}
+		return v
+	}
+	return value
+}
+
+func (m *ValueMapper) mapBytes(dbType string, value []byte) interface{} {
+	switch {
+	case dbType == "UNIQUEIDENTIFIER":
+		return sqlServerUUID(value)
+	case dbType == "UUID" && len(value) == 16:
+		return formatUUID(value)
+	case decimalColumnTypes[dbType]:
+		return m.mapDecimal(string(value))
+	case jsonColumnTypes[dbType]:
+		return mapJSON(value)
+	case binaryColumnTypes[dbType]:
+		// Cópia: o driver pode reutilizar o buffer
+		return append([]byte(nil), value...)
+	}
+	return string(value)
+}
+
+func (m *ValueMapper) mapDecimal(value string) interface{} {
+	value = strings.TrimSpace(value)
+	// MONEY no Postgres vem formatado (ex.: "$1,234.50") e NUMERIC pode ser
+	// "NaN"; nesses casos o texto é mantido
+	if m.decimals == DecimalsAsString || !jsonNumberPattern.MatchString(value) {
+		return value
+	}
+	return json.Number(value)
+}
+
+func mapJSON(value []byte) interface{} {
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return string(value)
+	}
+	return decoded
+}
+
+// sqlServerUUID converte o uniqueidentifier do SQL Server, cujos três
+// primeiros grupos são armazenados em little-endian
+func sqlServerUUID(value []byte) interface{} {
+	if len(value) != 16 {
+		return string(value)
+	}
+
+	b := append([]byte(nil), value...)
+	b[0], b[1], b[2], b[3] = b[3], b[2], b[1], b[0]
+	b[4], b[5] = b[5], b[4]
+	b[6], b[7] = b[7], b[6]
+	return formatUUID(b)
+}
+
+func formatUUID(b []byte) string {
+	s := hex.EncodeToString(b)
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
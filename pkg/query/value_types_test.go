@@ -0,0 +1,78 @@
+package query
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestValueMapper(t *testing.T) {
+	uuid := []byte{0x6f, 0x9a, 0x1c, 0x2e, 0x3b, 0x4d, 0x4e, 0x5f, 0x80, 0x91, 0xa2, 0xb3, 0xc4, 0xd5, 0xe6, 0xf7}
+	// O SQL Server armazena os três primeiros grupos em little-endian
+	mssqlUUID := []byte{0x2e, 0x1c, 0x9a, 0x6f, 0x4d, 0x3b, 0x5f, 0x4e, 0x80, 0x91, 0xa2, 0xb3, 0xc4, 0xd5, 0xe6, 0xf7}
+	moment := time.Date(2024, 3, 1, 10, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		dbType   string
+		decimals string
+		value    interface{}
+		want     interface{}
+	}{
+		{"nil", "NUMERIC", "", nil, nil},
+		{"numeric bytes", "NUMERIC", "", []byte("1234.50"), json.Number("1234.50")},
+		{"numeric text", "DECIMAL", "", "-0.001", json.Number("-0.001")},
+		{"numeric as string", "NUMERIC", DecimalsAsString, []byte("1234.50"), "1234.50"},
+		{"numeric NaN", "NUMERIC", "", []byte("NaN"), "NaN"},
+		{"formatted money", "MONEY", "", []byte("$1,234.50"), "$1,234.50"},
+		{"postgres uuid bytes", "UUID", "", uuid, "6f9a1c2e-3b4d-4e5f-8091-a2b3c4d5e6f7"},
+		{"postgres uuid text", "UUID", "", "6f9a1c2e-3b4d-4e5f-8091-a2b3c4d5e6f7", "6f9a1c2e-3b4d-4e5f-8091-a2b3c4d5e6f7"},
+		{"sqlserver uniqueidentifier", "UNIQUEIDENTIFIER", "", mssqlUUID, "6f9a1c2e-3b4d-4e5f-8091-a2b3c4d5e6f7"},
+		{"text bytes", "TEXT", "", []byte("São Paulo"), "São Paulo"},
+		{"varchar bytes", "VARCHAR", "", []byte("abc"), "abc"},
+		{"binary kept", "BYTEA", "", []byte{0x00, 0xff}, []byte{0x00, 0xff}},
+		{"jsonb bytes", "JSONB", "", []byte(`{"a": [1, 2.5]}`), map[string]interface{}{"a": []interface{}{json.Number("1"), json.Number("2.5")}}},
+		{"json text", "JSON", "", `["x"]`, []interface{}{"x"}},
+		{"invalid json kept", "JSON", "", []byte(`{`), "{"},
+		{"date", "DATE", "", moment, "2024-03-01"},
+		{"time", "TIME", "", moment, "10:30:15"},
+		{"timestamp kept", "TIMESTAMPTZ", "", moment, moment},
+		{"int kept", "INT8", "", int64(7), int64(7)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decimals := tt.decimals
+			if decimals == "" {
+				decimals = DecimalsAsNumber
+			}
+			mapper := &ValueMapper{decimals: decimals}
+			if got := mapper.MapValue(tt.dbType, tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MapValue(%s, %v) = %#v, want %#v", tt.dbType, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValueMapperMapRow(t *testing.T) {
+	mapper := &ValueMapper{types: []string{"NUMERIC", "TEXT"}, decimals: DecimalsAsNumber}
+	buffer := []byte("10.5")
+	row := []interface{}{buffer, []byte("a"), []byte("extra")}
+
+	mapper.MapRow(row)
+
+	want := []interface{}{json.Number("10.5"), "a", []byte("extra")}
+	if !reflect.DeepEqual(row, want) {
+		t.Errorf("MapRow = %#v, want %#v", row, want)
+	}
+
+	// Bytes binários são copiados, pois o driver pode reutilizar o buffer
+	binary := &ValueMapper{types: []string{"BYTEA"}, decimals: DecimalsAsNumber}
+	row = []interface{}{buffer}
+	binary.MapRow(row)
+	buffer[0] = 'X'
+	if string(row[0].([]byte)) != "10.5" {
+		t.Errorf("binary value shares the driver buffer: %q", row[0])
+	}
+}
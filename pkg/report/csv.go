@@ -0,0 +1,56 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+type csvRenderer struct{}
+
+func (csvRenderer) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (csvRenderer) Extension() string {
+	return "csv"
+}
+
+// Render grava as seções em sequência; com mais de uma seção, cada uma é
+// precedida pelo nome e separada por uma linha em branco
+func (csvRenderer) Render(w io.Writer, doc *Document) error {
+	writer := csv.NewWriter(w)
+
+	for i, section := range doc.Sections {
+		if len(doc.Sections) > 1 {
+			if i > 0 {
+				if err := writer.Write(nil); err != nil {
+					return err
+				}
+			}
+			if err := writer.Write([]string{section.Name}); err != nil {
+				return err
+			}
+		}
+
+		header := make([]string, len(section.Columns))
+		for j, column := range section.Columns {
+			header[j] = column.Label
+		}
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+
+		for _, row := range section.Rows {
+			record := make([]string, len(row.Cells))
+			for j, cell := range row.Cells {
+				record[j] = cell.Text
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
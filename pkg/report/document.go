@@ -0,0 +1,197 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"reports-system/internal/domain/entities"
+	"reports-system/pkg/query"
+)
+
+// Document é o relatório pronto para renderização: seções com colunas e
+// células já formatadas para exibição
+type Document struct {
+	Title       string
+	GeneratedAt time.Time
+	Sections    []Section
+}
+
+type Section struct {
+	Name    string
+	Columns []Column
+	Rows    []Row
+}
+
+type Column struct {
+	Name  string
+	Label string
+}
+
+// Row é uma linha da seção. Kind distingue as linhas de dados das linhas de
+// subtotal e total, destacadas pelos renderers.
+type Row struct {
+	Kind  RowKind
+	Cells []Cell
+}
+
+type RowKind int
+
+const (
+	RowData RowKind = iota
+	RowSubtotal
+	RowTotal
+)
+
+// Cell guarda o valor original e o texto de exibição. Numeric indica um valor
+// numérico sem formatação, gravado como número no XLSX.
+type Cell struct {
+	Value   interface{}
+	Text    string
+	Numeric bool
+}
+
+// NewSection monta uma seção a partir dos dados da resposta, nos formatos
+// records (lista de objetos) ou table, inclusive quando lidos do cache. A
+// ordem das colunas segue o schema da resposta; sem schema, a da tabela ou
+// a ordem alfabética dos campos.
+func NewSection(name string, columns []entities.ColumnInfo, data interface{}, formatter *query.Formatter) (Section, error) {
+	section := Section{Name: name}
+
+	names, rows, err := tabulate(data)
+	if err != nil {
+		return section, err
+	}
+
+	section.Columns = sectionColumns(columns, names)
+	for _, values := range rows {
+		section.Rows = append(section.Rows, dataRow(section.Columns, values, formatter))
+	}
+
+	return section, nil
+}
+
+// sectionColumns usa a ordem e os rótulos do schema da resposta, quando
+// presente, ou os nomes encontrados nos dados
+func sectionColumns(columns []entities.ColumnInfo, names []string) []Column {
+	if len(columns) > 0 {
+		result := make([]Column, len(columns))
+		for i, column := range columns {
+			label := column.Label
+			if label == "" {
+				label = column.Name
+			}
+			result[i] = Column{Name: column.Name, Label: label}
+		}
+		return result
+	}
+
+	result := make([]Column, len(names))
+	for i, name := range names {
+		result[i] = Column{Name: name, Label: name}
+	}
+	return result
+}
+
+func dataRow(columns []Column, values rowValues, formatter *query.Formatter) Row {
+	row := Row{Cells: make([]Cell, len(columns))}
+	for i, column := range columns {
+		value := values(column.Name)
+		text, formatted := formatter.Format(column.Name, value)
+		row.Cells[i] = Cell{Value: value, Text: text, Numeric: !formatted && isNumber(value)}
+	}
+	return row
+}
+
+// rowValues retorna o valor de uma coluna da linha pelo nome
+type rowValues func(name string) interface{}
+
+func tabulate(data interface{}) ([]string, []rowValues, error) {
+	switch v := data.(type) {
+	case nil:
+		return nil, nil, nil
+	case *entities.TableData:
+		return v.Columns, tableRows(v.Columns, v.Rows), nil
+	case []map[string]interface{}:
+		records := make([]interface{}, len(v))
+		for i, record := range v {
+			records[i] = record
+		}
+		return tabulateRecords(records)
+	case []interface{}:
+		return tabulateRecords(v)
+	case map[string]interface{}:
+		// Formato table lido do cache (JSON)
+		var table entities.TableData
+		if err := redecode(v, &table); err != nil || table.Columns == nil {
+			return nil, nil, fmt.Errorf("unsupported report data")
+		}
+		return table.Columns, tableRows(table.Columns, table.Rows), nil
+	}
+	return nil, nil, fmt.Errorf("unsupported report data type %T", data)
+}
+
+// redecode converte dados genéricos lidos do cache (JSON) para o tipo de
+// destino, preservando os números exatos
+func redecode(data interface{}, target interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	return decoder.Decode(target)
+}
+
+func tableRows(columns []string, rows [][]interface{}) []rowValues {
+	index := make(map[string]int, len(columns))
+	for i, column := range columns {
+		index[column] = i
+	}
+
+	result := make([]rowValues, len(rows))
+	for i, row := range rows {
+		result[i] = func(name string) interface{} {
+			if j, ok := index[name]; ok && j < len(row) {
+				return row[j]
+			}
+			return nil
+		}
+	}
+	return result
+}
+
+func tabulateRecords(records []interface{}) ([]string, []rowValues, error) {
+	seen := make(map[string]bool)
+	var names []string
+	result := make([]rowValues, len(records))
+
+	for i, item := range records {
+		record, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("unsupported report row type %T", item)
+		}
+		for name := range record {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+		result[i] = func(name string) interface{} {
+			return record[name]
+		}
+	}
+
+	sort.Strings(names)
+	return names, result, nil
+}
+
+func isNumber(value interface{}) bool {
+	switch value.(type) {
+	case int, int32, int64, float32, float64, json.Number:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+
+	"reports-system/internal/domain/entities"
+	"reports-system/pkg/query"
+)
+
+// NewGroupedSection monta a seção de um resultado agrupado (output.grouping):
+// as linhas de cada grupo seguidas das linhas de subtotal e, ao final, do
+// total geral. Cada agregado fica na coluna do campo agregado; agregados do
+// mesmo campo ocupam linhas de subtotal adicionais.
+func NewGroupedSection(name string, columns []entities.ColumnInfo, data interface{}, grouping entities.GroupingConfig, formatter *query.Formatter) (Section, error) {
+	section := Section{Name: name}
+
+	grouped, err := groupedData(data)
+	if err != nil {
+		return section, err
+	}
+
+	section.Columns = sectionColumns(columns, groupedNames(grouped.Groups))
+
+	builder := &groupedBuilder{section: &section, aggregates: grouping.Aggregates, formatter: formatter}
+	builder.groups(grouped.Groups)
+	builder.totals(RowTotal, "Total", grouped.Totals)
+
+	return section, nil
+}
+
+func groupedData(data interface{}) (*entities.GroupedData, error) {
+	switch v := data.(type) {
+	case *entities.GroupedData:
+		return v, nil
+	case map[string]interface{}:
+		// Resultado agrupado lido do cache (JSON)
+		var grouped entities.GroupedData
+		if err := redecode(v, &grouped); err != nil {
+			return nil, fmt.Errorf("unsupported grouped report data")
+		}
+		return &grouped, nil
+	}
+	return nil, fmt.Errorf("unsupported grouped report data type %T", data)
+}
+
+// groupedNames retorna, em ordem alfabética, os campos das linhas dos grupos
+func groupedNames(groups []entities.Group) []string {
+	seen := make(map[string]bool)
+	var names []string
+
+	var collect func(groups []entities.Group)
+	collect = func(groups []entities.Group) {
+		for _, group := range groups {
+			collect(group.Groups)
+			for _, record := range group.Rows {
+				for name := range record {
+					if !seen[name] {
+						seen[name] = true
+						names = append(names, name)
+					}
+				}
+			}
+		}
+	}
+	collect(groups)
+
+	sort.Strings(names)
+	return names
+}
+
+type groupedBuilder struct {
+	section    *Section
+	aggregates []entities.AggregateConfig
+	formatter  *query.Formatter
+}
+
+func (b *groupedBuilder) groups(groups []entities.Group) {
+	for _, group := range groups {
+		b.groups(group.Groups)
+		for _, record := range group.Rows {
+			b.section.Rows = append(b.section.Rows, dataRow(b.section.Columns, func(name string) interface{} {
+				return record[name]
+			}, b.formatter))
+		}
+
+		value, _ := b.formatter.Format(group.Field, group.Value)
+		b.totals(RowSubtotal, "Subtotal: "+value, group.Totals)
+	}
+}
+
+// totals adiciona as linhas de agregados, com o rótulo na primeira coluna
+// livre
+func (b *groupedBuilder) totals(kind RowKind, label string, totals map[string]interface{}) {
+	columns := b.section.Columns
+	var rows []Row
+	var used []map[int]bool
+
+	for _, aggregate := range b.aggregates {
+		index := -1
+		for i, column := range columns {
+			if column.Name == aggregate.Field {
+				index = i
+				break
+			}
+		}
+		// Campo fora das colunas exibidas (ex.: seleção de campos)
+		if index < 0 {
+			continue
+		}
+
+		r := 0
+		for r < len(rows) && used[r][index] {
+			r++
+		}
+		if r == len(rows) {
+			rows = append(rows, Row{Kind: kind, Cells: make([]Cell, len(columns))})
+			used = append(used, make(map[int]bool))
+		}
+
+		rows[r].Cells[index] = b.aggregateCell(aggregate, totals[query.AggregateKey(aggregate)])
+		used[r][index] = true
+	}
+
+	if len(rows) == 0 {
+		rows = append(rows, Row{Kind: kind, Cells: make([]Cell, len(columns))})
+		used = append(used, make(map[int]bool))
+	}
+	for i := range columns {
+		if !used[0][i] {
+			rows[0].Cells[i] = Cell{Text: label}
+			break
+		}
+	}
+
+	b.section.Rows = append(b.section.Rows, rows...)
+}
+
+// aggregateCell formata o agregado como o campo; exceto na soma, o texto
+// indica a função (ex.: "avg: 12.5")
+func (b *groupedBuilder) aggregateCell(aggregate entities.AggregateConfig, value interface{}) Cell {
+	var text string
+	formatted := false
+	if aggregate.Function == "count" {
+		text = query.FormatDefault(value)
+	} else {
+		text, formatted = b.formatter.Format(aggregate.Field, value)
+	}
+
+	if value == nil && text == "" {
+		return Cell{}
+	}
+	if aggregate.Function != "sum" {
+		return Cell{Value: value, Text: aggregate.Function + ": " + text}
+	}
+	return Cell{Value: value, Text: text, Numeric: !formatted && isNumber(value)}
+}
@@ -0,0 +1,80 @@
+package report
+
+import (
+	"html/template"
+	"io"
+)
+
+// htmlRenderer gera uma página HTML autocontida com uma tabela por seção
+type htmlRenderer struct{}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"rowClass": func(kind RowKind) string {
+		switch kind {
+		case RowSubtotal:
+			return "subtotal"
+		case RowTotal:
+			return "total"
+		}
+		return ""
+	},
+	"numeric": func(cell Cell) bool {
+		return cell.Numeric || isNumber(cell.Value)
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; font-size: 13px; margin: 24px; }
+table { border-collapse: collapse; margin-bottom: 24px; }
+th, td { border-bottom: 1px solid #ddd; padding: 4px 8px; text-align: left; }
+th { background: #f3f3f3; }
+td.num { text-align: right; }
+tr.subtotal, tr.total { font-weight: bold; }
+tr.total td { border-top: 2px solid #999; }
+</style>
+</head>
+<body>
+{{- if .Title}}
+<h1>{{.Title}}</h1>
+{{- end}}
+{{- if not .GeneratedAt.IsZero}}
+<p>{{.GeneratedAt.Format "2006-01-02 15:04:05 MST"}}</p>
+{{- end}}
+{{- $multiple := gt (len .Sections) 1}}
+{{- range .Sections}}
+<section>
+{{- if and $multiple .Name}}
+<h2>{{.Name}}</h2>
+{{- end}}
+<table>
+<thead><tr>{{range .Columns}}<th>{{.Label}}</th>{{end}}</tr></thead>
+<tbody>
+{{- range .Rows}}
+<tr{{with rowClass .Kind}} class="{{.}}"{{end}}>{{range .Cells}}<td{{if numeric .}} class="num"{{end}}>{{.Text}}</td>{{end}}</tr>
+{{- end}}
+</tbody>
+</table>
+</section>
+{{- end}}
+</body>
+</html>
+`))
+
+func (htmlRenderer) ContentType() string {
+	return "text/html; charset=utf-8"
+}
+
+func (htmlRenderer) Extension() string {
+	return "html"
+}
+
+func (htmlRenderer) Inline() bool {
+	return true
+}
+
+func (htmlRenderer) Render(w io.Writer, doc *Document) error {
+	return htmlTemplate.Execute(w, doc)
+}
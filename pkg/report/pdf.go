@@ -0,0 +1,257 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// pdfRenderer gera um PDF simples (A4 paisagem, Helvetica) com as seções em
+// tabelas, repetindo o cabeçalho a cada página. Os textos usam a codificação
+// WinAnsi das fontes padrão; caracteres fora dela viram "?".
+type pdfRenderer struct{}
+
+const (
+	pdfPageWidth  = 842.0
+	pdfPageHeight = 595.0
+	pdfMargin     = 36.0
+	pdfFontSize   = 8.0
+	pdfTitleSize  = 12.0
+	pdfLineHeight = 12.0
+	pdfCellPad    = 4.0
+	// Largura média aproximada de um caractere Helvetica, em unidades do
+	// tamanho da fonte
+	pdfCharWidth = 0.52
+)
+
+func (pdfRenderer) ContentType() string {
+	return "application/pdf"
+}
+
+func (pdfRenderer) Extension() string {
+	return "pdf"
+}
+
+func (pdfRenderer) Render(w io.Writer, doc *Document) error {
+	layout := &pdfLayout{}
+	layout.newPage()
+
+	if doc.Title != "" {
+		layout.text(pdfMargin, doc.Title, "F2", pdfTitleSize)
+		layout.y -= pdfTitleSize + 4
+	}
+	if !doc.GeneratedAt.IsZero() {
+		layout.text(pdfMargin, doc.GeneratedAt.Format("2006-01-02 15:04:05 MST"), "F1", pdfFontSize)
+		layout.y -= pdfLineHeight
+	}
+
+	for _, section := range doc.Sections {
+		layout.section(section, len(doc.Sections) > 1)
+	}
+
+	_, err := w.Write(layout.document())
+	return err
+}
+
+// pdfLayout acumula o conteúdo das páginas e a posição vertical atual
+type pdfLayout struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+func (l *pdfLayout) newPage() {
+	l.pages = append(l.pages, &bytes.Buffer{})
+	l.y = pdfPageHeight - pdfMargin
+}
+
+func (l *pdfLayout) page() *bytes.Buffer {
+	return l.pages[len(l.pages)-1]
+}
+
+func (l *pdfLayout) text(x float64, text, font string, size float64) {
+	fmt.Fprintf(l.page(), "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, l.y-size, pdfString(text))
+}
+
+func (l *pdfLayout) line(y float64) {
+	fmt.Fprintf(l.page(), "0.5 w %.2f %.2f m %.2f %.2f l S\n", pdfMargin, y, pdfPageWidth-pdfMargin, y)
+}
+
+func (l *pdfLayout) section(section Section, showName bool) {
+	widths := columnWidths(section)
+
+	// Nome da seção e cabeçalho não ficam sozinhos no fim da página
+	if l.y-3*pdfLineHeight < pdfMargin {
+		l.newPage()
+	}
+	l.y -= pdfLineHeight / 2
+	if showName && section.Name != "" {
+		l.text(pdfMargin, section.Name, "F2", pdfTitleSize-2)
+		l.y -= pdfLineHeight + 2
+	}
+
+	header := func() {
+		cells := make([]Cell, len(section.Columns))
+		for i, column := range section.Columns {
+			cells[i] = Cell{Text: column.Label}
+		}
+		l.row(cells, widths, "F2")
+		l.line(l.y + 2)
+	}
+	header()
+
+	for _, row := range section.Rows {
+		if l.y-pdfLineHeight < pdfMargin {
+			l.newPage()
+			header()
+		}
+		// Subtotais e total em negrito, o total separado por uma linha
+		font := "F1"
+		if row.Kind != RowData {
+			font = "F2"
+		}
+		if row.Kind == RowTotal {
+			l.line(l.y + 1)
+		}
+		l.row(row.Cells, widths, font)
+	}
+}
+
+func (l *pdfLayout) row(cells []Cell, widths []float64, font string) {
+	x := pdfMargin
+	for i, cell := range cells {
+		if i >= len(widths) {
+			break
+		}
+		text := fitText(cell.Text, widths[i]-2*pdfCellPad)
+		offset := pdfCellPad
+		if cell.Numeric || isNumber(cell.Value) {
+			// Números alinhados à direita
+			offset = widths[i] - pdfCellPad - textWidth(text)
+		}
+		l.text(x+offset, text, font, pdfFontSize)
+		x += widths[i]
+	}
+	l.y -= pdfLineHeight
+}
+
+// columnWidths distribui a largura útil proporcionalmente ao maior texto de
+// cada coluna (considerando as primeiras linhas)
+func columnWidths(section Section) []float64 {
+	widths := make([]float64, len(section.Columns))
+	for i, column := range section.Columns {
+		widths[i] = textWidth(column.Label)
+	}
+	for r, row := range section.Rows {
+		if r >= 200 {
+			break
+		}
+		for i, cell := range row.Cells {
+			if i < len(widths) {
+				widths[i] = max(widths[i], textWidth(cell.Text))
+			}
+		}
+	}
+
+	available := pdfPageWidth - 2*pdfMargin
+	total := 0.0
+	for i := range widths {
+		widths[i] += 2 * pdfCellPad
+		total += widths[i]
+	}
+	if total > available {
+		for i := range widths {
+			widths[i] = widths[i] * available / total
+		}
+	}
+	return widths
+}
+
+func textWidth(text string) float64 {
+	return float64(len([]rune(text))) * pdfFontSize * pdfCharWidth
+}
+
+// fitText corta o texto que não cabe na largura da coluna
+func fitText(text string, width float64) string {
+	runes := []rune(text)
+	limit := int(width / (pdfFontSize * pdfCharWidth))
+	if len(runes) <= limit {
+		return text
+	}
+	if limit <= 1 {
+		return ""
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+// document monta o arquivo: catálogo, árvore de páginas, fontes e, para cada
+// página, o objeto da página e seu conteúdo
+func (l *pdfLayout) document() []byte {
+	var out bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	kids := make([]string, len(l.pages))
+	for i := range l.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(l.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range l.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// Caracteres WinAnsi fora do Latin-1
+var winAnsiExtras = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+	'\u202f': 0xa0,
+}
+
+// pdfString codifica o texto em WinAnsi e escapa os caracteres especiais de
+// strings PDF
+func pdfString(text string) string {
+	var buf bytes.Buffer
+	for _, r := range text {
+		var b byte
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			buf.WriteByte('\\')
+			b = byte(r)
+		case r == '\n' || r == '\r' || r == '\t':
+			b = ' '
+		case r < 0x80 || (r >= 0xa0 && r <= 0xff):
+			b = byte(r)
+		default:
+			extra, ok := winAnsiExtras[r]
+			if !ok {
+				extra = '?'
+			}
+			b = extra
+		}
+		buf.WriteByte(b)
+	}
+	return buf.String()
+}
@@ -0,0 +1,32 @@
+package report
+
+import (
+	"io"
+	"strings"
+)
+
+// Renderer gera o arquivo de um formato de exportação
+type Renderer interface {
+	ContentType() string
+	Extension() string
+	Render(w io.Writer, doc *Document) error
+}
+
+// InlineRenderer é implementado pelos renderers cujo arquivo é exibido pelo
+// navegador (Content-Disposition: inline) em vez de baixado
+type InlineRenderer interface {
+	Inline() bool
+}
+
+var renderers = map[string]Renderer{
+	"csv":  csvRenderer{},
+	"xlsx": xlsxRenderer{},
+	"pdf":  pdfRenderer{},
+	"html": htmlRenderer{},
+}
+
+// Get retorna o renderer do formato (csv, xlsx, pdf, html)
+func Get(format string) (Renderer, bool) {
+	renderer, ok := renderers[strings.ToLower(format)]
+	return renderer, ok
+}
@@ -0,0 +1,389 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"reports-system/internal/domain/entities"
+	"reports-system/pkg/query"
+)
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		format string
+		found  bool
+		inline bool
+	}{
+		{"csv", true, false},
+		{"CSV", true, false},
+		{"xlsx", true, false},
+		{"pdf", true, false},
+		{"html", true, true},
+		{"json", false, false},
+		{"", false, false},
+	}
+
+	for _, tt := range tests {
+		renderer, found := Get(tt.format)
+		if found != tt.found {
+			t.Errorf("Get(%q) found = %v, want %v", tt.format, found, tt.found)
+			continue
+		}
+		if !found {
+			continue
+		}
+		inline, _ := renderer.(InlineRenderer)
+		if got := inline != nil && inline.Inline(); got != tt.inline {
+			t.Errorf("Get(%q) inline = %v, want %v", tt.format, got, tt.inline)
+		}
+	}
+}
+
+func TestNewSection(t *testing.T) {
+	formatter := query.NewFormatter(entities.OutputConfig{
+		Formatting: map[string]entities.ColumnFormat{"valor": {Type: "currency", Currency: "USD"}},
+	})
+	schema := []entities.ColumnInfo{{Name: "valor", Label: "Valor"}, {Name: "id"}}
+
+	tests := []struct {
+		name    string
+		columns []entities.ColumnInfo
+		data    interface{}
+		header  []string
+		rows    [][]string
+		numeric []bool
+	}{
+		{
+			name:    "records with schema",
+			columns: schema,
+			data:    []map[string]interface{}{{"id": int64(1), "valor": 10.5}},
+			header:  []string{"Valor", "id"},
+			rows:    [][]string{{"$10.50", "1"}},
+			numeric: []bool{false, true},
+		},
+		{
+			name:    "records without schema",
+			data:    []interface{}{map[string]interface{}{"b": "x", "a": nil}, map[string]interface{}{"c": true}},
+			header:  []string{"a", "b", "c"},
+			rows:    [][]string{{"", "x", ""}, {"", "", "true"}},
+			numeric: []bool{false, false, false},
+		},
+		{
+			name:    "table",
+			data:    &entities.TableData{Columns: []string{"id", "nome"}, Rows: [][]interface{}{{int64(1), "a"}, {int64(2)}}},
+			header:  []string{"id", "nome"},
+			rows:    [][]string{{"1", "a"}, {"2", ""}},
+			numeric: []bool{true, false},
+		},
+		{
+			name:    "table from cache",
+			columns: schema,
+			data:    map[string]interface{}{"columns": []interface{}{"id", "valor"}, "rows": []interface{}{[]interface{}{json.Number("7"), json.Number("1.25")}}},
+			header:  []string{"Valor", "id"},
+			rows:    [][]string{{"$1.25", "7"}},
+			numeric: []bool{false, true},
+		},
+		{
+			name:   "empty",
+			data:   nil,
+			header: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			section, err := NewSection("s", tt.columns, tt.data, formatter)
+			if err != nil {
+				t.Fatalf("NewSection: %v", err)
+			}
+
+			header := make([]string, len(section.Columns))
+			for i, column := range section.Columns {
+				header[i] = column.Label
+			}
+			if strings.Join(header, "|") != strings.Join(tt.header, "|") {
+				t.Errorf("header = %v, want %v", header, tt.header)
+			}
+
+			if len(section.Rows) != len(tt.rows) {
+				t.Fatalf("got %d rows, want %d", len(section.Rows), len(tt.rows))
+			}
+			for i, row := range section.Rows {
+				for j, cell := range row.Cells {
+					if cell.Text != tt.rows[i][j] {
+						t.Errorf("row %d cell %d = %q, want %q", i, j, cell.Text, tt.rows[i][j])
+					}
+					if i == 0 && cell.Numeric != tt.numeric[j] {
+						t.Errorf("cell %d numeric = %v, want %v", j, cell.Numeric, tt.numeric[j])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestNewSectionUnsupported(t *testing.T) {
+	formatter := query.NewFormatter(entities.OutputConfig{})
+	for _, data := range []interface{}{"text", []interface{}{1}, map[string]interface{}{"x": 1}} {
+		if _, err := NewSection("s", nil, data, formatter); err == nil {
+			t.Errorf("NewSection(%#v) succeeded", data)
+		}
+	}
+}
+
+func TestNewGroupedSection(t *testing.T) {
+	grouping := entities.GroupingConfig{
+		By: []string{"cliente"},
+		Aggregates: []entities.AggregateConfig{
+			{Function: "sum", Field: "valor"},
+			{Function: "avg", Field: "valor"},
+		},
+	}
+	totals := map[string]interface{}{"sum_valor": int64(30), "avg_valor": 15.0}
+	data := &entities.GroupedData{
+		Groups: []entities.Group{{
+			Field:  "cliente",
+			Value:  "A",
+			Count:  2,
+			Totals: totals,
+			Rows: []map[string]interface{}{
+				{"cliente": "A", "valor": int64(10)},
+				{"cliente": "A", "valor": int64(20)},
+			},
+		}},
+		Totals: totals,
+	}
+
+	// O mesmo resultado lido do cache (JSON)
+	encoded, _ := json.Marshal(data)
+	var cached map[string]interface{}
+	json.Unmarshal(encoded, &cached)
+
+	want := []struct {
+		kind  RowKind
+		cells []string
+	}{
+		{RowData, []string{"A", "10"}},
+		{RowData, []string{"A", "20"}},
+		{RowSubtotal, []string{"Subtotal: A", "30"}},
+		{RowSubtotal, []string{"", "avg: 15"}},
+		{RowTotal, []string{"Total", "30"}},
+		{RowTotal, []string{"", "avg: 15"}},
+	}
+
+	for name, input := range map[string]interface{}{"grouped": data, "cached": cached} {
+		t.Run(name, func(t *testing.T) {
+			section, err := NewGroupedSection("s", nil, input, grouping, query.NewFormatter(entities.OutputConfig{}))
+			if err != nil {
+				t.Fatalf("NewGroupedSection: %v", err)
+			}
+			if len(section.Rows) != len(want) {
+				t.Fatalf("got %d rows, want %d", len(section.Rows), len(want))
+			}
+			for i, row := range section.Rows {
+				cells := make([]string, len(row.Cells))
+				for j, cell := range row.Cells {
+					cells[j] = cell.Text
+				}
+				if row.Kind != want[i].kind || strings.Join(cells, "|") != strings.Join(want[i].cells, "|") {
+					t.Errorf("row %d = %v %v, want %v %v", i, row.Kind, cells, want[i].kind, want[i].cells)
+				}
+			}
+		})
+	}
+}
+
+func testDocument(sections ...Section) *Document {
+	return &Document{Title: "Vendas <2024>", Sections: sections}
+}
+
+func testSection(name string) Section {
+	return Section{
+		Name:    name,
+		Columns: []Column{{Name: "nome", Label: "Nome"}, {Name: "valor", Label: "Valor"}},
+		Rows: []Row{
+			{Cells: []Cell{{Value: "a, \"b\"", Text: "a, \"b\""}, {Value: int64(42), Text: "42", Numeric: true}}},
+			{Kind: RowTotal, Cells: []Cell{{Text: "Total"}, {Value: int64(42), Text: "$42.00"}}},
+		},
+	}
+}
+
+func TestCSVRender(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  *Document
+		want string
+	}{
+		{
+			name: "single section",
+			doc:  testDocument(testSection("vendas")),
+			want: "Nome,Valor\n\"a, \"\"b\"\"\",42\nTotal,$42.00\n",
+		},
+		{
+			name: "multiple sections",
+			doc:  testDocument(testSection("a"), testSection("b")),
+			want: "a\nNome,Valor\n\"a, \"\"b\"\"\",42\nTotal,$42.00\n\nb\nNome,Valor\n\"a, \"\"b\"\"\",42\nTotal,$42.00\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := (csvRenderer{}).Render(&buf, tt.doc); err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Render =\n%q\nwant\n%q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLRender(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (htmlRenderer{}).Render(&buf, testDocument(testSection("<script>"), testSection("b"))); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"<title>Vendas &lt;2024&gt;</title>",
+		"<h2>&lt;script&gt;</h2>",
+		`<td class="num">42</td>`,
+		`<tr class="total"><td>Total</td><td class="num">$42.00</td></tr>`,
+		"<td>a, &#34;b&#34;</td>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+	if strings.Contains(out, "<script>") {
+		t.Error("section name was not escaped")
+	}
+}
+
+func TestXLSXRender(t *testing.T) {
+	var buf bytes.Buffer
+	doc := testDocument(testSection("vendas/2024"), testSection("vendas/2024"))
+	if err := (xlsxRenderer{}).Render(&buf, doc); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+	files := make(map[string]string)
+	for _, file := range archive.File {
+		rc, _ := file.Open()
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[file.Name] = string(data)
+	}
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"xl/workbook.xml", `<sheet name="vendas_2024" sheetId="1" r:id="rId1"/>`},
+		{"xl/workbook.xml", `<sheet name="vendas_2024 (2)" sheetId="2" r:id="rId2"/>`},
+		{"xl/worksheets/sheet1.xml", `<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">Nome</t></is></c>`},
+		{"xl/worksheets/sheet1.xml", `<c r="B2" s="0"><v>42</v></c>`},
+		{"xl/worksheets/sheet1.xml", `<c r="B3" s="1" t="inlineStr"><is><t xml:space="preserve">$42.00</t></is></c>`},
+		{"xl/worksheets/sheet2.xml", `<row r="1">`},
+		{"[Content_Types].xml", `/xl/worksheets/sheet2.xml`},
+	}
+
+	for _, tt := range tests {
+		if !strings.Contains(files[tt.file], tt.want) {
+			t.Errorf("%s does not contain %q", tt.file, tt.want)
+		}
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, "A"}, {25, "Z"}, {26, "AA"}, {27, "AB"}, {701, "ZZ"}, {702, "AAA"},
+	}
+
+	for _, tt := range tests {
+		if got := columnName(tt.index); got != tt.want {
+			t.Errorf("columnName(%d) = %q, want %q", tt.index, got, tt.want)
+		}
+	}
+}
+
+func TestSheetNames(t *testing.T) {
+	long := strings.Repeat("x", 40)
+	tests := []struct {
+		name     string
+		sections []string
+		want     []string
+	}{
+		{"invalid characters", []string{"a[b]:c*?/\\"}, []string{"a_b__c____"}},
+		{"empty name", []string{"", ""}, []string{"Sheet1", "Sheet2"}},
+		{"duplicates ignore case", []string{"Vendas", "vendas"}, []string{"Vendas", "vendas (2)"}},
+		{"truncated", []string{long, long}, []string{strings.Repeat("x", 31), strings.Repeat("x", 27) + " (2)"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections := make([]Section, len(tt.sections))
+			for i, name := range tt.sections {
+				sections[i] = Section{Name: name}
+			}
+			got := sheetNames(sections)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("sheetNames = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPDFRender(t *testing.T) {
+	section := testSection("vendas")
+	for i := 0; i < 100; i++ {
+		section.Rows = append(section.Rows, section.Rows[0])
+	}
+
+	var buf bytes.Buffer
+	if err := (pdfRenderer{}).Render(&buf, testDocument(section)); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "%PDF-1.4") || !strings.HasSuffix(out, "%%EOF\n") {
+		t.Error("output is not a PDF document")
+	}
+	// 100 linhas não cabem em uma página A4 paisagem
+	if !strings.Contains(out, "/Count 2") && !strings.Contains(out, "/Count 3") {
+		t.Error("rows were not split across pages")
+	}
+}
+
+func TestPDFString(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"abc", "abc"},
+		{"(a)\\", "\\(a\\)\\\\"},
+		{"a\nb", "a b"},
+		{"ação", "a\xe7\xe3o"},
+		{"€", "\x80"},
+		{"日本", "??"},
+	}
+
+	for _, tt := range tests {
+		if got := pdfString(tt.text); got != tt.want {
+			t.Errorf("pdfString(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// xlsxRenderer gera uma planilha Office Open XML mínima (uma aba por seção),
+// sem dependências externas. Valores formatados são gravados como texto e
+// números sem formatação como células numéricas.
+type xlsxRenderer struct{}
+
+const (
+	xlsxMainNS = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	xlsxRelNS  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	xlsxPkgNS  = "http://schemas.openxmlformats.org/package/2006/relationships"
+
+	// Estilos definidos em xlsxStyles
+	xlsxStyleNormal = 0
+	xlsxStyleBold   = 1
+)
+
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="` + xlsxMainNS + `">
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>
+</styleSheet>`
+
+func (xlsxRenderer) ContentType() string {
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+
+func (xlsxRenderer) Extension() string {
+	return "xlsx"
+}
+
+func (xlsxRenderer) Render(w io.Writer, doc *Document) error {
+	sections := doc.Sections
+	if len(sections) == 0 {
+		sections = []Section{{Name: doc.Title}}
+	}
+
+	names := sheetNames(sections)
+	archive := zip.NewWriter(w)
+
+	var contentTypes, sheets, rels strings.Builder
+	for i := range sections {
+		n := i + 1
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&sheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(names[i]), n, n)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="%s/worksheet" Target="worksheets/sheet%d.xml"/>`, n, xlsxRelNS, n)
+	}
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="%s/styles" Target="styles.xml"/>`, len(sections)+1, xlsxRelNS)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+` + contentTypes.String() + `</Types>`},
+		{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="` + xlsxPkgNS + `"><Relationship Id="rId1" Type="` + xlsxRelNS + `/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="` + xlsxMainNS + `" xmlns:r="` + xlsxRelNS + `"><sheets>` + sheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="` + xlsxPkgNS + `">` + rels.String() + `</Relationships>`},
+		{"xl/styles.xml", xlsxStyles},
+	}
+
+	for _, file := range files {
+		part, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(part, file.content); err != nil {
+			return err
+		}
+	}
+
+	for i, section := range sections {
+		part, err := archive.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1))
+		if err != nil {
+			return err
+		}
+		if err := writeSheet(part, section); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+func writeSheet(w io.Writer, section Section) error {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`)
+	buf.WriteString(`<worksheet xmlns="` + xlsxMainNS + `"><sheetData>`)
+
+	header := make([]Cell, len(section.Columns))
+	for i, column := range section.Columns {
+		header[i] = Cell{Text: column.Label}
+	}
+	writeSheetRow(&buf, 1, header, xlsxStyleBold)
+
+	for i, row := range section.Rows {
+		style := xlsxStyleNormal
+		if row.Kind != RowData {
+			style = xlsxStyleBold
+		}
+		writeSheetRow(&buf, i+2, row.Cells, style)
+	}
+
+	buf.WriteString(`</sheetData></worksheet>`)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeSheetRow(buf *bytes.Buffer, number int, cells []Cell, style int) {
+	fmt.Fprintf(buf, `<row r="%d">`, number)
+	for i, cell := range cells {
+		ref := columnName(i) + strconv.Itoa(number)
+		if cell.Numeric {
+			fmt.Fprintf(buf, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, xmlEscape(cell.Text))
+			continue
+		}
+		if cell.Text == "" {
+			continue
+		}
+		fmt.Fprintf(buf, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, xmlEscape(cell.Text))
+	}
+	buf.WriteString(`</row>`)
+}
+
+// columnName converte o índice da coluna para a letra da planilha (A, B, ..., AA)
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// sheetNames gera nomes de abas válidos: até 31 caracteres, sem []:*?/\ e
+// sem repetição
+func sheetNames(sections []Section) []string {
+	names := make([]string, len(sections))
+	used := make(map[string]bool)
+
+	for i, section := range sections {
+		name := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`[]:*?/\`, r) {
+				return '_'
+			}
+			return r
+		}, section.Name)
+		if name == "" {
+			name = fmt.Sprintf("Sheet%d", i+1)
+		}
+
+		base := []rune(name)
+		if len(base) > 31 {
+			base = base[:31]
+		}
+		name = string(base)
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			suffix := fmt.Sprintf(" (%d)", n)
+			trimmed := base
+			if len(trimmed)+len(suffix) > 31 {
+				trimmed = trimmed[:31-len(suffix)]
+			}
+			name = string(trimmed) + suffix
+		}
+
+		used[strings.ToLower(name)] = true
+		names[i] = name
+	}
+
+	return names
+}
+
+func xmlEscape(text string) string {
+	var buf strings.Builder
+	xml.EscapeText(&buf, []byte(text))
+	return buf.String()
+}